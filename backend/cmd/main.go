@@ -3,10 +3,24 @@ package main
 import (
 	"backend/internal/config"
 	"backend/internal/dao"
+	"backend/internal/dao/chain"
+	"backend/internal/dao/minio"
+	"backend/internal/dao/mongo"
+	"backend/internal/dao/mysql"
+	"backend/internal/dao/redis"
 	"backend/internal/model"
 	"backend/internal/router"
+	"backend/internal/service"
 	"backend/internal/util"
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -23,6 +37,12 @@ func main() {
 	cfg := config.LoadConfig()
 	util.Info("配置文件加载成功", zap.String("config", "config.yaml"))
 
+	// 指纹 HMAC 密钥
+	if cfg.Fingerprint.Secret == "" {
+		util.Warn("未配置指纹密钥 fingerprint.secret，指纹可被伪造")
+	}
+	util.SetFingerprintKey(cfg.Fingerprint.Secret)
+
 	// 初始化数据库
 	repo, err := dao.InitRepositories()
 	if err != nil {
@@ -32,29 +52,118 @@ func main() {
 
 	// 自动迁移数据库
 	err = repo.MySQL.AutoMigrate(&model.User{}, &model.UserStats{}, &model.WalletChange{},
-		&model.Dataset{}, &model.Outbox{}, &model.Favorite{}, &model.Transaction{}, &model.DownloadRecord{}, &model.DetectRecord{})
+		&model.Dataset{}, &model.Outbox{}, &model.Favorite{}, &model.Transaction{}, &model.DownloadRecord{}, &model.DetectRecord{},
+		&model.ChainCheckpoint{}, &model.ChainEvent{}, &model.DownloadJob{}, &model.DetectJob{}, &model.Tag{}, &model.DatasetTag{},
+		&model.DatasetVersion{}, &model.DatasetEdit{}, &model.DatasetValidation{}, &model.UploadSession{}, &model.DatasetDuplicate{})
 	if err != nil {
 		util.Error("AutoMigrate failed", zap.Error(err))
 	} else {
 		util.Info("AutoMigrate success")
 	}
+	if err := mysql.NewDatasetDAO(repo.MySQL).DropSupersededSortIndexes(); err != nil {
+		util.Error("删除旧的数据集排序索引失败", zap.Error(err))
+	}
+
+	// 迁移旧数据集逗号分隔的标签到 tags / dataset_tags 表
+	if n, err := service.NewTagService(mysql.NewTagDAO(repo.MySQL), repo.MySQL).MigrateLegacyTags(); err != nil {
+		util.Error("迁移数据集标签失败", zap.Error(err))
+	} else if n > 0 {
+		util.Info("迁移数据集标签成功", zap.Int("数据集数量", n))
+	}
+
+	// 为版本化之前上传的数据集补建初始版本
+	if n, err := mysql.NewDatasetDAO(repo.MySQL).BackfillInitialVersions(); err != nil {
+		util.Error("补建数据集初始版本失败", zap.Error(err))
+	} else if n > 0 {
+		util.Info("补建数据集初始版本成功", zap.Int64("数据集数量", n))
+	}
+
+	// 收到退出信号时取消，后台任务据此停止并将执行中的任务重新排队
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var workers sync.WaitGroup
+	runWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(ctx)
+		}()
+	}
+
+	// 启动异步任务调度
+	outboxService := service.NewOutboxService(mysql.NewOutboxDAO(repo.MySQL), mongo.NewDatasetsPreviewDAO(repo.Mongo),
+		minio.NewAdminMinioDAO(repo.MinIO, cfg.MinIO.Buckets[util.DATASET_BUCKET]),
+		time.Duration(cfg.Outbox.Interval)*time.Second, cfg.Outbox.BatchSize, cfg.Outbox.MaxAttempts, time.Duration(cfg.Outbox.BaseBackoff)*time.Second)
+	runWorker(outboxService.Run)
+
+	// 启动付费下载任务 worker（租约过期的执行中任务会被重新认领）
+	downloadJobService := service.NewDownloadJobService(mysql.NewDownloadJobDAO(repo.MySQL),
+		minio.NewDatasetMinioDAO(repo.MinIO, repo.MinIOCore, cfg.MinIO.Buckets[util.DATASET_BUCKET], cfg.MinIO.Buckets[util.DATASET_TEMP_BUCKET]),
+		cfg.Download.Workers, time.Duration(cfg.Download.JobTimeout)*time.Minute, time.Duration(cfg.Download.PollInterval)*time.Millisecond)
+	runWorker(downloadJobService.Run)
+
+	// 启动指纹检测任务 worker
+	detectJobService := service.NewDetectJobService(mysql.NewDetectJobDAO(repo.MySQL), mysql.NewAdminDAO(repo.MySQL),
+		minio.NewAdminMinioDAO(repo.MinIO, cfg.MinIO.Buckets[util.DATASET_BUCKET]),
+		cfg.Detect.Workers, time.Duration(cfg.Detect.JobTimeout)*time.Minute, time.Duration(cfg.Detect.PollInterval)*time.Millisecond)
+	runWorker(detectJobService.Run)
+
+	// 数据集搜索索引（各服务与后台任务共用）
+	searchIndex := service.NewSearchIndex(repo.MySQL, cfg.Search.Engine)
+
+	// 启动数据集文件校验任务 worker（校验结束后更新数据集状态和搜索索引）
+	validationService := service.NewDatasetValidationService(mysql.NewDatasetValidationDAO(repo.MySQL), mysql.NewDatasetDAO(repo.MySQL), mongo.NewDatasetsPreviewDAO(repo.Mongo),
+		minio.NewAdminMinioDAO(repo.MinIO, cfg.MinIO.Buckets[util.DATASET_BUCKET]), redis.NewRankRedisDAO(repo.Redis), searchIndex, cfg.MinIO.Buckets[util.DATASET_BUCKET],
+		cfg.Validate.Workers, time.Duration(cfg.Validate.JobTimeout)*time.Minute, time.Duration(cfg.Validate.PollInterval)*time.Millisecond, cfg.Validate.MaxErrorRate)
+	runWorker(validationService.Run)
+
+	// 定时清理被放弃的分片上传和未使用的数据集文件
+	uploadSweepService := service.NewUploadSweepService(mysql.NewUploadSessionDAO(repo.MySQL), mysql.NewDatasetDAO(repo.MySQL), mongo.NewDatasetsPreviewDAO(repo.Mongo),
+		minio.NewDatasetMinioDAO(repo.MinIO, repo.MinIOCore, cfg.MinIO.Buckets[util.DATASET_BUCKET], cfg.MinIO.Buckets[util.DATASET_TEMP_BUCKET]),
+		time.Duration(cfg.Upload.SweepInterval)*time.Minute)
+	runWorker(uploadSweepService.Run)
+
+	// 启动链上事件同步
+	if cfg.Chain.Indexer.Enabled && cfg.Chain.RPCURL != "" {
+		indexer := service.NewChainIndexerService(
+			chain.NewContractDAO(chain.NewRPCClient(cfg.Chain.RPCURL), cfg.Chain.ContractAddress),
+			mysql.NewChainIndexerDAO(repo.MySQL), mysql.NewUserStatsDAO(repo.MySQL), redis.NewUserRedisDAO(repo.Redis), redis.NewRankRedisDAO(repo.Redis),
+			searchIndex, cfg.Chain.Indexer.StartBlock, cfg.Chain.Indexer.Confirmations, cfg.Chain.Indexer.BatchSize,
+			time.Duration(cfg.Chain.Indexer.Interval)*time.Second,
+		)
+		runWorker(indexer.Run)
+	}
 
 	r := gin.New()
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Range", "If-Range"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Range", "Accept-Ranges", "ETag", "Content-Disposition", "X-Checksum-Sha256"},
 		AllowCredentials: true,
 	})) // 跨域
 
 	// 初始化路由
-	router.InitRouter(r, repo, cfg)
+	router.InitRouter(r, repo, cfg, searchIndex)
 
 	addr := fmt.Sprintf(":%s", cfg.Server.Port)
-	if err := r.Run(addr); err != nil {
+	srv := &http.Server{Addr: addr, Handler: r}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			util.Error("服务关闭失败", zap.Error(err))
+		}
+	}()
+	util.Info("服务启动", zap.String("port", addr))
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		util.Error("服务启动失败", zap.Error(err))
 	}
-	util.Info("服务启动成功", zap.String("port", addr))
+
+	// 等待后台任务停止，执行中的任务重新排队
+	stop()
+	workers.Wait()
+	util.Info("服务已退出")
 }
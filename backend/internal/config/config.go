@@ -60,6 +60,63 @@ type Config struct {
 		VerifyCodeExpire int    `json:"verify_code_expire"`
 		SendLimit        int    `json:"send_limit"`
 	} `json:"email"`
+
+	Outbox struct {
+		Interval    int // 轮询间隔（秒）
+		BatchSize   int // 每次认领的任务数
+		MaxAttempts int // 最大执行次数，超过后进入 failed
+		BaseBackoff int // 首次重试间隔（秒），之后指数增长
+	} `json:"outbox"`
+
+	Download struct {
+		Workers      int  // 付费下载任务 worker 数
+		JobTimeout   int  // 单个任务超时（分钟）
+		PollInterval int  // 认领任务的轮询间隔（毫秒）
+		Streaming    bool // 开启流式下载接口，直接将带指纹文件写入响应
+	} `json:"download"`
+
+	Fingerprint struct {
+		Secret string // v2 指纹 HMAC 密钥，防止伪造他人的购买 ID；更换后旧文件中的指纹无法再验证
+	} `json:"fingerprint"`
+
+	Search struct {
+		Engine string // mysql（默认，FULLTEXT ngram 索引）或 memory（进程内倒排索引）
+	} `json:"search"`
+
+	Detect struct {
+		Workers      int // 指纹检测任务 worker 数
+		JobTimeout   int // 单个任务超时（分钟）
+		PollInterval int // 认领任务的轮询间隔（毫秒）
+	} `json:"detect"`
+
+	Validate struct {
+		Workers      int     // 数据集文件校验任务 worker 数
+		JobTimeout   int     // 单个任务超时（分钟）
+		PollInterval int     // 认领任务的轮询间隔（毫秒）
+		MaxErrorRate float64 // 格式错误或编码问题的记录占比上限，超过则校验不通过
+	} `json:"validate"`
+
+	Upload struct {
+		PartSize      int // 默认分片大小（MB）
+		SessionTTL    int // 分片上传会话有效期（小时），超过未完成则中断上传
+		OrphanTTL     int // 上传完成后未创建数据集或发布版本的文件保留时间（小时）
+		SweepInterval int // 清理被放弃的上传的间隔（分钟）
+	} `json:"upload"`
+
+	Chain struct {
+		RPCURL          string // 以太坊节点 JSON-RPC 地址
+		ContractAddress string // AiDatasets 合约地址
+		VerifyPurchase  bool   // 付费下载时是否额外校验链上 hasPurchased
+
+		// 链上事件同步
+		Indexer struct {
+			Enabled       bool
+			StartBlock    uint64 // 首次同步的起始区块（合约部署区块）
+			Confirmations uint64 // 确认深度，只处理已确认的区块
+			BatchSize     uint64 // 单次 eth_getLogs 的区块数
+			Interval      int    // 轮询间隔（秒）
+		} `json:"indexer"`
+	} `json:"chain"`
 }
 
 var cfg *Config
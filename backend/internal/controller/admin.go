@@ -4,17 +4,21 @@ import (
 	"backend/internal/model"
 	"backend/internal/service"
 	"backend/internal/util"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"net/http"
 	"strconv"
 )
 
 type AdminController struct {
-	adminService *service.AdminService
+	adminService  *service.AdminService
+	outboxService *service.OutboxService
 }
 
-func NewAdminController(adminService *service.AdminService) *AdminController {
-	return &AdminController{adminService: adminService}
+func NewAdminController(adminService *service.AdminService, outboxService *service.OutboxService) *AdminController {
+	return &AdminController{adminService: adminService, outboxService: outboxService}
 }
 
 // 获取统计数据
@@ -166,26 +170,28 @@ func (ad AdminController) AddUser(c *gin.Context) {
 
 // 获取数据集列表
 func (ad AdminController) GetDatasets(c *gin.Context) {
-	pageStr := c.DefaultQuery("page", "1")
-	limitStr := c.DefaultQuery("limit", "10")
-	page, _ := strconv.Atoi(pageStr)
-	limit, _ := strconv.Atoi(limitStr)
+	pq, ok := parsePageQuery(c)
+	if !ok {
+		return
+	}
+	q, ok := parseDatasetQuery(c)
+	if !ok {
+		return
+	}
 
-	datasets, total, totalPages, err := ad.adminService.GetDatasets(page, limit)
+	datasets, info, err := ad.adminService.GetDatasets(pq, q)
 	if err != nil {
 		util.Error("获取数据集列表失败", zap.Error(err))
+		if isPageQueryError(err) {
+			util.BadRequest(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, err.Error())
 		return
 	}
 
 	util.Info("获取数据集列表成功", zap.Int("数据集总数", len(datasets)))
-	util.Success(c, 200, gin.H{
-		"items":      datasets,
-		"total":      total,
-		"totalPages": totalPages,
-		"page":       page,
-		"limit":      limit,
-	})
+	util.Success(c, 200, pageResponse(datasets, pq, info))
 }
 
 // 删除数据集
@@ -241,10 +247,14 @@ func (ad AdminController) FingerprintDetect(c *gin.Context) {
 		return
 	}
 
-	// 检测指纹
-	fingerprint, err := ad.adminService.FingerprintDetect(file)
+	// 检测指纹，format 为空时按文件扩展名推断
+	fingerprint, err := ad.adminService.FingerprintDetect(file, c.PostForm("format"))
 	if err != nil {
 		util.Error("指纹检测失败", zap.Error(err))
+		if errors.Is(err, util.ErrUnsupportedFormat) {
+			util.BadRequest(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, err.Error())
 		return
 	}
@@ -255,16 +265,114 @@ func (ad AdminController) FingerprintDetect(c *gin.Context) {
 	})
 }
 
+// 创建异步指纹检测任务：multipart 上传 file，或提交 bucket/objectName 检测 MinIO 中已有的对象
+func (ad AdminController) CreateDetectJob(c *gin.Context) {
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		util.Error("用户未登录", zap.String("userID", userIDStr.(string)))
+		util.Unauthorized(c, "用户未登录")
+		return
+	}
+	adminID := userIDStr.(uint)
+
+	var jobID uint
+	var err error
+	if file, ferr := c.FormFile("file"); ferr == nil {
+		jobID, err = ad.adminService.CreateUploadDetectJob(adminID, file, c.PostForm("format"))
+	} else {
+		var req model.CreateDetectJobRequest
+		if err := c.ShouldBind(&req); err != nil {
+			util.Error("参数格式错误", zap.Error(err))
+			util.BadRequest(c, "参数格式错误: "+err.Error())
+			return
+		}
+		jobID, err = ad.adminService.CreateObjectDetectJob(adminID, &req)
+	}
+	if err != nil {
+		util.Error("创建检测任务失败", zap.Error(err))
+		switch {
+		case errors.Is(err, util.ErrUnsupportedFormat), errors.Is(err, service.ErrBucketNotAllowed):
+			util.BadRequest(c, err.Error())
+		case errors.Is(err, service.ErrDetectObjectNotFound):
+			util.NotFound(c, err.Error())
+		default:
+			util.InternalServerError(c, err.Error())
+		}
+		return
+	}
+
+	util.Info("创建检测任务成功", zap.Uint("jobId", jobID))
+	util.Success(c, 200, gin.H{
+		"jobId":   jobID,
+		"message": "检测任务已创建",
+	})
+}
+
+// 获取检测任务状态
+func (ad AdminController) GetDetectJob(c *gin.Context) {
+	jobIDStr := c.Param("jobId")
+	jobID, err := strconv.Atoi(jobIDStr)
+	if err != nil {
+		util.Error("参数错误", zap.String("jobID", jobIDStr))
+		util.BadRequest(c, "参数错误")
+		return
+	}
+
+	status, err := ad.adminService.GetDetectJob(uint(jobID))
+	if err != nil {
+		util.Error("获取检测任务状态失败", zap.Error(err))
+		if errors.Is(err, service.ErrDetectJobNotFound) {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.InternalServerError(c, err.Error())
+		return
+	}
+	util.Success(c, 200, status)
+}
+
+// 取消检测任务
+func (ad AdminController) CancelDetectJob(c *gin.Context) {
+	jobIDStr := c.Param("jobId")
+	jobID, err := strconv.Atoi(jobIDStr)
+	if err != nil {
+		util.Error("参数错误", zap.String("jobID", jobIDStr))
+		util.BadRequest(c, "参数错误")
+		return
+	}
+
+	if err = ad.adminService.CancelDetectJob(uint(jobID)); err != nil {
+		util.Error("取消检测任务失败", zap.Error(err))
+		if errors.Is(err, service.ErrDetectJobNotFound) {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.InternalServerError(c, err.Error())
+		return
+	}
+
+	util.Info("取消检测任务成功", zap.String("jobID", jobIDStr))
+	util.Success(c, 200, gin.H{
+		"message": "检测任务已取消",
+	})
+}
+
 // 获取指纹检测记录
 func (ad AdminController) GetDetectRecords(c *gin.Context) {
-	pageStr := c.DefaultQuery("page", "1")
-	limitStr := c.DefaultQuery("limit", "10")
-	page, _ := strconv.Atoi(pageStr)
-	limit, _ := strconv.Atoi(limitStr)
+	var q model.DetectRecordQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		util.Error("参数错误", zap.Error(err))
+		util.BadRequest(c, "参数错误")
+		return
+	}
 
-	detectionRecords, total, totalPages, err := ad.adminService.GetDetectRecords(page, limit)
+	detectionRecords, total, totalPages, err := ad.adminService.GetDetectRecords(&q)
 	if err != nil {
 		util.Error("获取指纹检测记录失败", zap.Error(err))
+		if errors.Is(err, service.ErrInvalidDetectRecordQuery) {
+			util.BadRequest(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, err.Error())
 		return
 	}
@@ -274,11 +382,53 @@ func (ad AdminController) GetDetectRecords(c *gin.Context) {
 		"items":      detectionRecords,
 		"total":      total,
 		"totalPages": totalPages,
-		"page":       page,
-		"limit":      limit,
+		"page":       q.Page,
+		"limit":      q.Limit,
 	})
 }
 
+// 导出指纹匹配报告（format=json|html）
+func (ad AdminController) ExportDetectReport(c *gin.Context) {
+	recordIDStr := c.Param("recordId")
+	recordID, err := strconv.Atoi(recordIDStr)
+	if err != nil {
+		util.Error("参数错误", zap.String("recordID", recordIDStr))
+		util.BadRequest(c, "参数错误")
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "html" {
+		util.BadRequest(c, "不支持的导出格式")
+		return
+	}
+
+	export, err := ad.adminService.ExportDetectReport(recordID)
+	if err != nil {
+		util.Error("获取指纹匹配报告失败", zap.Error(err))
+		if errors.Is(err, service.ErrDetectRecordNotFound) {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.InternalServerError(c, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("detect_report_%d.%s", recordID, format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("X-Report-SHA256", export.Digest)
+	if format == "json" {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", export.JSON)
+	} else {
+		c.Status(http.StatusOK)
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		if err := export.WriteHTML(c.Writer); err != nil {
+			util.Error("渲染指纹匹配报告失败", zap.Error(err))
+			return
+		}
+	}
+	util.Info("导出指纹匹配报告成功", zap.Int("recordID", recordID), zap.String("format", format))
+}
+
 // 获取minio buckets
 func (ad AdminController) GetMinioBuckets(c *gin.Context) {
 	buckets, err := ad.adminService.GetMinioBuckets()
@@ -327,12 +477,175 @@ func (ad AdminController) DeleteMinioObject(c *gin.Context) {
 	})
 }
 
-// 处理异步删除任务
+// 处理异步删除任务（立即执行一批到期任务，后台调度同样会处理）
 func (ad AdminController) ProcessOutboxTasks(c *gin.Context) {
-	ad.adminService.ProcessOutboxTasks()
+	n, err := ad.outboxService.Dispatch()
+	if err != nil {
+		util.Error("处理异步删除任务失败", zap.Error(err))
+		util.InternalServerError(c, err.Error())
+		return
+	}
+
+	util.Info("处理异步删除任务成功", zap.Int("任务数", n))
+	util.Success(c, 200, gin.H{
+		"message":   "处理异步删除任务成功",
+		"processed": n,
+	})
+}
+
+// 获取异步任务列表
+func (ad AdminController) GetOutboxTasks(c *gin.Context) {
+	status := c.DefaultQuery("status", "failed")
+	if status == "all" {
+		status = ""
+	} else if status != "pending" && status != "done" && status != "failed" {
+		util.BadRequest(c, "参数错误")
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	tasks, total, totalPages, err := ad.outboxService.ListTasks(status, page, limit)
+	if err != nil {
+		util.Error("获取异步任务列表失败", zap.Error(err))
+		util.InternalServerError(c, err.Error())
+		return
+	}
+
+	util.Info("获取异步任务列表成功", zap.Int("任务数", len(tasks)))
+	util.Success(c, 200, gin.H{
+		"items":      tasks,
+		"total":      total,
+		"totalPages": totalPages,
+		"page":       page,
+		"limit":      limit,
+	})
+}
+
+// 重试失败的异步任务
+func (ad AdminController) RetryOutboxTask(c *gin.Context) {
+	taskIDStr := c.Param("taskId")
+	taskID, err := strconv.Atoi(taskIDStr)
+	if err != nil {
+		util.Error("参数错误", zap.String("taskID", taskIDStr))
+		util.BadRequest(c, "参数错误")
+		return
+	}
+
+	if err = ad.outboxService.RetryTask(uint(taskID)); err != nil {
+		util.Error("重试异步任务失败", zap.Error(err))
+		if errors.Is(err, service.ErrOutboxTaskNotFailed) {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.InternalServerError(c, err.Error())
+		return
+	}
+
+	util.Info("重试异步任务成功", zap.String("taskID", taskIDStr))
+	util.Success(c, 200, gin.H{
+		"message": "重试异步任务成功",
+	})
+}
+
+// 丢弃失败的异步任务
+func (ad AdminController) DiscardOutboxTask(c *gin.Context) {
+	taskIDStr := c.Param("taskId")
+	taskID, err := strconv.Atoi(taskIDStr)
+	if err != nil {
+		util.Error("参数错误", zap.String("taskID", taskIDStr))
+		util.BadRequest(c, "参数错误")
+		return
+	}
+
+	if err = ad.outboxService.DiscardTask(uint(taskID)); err != nil {
+		util.Error("丢弃异步任务失败", zap.Error(err))
+		if errors.Is(err, service.ErrOutboxTaskNotFailed) {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.InternalServerError(c, err.Error())
+		return
+	}
+
+	util.Info("丢弃异步任务成功", zap.String("taskID", taskIDStr))
+	util.Success(c, 200, gin.H{
+		"message": "丢弃异步任务成功",
+	})
+}
+
+// 获取重复文件记录（status=pending|confirmed|dismissed）
+func (ad AdminController) GetDuplicates(c *gin.Context) {
+	var q model.DuplicateQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		util.Error("参数错误", zap.Error(err))
+		util.BadRequest(c, "参数错误")
+		return
+	}
+
+	duplicates, total, totalPages, err := ad.adminService.GetDuplicates(&q)
+	if err != nil {
+		util.Error("获取重复文件记录失败", zap.Error(err))
+		util.InternalServerError(c, err.Error())
+		return
+	}
+
+	util.Info("获取重复文件记录成功", zap.Int("重复文件记录数", len(duplicates)))
+	util.Success(c, 200, gin.H{
+		"items":      duplicates,
+		"total":      total,
+		"totalPages": totalPages,
+		"page":       q.Page,
+		"limit":      q.Limit,
+	})
+}
+
+// 审核重复文件记录（action=confirm 下架数据集，action=dismiss 标记为误报）
+func (ad AdminController) ReviewDuplicate(c *gin.Context) {
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		util.Error("用户未登录", zap.String("userID", userIDStr.(string)))
+		util.Unauthorized(c, "用户未登录")
+		return
+	}
+	adminID := userIDStr.(uint)
+
+	idStr := c.Param("duplicateId")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		util.Error("参数错误", zap.String("duplicateId", idStr))
+		util.BadRequest(c, "参数错误")
+		return
+	}
+	var req model.ReviewDuplicateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.Error("参数格式错误", zap.Error(err))
+		util.BadRequest(c, "参数格式错误: "+err.Error())
+		return
+	}
+
+	duplicate, err := ad.adminService.ReviewDuplicate(adminID, uint(id), &req)
+	if err != nil {
+		util.Error("审核重复文件失败", zap.Error(err))
+		switch {
+		case errors.Is(err, service.ErrDuplicateNotFound):
+			util.NotFound(c, err.Error())
+		case errors.Is(err, service.ErrDuplicateReviewed):
+			util.Conflict(c, err.Error())
+		default:
+			util.InternalServerError(c, err.Error())
+		}
+		return
+	}
 
-	util.Info("处理异步删除任务成功")
+	util.Info("审核重复文件成功", zap.String("duplicateId", idStr), zap.String("action", req.Action))
 	util.Success(c, 200, gin.H{
-		"message": "处理异步删除任务成功",
+		"data": duplicate,
 	})
 }
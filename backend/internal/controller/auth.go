@@ -445,3 +445,55 @@ func (ac *AuthController) GetAuthorStats(c *gin.Context) {
 		"data": authorStats,
 	})
 }
+
+// 校验当前用户权限
+func (ac *AuthController) CheckPermission(c *gin.Context) {
+	userIdStr, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "用户未登录")
+		return
+	}
+	userID := userIdStr.(uint)
+
+	var req model.CheckPermissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.Error("参数格式错误", zap.Error(err))
+		util.BadRequest(c, "参数格式错误: "+err.Error())
+		return
+	}
+
+	allowed, role, err := ac.authService.CheckPermission(userID, &req)
+	if err != nil {
+		util.Error("校验权限失败", zap.Error(err))
+		util.InternalServerError(c, err.Error())
+		return
+	}
+
+	util.Info("校验权限成功", zap.String("userID", strconv.Itoa(int(userID))), zap.String("action", req.Action), zap.String("resource", req.Resource), zap.Bool("allowed", allowed))
+	util.Success(c, 200, gin.H{
+		"allowed": allowed,
+		"role":    role,
+	})
+}
+
+// 获取当前用户角色信息
+func (ac *AuthController) GetRoleInfo(c *gin.Context) {
+	userIdStr, exists := c.Get("userID")
+	if !exists {
+		util.Unauthorized(c, "用户未登录")
+		return
+	}
+	userID := userIdStr.(uint)
+
+	info, err := ac.authService.GetRoleInfo(userID)
+	if err != nil {
+		util.Error("获取角色信息失败", zap.Error(err))
+		util.InternalServerError(c, err.Error())
+		return
+	}
+
+	util.Info("获取角色信息成功", zap.String("userID", strconv.Itoa(int(userID))), zap.String("role", info.Role))
+	util.Success(c, 200, gin.H{
+		"data": info,
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"backend/internal/model"
 	"backend/internal/service"
 	"backend/internal/util"
+	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -16,11 +18,13 @@ import (
 
 type DatasetController struct {
 	datasetService *service.DatasetService
+	tagService     *service.TagService
 }
 
-func NewDatasetController(datasetService *service.DatasetService) *DatasetController {
+func NewDatasetController(datasetService *service.DatasetService, tagService *service.TagService) *DatasetController {
 	return &DatasetController{
 		datasetService: datasetService,
+		tagService:     tagService,
 	}
 }
 
@@ -42,16 +46,28 @@ func (d *DatasetController) UploadPreview(c *gin.Context) {
 		return
 	}
 	// 预览数据存入mongodb
-	if err := d.datasetService.SavePreviewData(&req); err != nil {
+	if err := d.datasetService.SavePreviewData(&req, userID); err != nil {
 		util.Error("存入预览数据失败", zap.Error(err))
+		if service.IsForbiddenError(err) {
+			util.Forbidden(c, err.Error())
+			return
+		}
+		if errors.Is(err, util.ErrUnsupportedFormat) {
+			util.BadRequest(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, err.Error())
 		return
 	}
 
 	// 获取上传 minio 预签名 url
-	url, err := d.datasetService.GetUploadPreviewURL(userID)
+	url, err := d.datasetService.GetUploadPreviewURL(userID, &req)
 	if err != nil {
 		util.Error("获取上传预览数据预签名 url 失败", zap.Error(err))
+		if errors.Is(err, util.ErrUnsupportedFormat) {
+			util.BadRequest(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, err.Error())
 		return
 	}
@@ -63,32 +79,100 @@ func (d *DatasetController) UploadPreview(c *gin.Context) {
 
 // 初始化分片上传
 func (d *DatasetController) InitMultipartUpload(c *gin.Context) {
-	var req struct {
-		FileName string `json:"fileName" binding:"required"`
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		util.Error("用户未登录", zap.String("userID", userIDStr.(string)))
+		util.Unauthorized(c, "用户未登录")
+		return
 	}
+
+	userID := userIDStr.(uint)
+
+	var req model.InitMultipartUploadRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		util.Error("参数格式错误", zap.Error(err))
 		util.BadRequest(c, "参数格式错误: "+err.Error())
 		return
 	}
 
-	uploadId, objectName, err := d.datasetService.InitiateMultipartUpload(req.FileName)
+	session, err := d.datasetService.InitiateMultipartUpload(userID, &req)
 	if err != nil {
 		util.Error("初始化分片上传失败", zap.Error(err))
-		util.InternalServerError(c, "初始化分片上传失败: "+err.Error())
+		uploadSessionFailure(c, err, "初始化分片上传失败")
 		return
 	}
 
-	util.Info("初始化分片上传成功", zap.String("uploadId", uploadId))
+	util.Info("初始化分片上传成功", zap.String("uploadId", session.UploadID), zap.String("objectName", session.ObjectName))
 
 	util.Success(c, 200, gin.H{
-		"uploadId":   uploadId,
-		"objectName": objectName,
+		"uploadId":   session.UploadID,
+		"objectName": session.ObjectName,
+		"partSize":   session.PartSize,
+		"totalParts": session.TotalParts,
+		"expiresAt":  session.ExpiresAt,
 	})
 }
 
+// 续传：查询上传会话及已上传的分片
+func (d *DatasetController) ResumeMultipartUpload(c *gin.Context) {
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		util.Error("用户未登录", zap.String("userID", userIDStr.(string)))
+		util.Unauthorized(c, "用户未登录")
+		return
+	}
+
+	userID := userIDStr.(uint)
+
+	var req struct {
+		ObjectName string `json:"objectName" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.Error("参数格式错误", zap.Error(err))
+		util.BadRequest(c, "参数格式错误: "+err.Error())
+		return
+	}
+
+	session, err := d.datasetService.ResumeMultipartUpload(userID, req.ObjectName)
+	if err != nil {
+		util.Error("查询上传会话失败", zap.Error(err))
+		uploadSessionFailure(c, err, "查询上传会话失败")
+		return
+	}
+
+	util.Info("查询上传会话成功", zap.String("objectName", req.ObjectName), zap.Int("missingParts", len(session.MissingParts)))
+	util.Success(c, 200, gin.H{
+		"data": session,
+	})
+}
+
+// 分片上传错误映射：归属 403、会话不存在 404、会话已结束 409、分片参数或分片不完整 400
+func uploadSessionFailure(c *gin.Context, err error, msg string) {
+	switch {
+	case service.IsForbiddenError(err):
+		util.Forbidden(c, err.Error())
+	case errors.Is(err, service.ErrUploadSessionNotFound):
+		util.NotFound(c, err.Error())
+	case errors.Is(err, service.ErrUploadSessionClosed):
+		util.Conflict(c, err.Error())
+	case errors.Is(err, service.ErrInvalidUploadPart) || errors.Is(err, service.ErrUploadIncomplete):
+		util.BadRequest(c, err.Error())
+	default:
+		util.InternalServerError(c, msg+": "+err.Error())
+	}
+}
+
 // 获取某个分片的上传 URL
 func (d *DatasetController) GetPartUploadURL(c *gin.Context) {
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		util.Error("用户未登录", zap.String("userID", userIDStr.(string)))
+		util.Unauthorized(c, "用户未登录")
+		return
+	}
+
+	userID := userIDStr.(uint)
+
 	var req struct {
 		ObjectName string `json:"objectName" binding:"required"`
 		UploadId   string `json:"uploadId" binding:"required"`
@@ -99,10 +183,10 @@ func (d *DatasetController) GetPartUploadURL(c *gin.Context) {
 		return
 	}
 
-	url, err := d.datasetService.GetPresignedPartURL(req.ObjectName, req.UploadId, req.PartNumber)
+	url, err := d.datasetService.GetPresignedPartURL(userID, req.ObjectName, req.UploadId, req.PartNumber)
 	if err != nil {
 		util.Error("获取分片URL失败", zap.Error(err))
-		util.InternalServerError(c, "获取分片URL失败: "+err.Error())
+		uploadSessionFailure(c, err, "获取分片URL失败")
 		return
 	}
 
@@ -112,13 +196,22 @@ func (d *DatasetController) GetPartUploadURL(c *gin.Context) {
 
 // 完成分片上传
 func (d *DatasetController) CompleteMultipartUpload(c *gin.Context) {
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		util.Error("用户未登录", zap.String("userID", userIDStr.(string)))
+		util.Unauthorized(c, "用户未登录")
+		return
+	}
+
+	userID := userIDStr.(uint)
+
 	var req struct {
 		ObjectName string `json:"objectName" binding:"required"`
 		UploadId   string `json:"uploadId" binding:"required"`
 		Parts      []struct {
 			ETag       string `json:"ETag" binding:"required"`
 			PartNumber int    `json:"PartNumber" binding:"required"`
-		} `json:"parts" binding:"required"`
+		} `json:"parts"` // 可选，分片以服务端记录为准
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		util.Error("参数格式错误", zap.Error(err))
@@ -136,10 +229,10 @@ func (d *DatasetController) CompleteMultipartUpload(c *gin.Context) {
 	}
 
 	// 合并分片
-	objectName, err := d.datasetService.CompleteMultipartUpload(req.ObjectName, req.UploadId, completeParts)
+	objectName, err := d.datasetService.CompleteMultipartUpload(userID, req.ObjectName, req.UploadId, completeParts)
 	if err != nil {
 		util.Error("合并分片失败", zap.Error(err))
-		util.InternalServerError(c, "合并分片失败: "+err.Error())
+		uploadSessionFailure(c, err, "合并分片失败")
 		return
 	}
 
@@ -152,6 +245,15 @@ func (d *DatasetController) CompleteMultipartUpload(c *gin.Context) {
 
 // 取消分片上传
 func (d *DatasetController) AbortMultipartUpload(c *gin.Context) {
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		util.Error("用户未登录", zap.String("userID", userIDStr.(string)))
+		util.Unauthorized(c, "用户未登录")
+		return
+	}
+
+	userID := userIDStr.(uint)
+
 	var req struct {
 		ObjectName string `json:"objectName" binding:"required"`
 		UploadId   string `json:"uploadId" binding:"required"`
@@ -163,9 +265,9 @@ func (d *DatasetController) AbortMultipartUpload(c *gin.Context) {
 	}
 
 	// 取消分片上传
-	if err := d.datasetService.AbortMultipartUpload(req.ObjectName, req.UploadId); err != nil {
+	if err := d.datasetService.AbortMultipartUpload(userID, req.ObjectName, req.UploadId); err != nil {
 		util.Error("取消分片上传", zap.Error(err))
-		util.InternalServerError(c, "取消分片上传: "+err.Error())
+		uploadSessionFailure(c, err, "取消分片上传")
 		return
 	}
 
@@ -193,6 +295,18 @@ func (d *DatasetController) UploadDataset(c *gin.Context) {
 	datasetId, err := d.datasetService.UploadDataset(&req, userID)
 	if err != nil {
 		util.Error("上传数据集失败", zap.Error(err))
+		if service.IsForbiddenError(err) {
+			util.Forbidden(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrUploadSessionClosed) {
+			util.Conflict(c, err.Error())
+			return
+		}
+		if errors.Is(err, util.ErrUnsupportedFormat) {
+			util.BadRequest(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, "上传数据集失败: "+err.Error())
 		return
 	}
@@ -204,6 +318,142 @@ func (d *DatasetController) UploadDataset(c *gin.Context) {
 	})
 }
 
+// 发布数据集新版本（文件需先通过分片上传接口上传）
+func (d *DatasetController) PublishVersion(c *gin.Context) {
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		util.Error("用户未登录", zap.String("userID", userIDStr.(string)))
+		util.Unauthorized(c, "用户未登录")
+		return
+	}
+
+	userID := userIDStr.(uint)
+	var req model.PublishDatasetVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.Error("参数格式错误", zap.Error(err))
+		util.BadRequest(c, "参数格式错误: "+err.Error())
+		return
+	}
+	version, err := d.datasetService.PublishVersion(&req, userID)
+	if err != nil {
+		util.Error("发布数据集版本失败", zap.Error(err))
+		if service.IsForbiddenError(err) {
+			util.Forbidden(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrUploadSessionClosed) {
+			util.Conflict(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrDatasetNotFound) {
+			util.NotFound(c, err.Error())
+			return
+		}
+		if errors.Is(err, util.ErrUnsupportedFormat) {
+			util.BadRequest(c, err.Error())
+			return
+		}
+		util.InternalServerError(c, "发布数据集版本失败: "+err.Error())
+		return
+	}
+
+	util.Info("发布数据集版本成功", zap.Uint("datasetId", req.DatasetID), zap.Int("version", version.Version))
+	util.Success(c, 201, gin.H{
+		"version": version,
+		"message": "发布数据集版本成功",
+	})
+}
+
+// 修改数据集元数据，付费数据集的价格等需卖家按 chainAction 调用合约后生效
+func (d *DatasetController) UpdateDataset(c *gin.Context) {
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		util.Error("用户未登录", zap.String("userID", userIDStr.(string)))
+		util.Unauthorized(c, "用户未登录")
+		return
+	}
+
+	userID := userIDStr.(uint)
+	datasetIDStr := c.Param("id")
+	datasetID, err := strconv.ParseUint(datasetIDStr, 10, 64)
+	if err != nil {
+		util.Error("参数格式错误", zap.Error(err))
+		util.BadRequest(c, "参数格式错误: "+err.Error())
+		return
+	}
+	var req model.UpdateDatasetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.Error("参数格式错误", zap.Error(err))
+		util.BadRequest(c, "参数格式错误: "+err.Error())
+		return
+	}
+	res, err := d.datasetService.UpdateDataset(uint(datasetID), &req, userID)
+	if err != nil {
+		util.Error("修改数据集失败", zap.Error(err))
+		if service.IsForbiddenError(err) {
+			util.Forbidden(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrDatasetNotFound) {
+			util.NotFound(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrInvalidDatasetEdit) || errors.Is(err, service.ErrNoDatasetChanges) {
+			util.BadRequest(c, err.Error())
+			return
+		}
+		util.InternalServerError(c, "修改数据集失败: "+err.Error())
+		return
+	}
+
+	util.Info("修改数据集成功", zap.String("datasetId", datasetIDStr), zap.String("status", res.Edit.Status))
+	util.Success(c, 200, gin.H{
+		"data":    res,
+		"message": "修改数据集成功",
+	})
+}
+
+// 查询数据集版本的文件校验报告（仅作者本人）
+func (d *DatasetController) GetValidationReport(c *gin.Context) {
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		util.Error("用户未登录", zap.String("userID", userIDStr.(string)))
+		util.Unauthorized(c, "用户未登录")
+		return
+	}
+
+	userID := userIDStr.(uint)
+	datasetIDStr := c.Query("datasetId")
+	datasetID, err := strconv.ParseUint(datasetIDStr, 10, 64)
+	if err != nil {
+		util.Error("参数格式错误", zap.Error(err))
+		util.BadRequest(c, "参数格式错误: "+err.Error())
+		return
+	}
+	version, ok := parseVersion(c)
+	if !ok {
+		return
+	}
+	validation, err := d.datasetService.GetValidationReport(uint(datasetID), version, userID)
+	if err != nil {
+		util.Error("查询校验报告失败", zap.Error(err))
+		if service.IsForbiddenError(err) {
+			util.Forbidden(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrDatasetNotFound) || errors.Is(err, service.ErrDatasetVersionNotFound) || errors.Is(err, service.ErrValidationNotFound) {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.InternalServerError(c, "查询校验报告失败: "+err.Error())
+		return
+	}
+
+	util.Success(c, 200, gin.H{
+		"data": validation,
+	})
+}
+
 // 删除数据集
 func (d *DatasetController) DeleteDataset(c *gin.Context) {
 	userIDStr, exists := c.Get("userID")
@@ -213,6 +463,7 @@ func (d *DatasetController) DeleteDataset(c *gin.Context) {
 		return
 	}
 
+	userID := userIDStr.(uint)
 	var req model.DeleteDatasetRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		util.Error("参数格式错误", zap.Error(err))
@@ -220,8 +471,12 @@ func (d *DatasetController) DeleteDataset(c *gin.Context) {
 		return
 	}
 
-	if err := d.datasetService.DeleteDataset(&req); err != nil {
+	if err := d.datasetService.DeleteDataset(&req, userID); err != nil {
 		util.Error("删除数据集和文件失败", zap.Error(err))
+		if service.IsForbiddenError(err) {
+			util.Forbidden(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, "删除数据集和文件失败: "+err.Error())
 		return
 	}
@@ -230,43 +485,180 @@ func (d *DatasetController) DeleteDataset(c *gin.Context) {
 	util.Success(c, 204, gin.H{"message": "删除数据集和文件成功"})
 }
 
-// 获取数据集列表，支持分页、免费/付费、分类、搜索、文件大小等筛选
+// 获取数据集列表，支持排序、游标/页码分页、免费/付费、分类、搜索、标签、文件大小、价格、创建时间等筛选
 func (d *DatasetController) ListDatasets(c *gin.Context) {
-	// 解析分页参数
-	pageStr := c.DefaultQuery("page", "1")
-	limitStr := c.DefaultQuery("limit", "10")
-	category := c.Query("category")
-	search := c.Query("search")
-	fileSizeRange := c.Query("fileSizeRange")
-	priceRange := c.Query("priceRange")
-	isFreeStr := c.Query("is_free")
-
-	page, _ := strconv.Atoi(pageStr)
-	limit, _ := strconv.Atoi(limitStr)
-	isFree := -1 // -1: 不筛选, 0: 付费, 1: 免费
-	if isFreeStr != "" {
-		if isFreeStr == "true" || isFreeStr == "1" {
-			isFree = 1
-		} else if isFreeStr == "false" || isFreeStr == "0" {
-			isFree = 0
-		}
+	pq, ok := parsePageQuery(c)
+	if !ok {
+		return
+	}
+	q, ok := parseDatasetQuery(c)
+	if !ok {
+		return
 	}
 
 	// 调用 service 层
-	datasets, total, totalPages, err := d.datasetService.ListDatasets(page, limit, isFree, category, search, fileSizeRange, priceRange)
+	datasets, info, err := d.datasetService.ListDatasets(pq, q)
 	if err != nil {
 		util.Error("获取数据集列表失败", zap.Error(err))
+		if isPageQueryError(err) {
+			util.BadRequest(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, "获取数据集列表失败: "+err.Error())
 		return
 	}
 
-	util.Info("获取数据集列表成功", zap.String("page", pageStr), zap.String("limit", limitStr))
+	util.Info("获取数据集列表成功", zap.String("sort", pq.Sort), zap.Int("page", pq.Page), zap.Int("limit", pq.Limit))
+	util.Success(c, 200, pageResponse(datasets, pq, info))
+}
+
+// 解析并校验数据集筛选参数，参数错误时返回 400
+func parseDatasetQuery(c *gin.Context) (*model.DatasetQuery, bool) {
+	var p model.DatasetQueryParams
+	if err := c.ShouldBindQuery(&p); err != nil {
+		util.Error("参数格式错误", zap.Error(err))
+		util.BadRequest(c, "参数格式错误: "+err.Error())
+		return nil, false
+	}
+	q, err := service.NewDatasetQuery(&p)
+	if err != nil {
+		util.Error("参数格式错误", zap.Error(err))
+		util.BadRequest(c, err.Error())
+		return nil, false
+	}
+	return q, true
+}
+
+// 解析下载的版本号，未传时为 0（最新版本）
+func parseVersion(c *gin.Context) (int, bool) {
+	versionStr := c.Query("version")
+	if versionStr == "" || versionStr == "latest" {
+		return 0, true
+	}
+	version, err := strconv.Atoi(versionStr)
+	if err != nil || version < 1 {
+		util.Error("参数格式错误", zap.String("version", versionStr))
+		util.BadRequest(c, "参数格式错误: version 应为正整数或 latest")
+		return 0, false
+	}
+	return version, true
+}
+
+// 解析分页参数：sort 为排序方式，cursor 为上一页返回的 nextCursor；
+// 未传 cursor 而传了 page 时按页码分页（兼容旧接口，默认返回总数），否则按游标分页，withTotal=true 时才返回总数
+func parsePageQuery(c *gin.Context) (*model.PageQuery, bool) {
+	q := &model.PageQuery{Sort: c.Query("sort")}
+	var err error
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if q.Limit, err = strconv.Atoi(limitStr); err != nil {
+			util.Error("参数格式错误", zap.Error(err))
+			util.BadRequest(c, "参数格式错误: limit 应为整数")
+			return nil, false
+		}
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		q.Cursor = &model.PageCursor{}
+		if err := util.DecodeCursor(cursor, q.Cursor); err != nil {
+			util.Error("参数格式错误", zap.String("cursor", cursor))
+			util.BadRequest(c, err.Error())
+			return nil, false
+		}
+	} else if pageStr, ok := c.GetQuery("page"); ok {
+		if q.Page, err = strconv.Atoi(pageStr); err != nil {
+			util.Error("参数格式错误", zap.Error(err))
+			util.BadRequest(c, "参数格式错误: page 应为整数")
+			return nil, false
+		}
+		q.Page = max(q.Page, 1)
+		q.WithTotal = true
+	}
+	if withTotal := c.Query("withTotal"); withTotal != "" {
+		if q.WithTotal, err = strconv.ParseBool(withTotal); err != nil {
+			util.Error("参数格式错误", zap.Error(err))
+			util.BadRequest(c, "参数格式错误: withTotal 应为 true 或 false")
+			return nil, false
+		}
+	}
+	return q, true
+}
+
+// 分页参数错误（排序方式或游标无效、相关度排序翻页过深），映射为 400
+func isPageQueryError(err error) bool {
+	return errors.Is(err, service.ErrInvalidSort) || errors.Is(err, util.ErrInvalidCursor) || errors.Is(err, service.ErrRelevanceTooDeep)
+}
+
+// 列表分页响应：总数仅在请求时返回，页码模式额外返回 page/totalPages
+func pageResponse(items interface{}, q *model.PageQuery, info model.PageInfo) gin.H {
+	resp := gin.H{
+		"items":      items,
+		"limit":      q.Limit,
+		"sort":       q.Sort,
+		"nextCursor": info.NextCursor,
+		"hasMore":    info.HasMore,
+	}
+	if q.Page > 0 {
+		resp["page"] = q.Page
+	}
+	if info.Total != nil {
+		resp["total"] = *info.Total
+		resp["totalPages"] = int((*info.Total + int64(q.Limit) - 1) / int64(q.Limit))
+	}
+	return resp
+}
+
+// 热门标签
+func (d *DatasetController) GetPopularTags(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	tags, err := d.tagService.GetPopularTags(limit)
+	if err != nil {
+		util.Error("获取热门标签失败", zap.Error(err))
+		util.InternalServerError(c, "获取热门标签失败: "+err.Error())
+		return
+	}
 	util.Success(c, 200, gin.H{
-		"items":      datasets,
+		"items": tags,
+	})
+}
+
+// 标签前缀补全
+func (d *DatasetController) AutocompleteTags(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	tags, err := d.tagService.AutocompleteTags(c.Query("prefix"), limit)
+	if err != nil {
+		util.Error("标签补全失败", zap.Error(err))
+		util.InternalServerError(c, "标签补全失败: "+err.Error())
+		return
+	}
+	util.Success(c, 200, gin.H{
+		"items": tags,
+	})
+}
+
+// 全文搜索数据集，按相关度排序，返回分面统计和高亮片段
+func (d *DatasetController) SearchDatasets(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	dq, ok := parseDatasetQuery(c)
+	if !ok {
+		return
+	}
+
+	q := service.NewDatasetSearchQuery(dq, page, limit)
+	items, total, totalPages, facets, err := d.datasetService.SearchDatasets(q)
+	if err != nil {
+		util.Error("搜索数据集失败", zap.Error(err))
+		util.InternalServerError(c, "搜索数据集失败: "+err.Error())
+		return
+	}
+
+	util.Info("搜索数据集成功", zap.String("q", q.Text), zap.Int64("total", total))
+	util.Success(c, 200, gin.H{
+		"items":      items,
 		"total":      total,
-		"page":       page,
-		"limit":      limit,
+		"page":       q.Page,
+		"limit":      q.Limit,
 		"totalPages": totalPages,
+		"facets":     facets,
 	})
 }
 
@@ -279,9 +671,16 @@ func (d *DatasetController) GetDatasetDetail(c *gin.Context) {
 		util.BadRequest(c, "参数格式错误: "+err.Error())
 		return
 	}
-	dataset, err := d.datasetService.GetDatasetDetail(uint(datasetID))
+	// 公开接口，登录用户为作者本人时返回全部版本
+	value, _ := c.Get("userID")
+	viewerID, _ := value.(uint)
+	dataset, err := d.datasetService.GetDatasetDetail(uint(datasetID), viewerID)
 	if err != nil {
 		util.Error("获取数据集详情失败", zap.Error(err))
+		if errors.Is(err, service.ErrDatasetNotFound) {
+			util.NotFound(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, "获取数据集详情失败: "+err.Error())
 		return
 	}
@@ -300,45 +699,52 @@ func (d *DatasetController) GetPreviewData(c *gin.Context) {
 		util.BadRequest(c, "参数格式错误: objectName 不能为空")
 		return
 	}
-	previewData, err := d.datasetService.GetPreviewData(objectName)
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		util.Error("参数格式错误", zap.Error(err))
+		util.BadRequest(c, "参数格式错误: page 应为整数")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		util.Error("参数格式错误", zap.Error(err))
+		util.BadRequest(c, "参数格式错误: limit 应为整数")
+		return
+	}
+	preview, err := d.datasetService.GetPreviewData(objectName, page, limit)
 	if err != nil {
 		util.Error("获取预览数据失败", zap.Error(err))
+		if errors.Is(err, service.ErrPreviewNotFound) {
+			util.NotFound(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, "获取预览数据失败: "+err.Error())
 		return
 	}
-	util.Info("获取预览数据成功", zap.String("objectName", objectName))
-	util.Success(c, 200, gin.H{
-		"data": previewData.PreviewData,
-	})
+	util.Info("获取预览数据成功", zap.String("objectName", objectName), zap.String("source", preview.Source), zap.Int("page", preview.Page))
+	util.Success(c, 200, preview)
 }
 
 // 获取作者的付费数据集
 func (d *DatasetController) GetAuthorPaidDatasets(c *gin.Context) {
 	authorWalletAddress := c.Query("authorWalletAddress")
-	pageStr := c.DefaultQuery("page", "1")
-	limitStr := c.DefaultQuery("limit", "3")
-	page, err := strconv.Atoi(pageStr)
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		util.Error("参数格式错误", zap.Error(err))
-		util.BadRequest(c, "参数格式错误: "+err.Error())
+	q, ok := parsePageQuery(c)
+	if !ok {
 		return
 	}
-	datasets, total, totalPages, err := d.datasetService.GetAuthorPaidDatasets(authorWalletAddress, page, limit)
+	datasets, info, err := d.datasetService.GetAuthorPaidDatasets(authorWalletAddress, q)
 	if err != nil {
 		util.Error("获取作者的付费数据集失败", zap.Error(err))
+		if isPageQueryError(err) {
+			util.BadRequest(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, "获取作者的付费数据集失败: "+err.Error())
 		return
 	}
 
 	util.Info("获取作者的付费数据集成功", zap.String("authorWalletAddress", authorWalletAddress))
-	util.Success(c, 200, gin.H{
-		"items":      datasets,
-		"total":      total,
-		"page":       page,
-		"limit":      limit,
-		"totalPages": totalPages,
-	})
+	util.Success(c, 200, pageResponse(datasets, q, info))
 }
 
 // 下载免费数据集
@@ -359,17 +765,32 @@ func (d *DatasetController) DownloadFreeDataset(c *gin.Context) {
 		return
 	}
 
-	url, err := d.datasetService.GetDownloadURL(userID, uint(datasetID))
+	version, ok := parseVersion(c)
+	if !ok {
+		return
+	}
+
+	res, err := d.datasetService.GetDownloadURL(userID, uint(datasetID), version)
 	if err != nil {
 		util.Error("获取数据集详情失败", zap.Error(err))
+		if service.IsPaymentRequiredError(err) {
+			util.PaymentRequired(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrDatasetVersionNotFound) {
+			util.NotFound(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrDatasetVersionNotReady) {
+			util.Conflict(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, "获取数据集详情失败: "+err.Error())
 		return
 	}
 
 	util.Info("获取数据集详情成功", zap.String("datasetId", datasetIDStr))
-	util.Success(c, 200, gin.H{
-		"url": url,
-	})
+	util.Success(c, 200, res)
 }
 
 // 下载付费数据集
@@ -391,12 +812,33 @@ func (d *DatasetController) DownloadPaidDataset(c *gin.Context) {
 		return
 	}
 
+	version, ok := parseVersion(c)
+	if !ok {
+		return
+	}
+
 	start := time.Now()
 
-	objectName, err := d.datasetService.DownloadPaidDataset(userID, uint(datasetID))
+	objectName, jobID, err := d.datasetService.DownloadPaidDataset(userID, uint(datasetID), version)
 	util.Info("下载付费数据集耗时", zap.String("cost", time.Since(start).String()))
 	if err != nil {
 		util.Error("获取数据集详情失败", zap.Error(err))
+		if service.IsPaymentRequiredError(err) {
+			util.PaymentRequired(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrDatasetVersionNotFound) {
+			util.NotFound(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrDatasetVersionNotReady) {
+			util.Conflict(c, err.Error())
+			return
+		}
+		if service.IsForbiddenError(err) {
+			util.Forbidden(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, err.Error())
 		return
 	}
@@ -404,6 +846,7 @@ func (d *DatasetController) DownloadPaidDataset(c *gin.Context) {
 	util.Info("处理数据集结束了", zap.String("datasetId", datasetIDStr))
 	util.Success(c, 200, gin.H{
 		"objectName": objectName,
+		"jobId":      jobID,
 		"message":    "正在处理下载请求",
 	})
 }
@@ -419,53 +862,199 @@ func (d *DatasetController) GetDownloadStatus(c *gin.Context) {
 
 	userID := userIDStr.(uint)
 	datasetIDStr := c.Query("datasetID")
-	objectName := c.Query("objectName")
 	datasetID, err := strconv.Atoi(datasetIDStr)
 	if err != nil {
 		util.Error("参数格式错误", zap.Error(err))
 		util.BadRequest(c, "参数格式错误: "+err.Error())
 		return
 	}
+	jobID, err := strconv.Atoi(c.DefaultQuery("jobId", "0"))
+	if err != nil {
+		util.Error("参数格式错误", zap.Error(err))
+		util.BadRequest(c, "参数格式错误: "+err.Error())
+		return
+	}
 
-	url, progress, err := d.datasetService.GetDownloadStatus(userID, uint(datasetID), objectName)
-	if err != nil && progress != "100" {
+	status, err := d.datasetService.GetDownloadStatus(userID, uint(datasetID), uint(jobID))
+	if err != nil {
 		util.Error("获取下载状态失败", zap.Error(err))
+		if errors.Is(err, service.ErrDownloadJobNotFound) {
+			util.NotFound(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, err.Error())
 		return
 	}
 
-	util.Info("获取下载状态成功", zap.String("datasetId", datasetIDStr))
+	util.Info("获取下载状态成功", zap.String("datasetId", datasetIDStr), zap.String("state", status.State))
+	util.Success(c, 200, status)
+}
+
+// 取消下载任务
+func (d *DatasetController) CancelDownloadJob(c *gin.Context) {
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		util.Error("用户未登录", zap.String("userID", userIDStr.(string)))
+		util.Unauthorized(c, "用户未登录")
+		return
+	}
+
+	userID := userIDStr.(uint)
+	jobIDStr := c.Query("jobId")
+	jobID, err := strconv.Atoi(jobIDStr)
+	if err != nil {
+		util.Error("参数格式错误", zap.Error(err))
+		util.BadRequest(c, "参数格式错误: "+err.Error())
+		return
+	}
+
+	if err := d.datasetService.CancelDownloadJob(userID, uint(jobID)); err != nil {
+		util.Error("取消下载任务失败", zap.Error(err))
+		if errors.Is(err, service.ErrDownloadJobNotFound) {
+			util.NotFound(c, err.Error())
+			return
+		}
+		util.InternalServerError(c, err.Error())
+		return
+	}
+
+	util.Info("取消下载任务成功", zap.String("jobId", jobIDStr))
 	util.Success(c, 200, gin.H{
-		"url":      url,
-		"progress": progress,
+		"message": "取消下载任务成功",
 	})
 }
 
-// 获取作者的数据集
-func (d *DatasetController) GetAuthorDatasets(c *gin.Context) {
-	authorWalletAddress := c.Query("authorWalletAddress")
-	pageStr := c.DefaultQuery("page", "1")
-	limitStr := c.DefaultQuery("limit", "5")
-	page, err := strconv.Atoi(pageStr)
-	limit, err := strconv.Atoi(limitStr)
+// 流式下载付费数据集：边读源文件边插入指纹直接写入响应，支持 Range 断点续传
+func (d *DatasetController) StreamPaidDataset(c *gin.Context) {
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		util.Error("用户未登录", zap.String("userID", userIDStr.(string)))
+		util.Unauthorized(c, "用户未登录")
+		return
+	}
+
+	userID := userIDStr.(uint)
+	datasetIDStr := c.Query("datasetID")
+	datasetID, err := strconv.Atoi(datasetIDStr)
 	if err != nil {
 		util.Error("参数格式错误", zap.Error(err))
 		util.BadRequest(c, "参数格式错误: "+err.Error())
 		return
 	}
-	datasets, total, totalPages, err := d.datasetService.GetAuthorDatasets(authorWalletAddress, page, limit)
+
+	version, ok := parseVersion(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	info, err := d.datasetService.PreparePaidStream(ctx, userID, uint(datasetID), version)
+	if err != nil {
+		util.Error("准备流式下载失败", zap.Error(err))
+		if service.IsPaymentRequiredError(err) {
+			util.PaymentRequired(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrDatasetVersionNotFound) {
+			util.NotFound(c, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrDatasetVersionNotReady) {
+			util.Conflict(c, err.Error())
+			return
+		}
+		if service.IsForbiddenError(err) {
+			util.Forbidden(c, err.Error())
+			return
+		}
+		util.InternalServerError(c, err.Error())
+		return
+	}
+
+	c.Header("ETag", info.ETag)
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, info.FileName))
+
+	// 首次下载时大小未知：忽略 Range，以分块编码返回完整文件，SHA-256 在传输完成后以 trailer 发送
+	if info.Size < 0 {
+		c.Header("Trailer", "X-Checksum-Sha256")
+		c.Status(http.StatusOK)
+		if err := d.datasetService.StreamPaidDataset(ctx, info, c.Writer, 0, -1); err != nil {
+			util.Error("流式下载中断", zap.String("datasetId", datasetIDStr), zap.Error(err))
+			c.Abort()
+			return
+		}
+		c.Writer.Header().Set("X-Checksum-Sha256", info.Checksum)
+		util.Info("流式下载完成", zap.String("datasetId", datasetIDStr), zap.Int64("size", info.Size))
+		return
+	}
+
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("X-Checksum-Sha256", info.Checksum) // 完整文件的 SHA-256，分段下载时同样为完整文件的值
+
+	// If-Range 与当前 ETag 不一致时说明文件已变化，返回完整文件
+	rangeHeader := c.GetHeader("Range")
+	if ifRange := c.GetHeader("If-Range"); ifRange != "" && ifRange != info.ETag {
+		rangeHeader = ""
+	}
+	start, end, partial, err := util.ParseRange(rangeHeader, info.Size)
+	if err != nil {
+		c.Header("Content-Range", fmt.Sprintf("bytes */%d", info.Size))
+		c.AbortWithStatus(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	status := http.StatusOK
+	if partial {
+		status = http.StatusPartialContent
+		c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, info.Size))
+	}
+	c.Header("Content-Length", strconv.FormatInt(end-start+1, 10))
+	c.Status(status)
+	if info.Size == 0 {
+		return
+	}
+
+	if err := d.datasetService.StreamPaidDataset(ctx, info, c.Writer, start, end); err != nil {
+		// 响应头已发出，只能中断连接，客户端可凭 Range 续传
+		util.Error("流式下载中断", zap.String("datasetId", datasetIDStr), zap.Int64("start", start), zap.Error(err))
+		c.Abort()
+		return
+	}
+	util.Info("流式下载完成", zap.String("datasetId", datasetIDStr), zap.Int64("start", start), zap.Int64("end", end))
+}
+
+// 获取作者的数据集
+func (d *DatasetController) GetAuthorDatasets(c *gin.Context) {
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		util.Error("用户未登录", zap.String("userID", userIDStr.(string)))
+		util.Unauthorized(c, "用户未登录")
+		return
+	}
+
+	userID := userIDStr.(uint)
+
+	authorWalletAddress := c.Query("authorWalletAddress")
+	q, ok := parsePageQuery(c)
+	if !ok {
+		return
+	}
+	datasets, info, err := d.datasetService.GetAuthorDatasets(userID, authorWalletAddress, q)
 	if err != nil {
 		util.Error("获取作者的数据集失败", zap.Error(err))
+		if service.IsForbiddenError(err) {
+			util.Forbidden(c, err.Error())
+			return
+		}
+		if isPageQueryError(err) {
+			util.BadRequest(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, "获取作者的数据集失败: "+err.Error())
 		return
 	}
 
 	util.Info("获取作者的数据集成功", zap.String("authorWalletAddress", authorWalletAddress))
-	util.Success(c, 200, gin.H{
-		"items":      datasets,
-		"total":      total,
-		"page":       page,
-		"limit":      limit,
-		"totalPages": totalPages,
-	})
+	util.Success(c, 200, pageResponse(datasets, q, info))
 }
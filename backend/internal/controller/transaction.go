@@ -64,8 +64,20 @@ func (t TransactionController) ConfirmTransaction(c *gin.Context) {
 		util.BadRequest(c, "参数错误")
 		return
 	}
-	if err := t.transactionService.ConfirmTransaction(&req); err != nil {
+	if err := t.transactionService.ConfirmTransaction(userID, &req); err != nil {
 		util.Error("确认交易记录失败", zap.Error(err))
+		if service.IsForbiddenError(err) {
+			util.Forbidden(c, err.Error())
+			return
+		}
+		if service.IsTransactionVerifyError(err) {
+			util.BadRequest(c, err.Error())
+			return
+		}
+		if service.IsUnavailableError(err) {
+			util.ServiceUnavailable(c, err.Error())
+			return
+		}
 		util.InternalServerError(c, err.Error())
 		return
 	}
@@ -0,0 +1,96 @@
+package chain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// keccak256 哈希
+func Keccak256(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// 函数选择器（前 4 字节，不带 0x）
+func selector(signature string) string {
+	return hex.EncodeToString(Keccak256([]byte(signature))[:4])
+}
+
+// 编码 uint256 参数（32 字节左补零）
+func encodeUint256(v *big.Int) string {
+	return fmt.Sprintf("%064x", v)
+}
+
+// 编码 address 参数（32 字节左补零）
+func encodeAddress(address string) (string, error) {
+	addr := strings.ToLower(strings.TrimPrefix(address, "0x"))
+	if len(addr) != 40 {
+		return "", errors.New("无效的钱包地址: " + address)
+	}
+	if _, err := hex.DecodeString(addr); err != nil {
+		return "", errors.New("无效的钱包地址: " + address)
+	}
+	return strings.Repeat("0", 24) + addr, nil
+}
+
+// 解析十六进制数量（如 "0x1a"）
+func ParseHexBig(s string) (*big.Int, error) {
+	s = strings.TrimPrefix(s, "0x")
+	if s == "" {
+		return new(big.Int), nil
+	}
+	v, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		return nil, errors.New("无效的十六进制数: " + s)
+	}
+	return v, nil
+}
+
+// 解析十六进制数量为 uint64
+func ParseHexUint64(s string) (uint64, error) {
+	v, err := ParseHexBig(s)
+	if err != nil {
+		return 0, err
+	}
+	if !v.IsUint64() {
+		return 0, errors.New("数值溢出: " + s)
+	}
+	return v.Uint64(), nil
+}
+
+// 事件 topic0（带 0x）
+func eventTopic(signature string) string {
+	return "0x" + hex.EncodeToString(Keccak256([]byte(signature)))
+}
+
+// 将 32 字节的 topic/word 解析为地址（小写，带 0x）
+func wordToAddress(word string) string {
+	word = strings.TrimPrefix(word, "0x")
+	if len(word) < 40 {
+		return ""
+	}
+	return "0x" + strings.ToLower(word[len(word)-40:])
+}
+
+// 将 data 按 32 字节切分
+func splitWords(data string) []string {
+	data = strings.TrimPrefix(data, "0x")
+	var words []string
+	for i := 0; i+64 <= len(data); i += 64 {
+		words = append(words, data[i:i+64])
+	}
+	return words
+}
+
+// wei 转 ether 字符串，保留 decimals 位小数（四舍五入）
+func WeiToEther(wei *big.Int, decimals int) string {
+	f := new(big.Float).SetPrec(256).SetInt(wei)
+	f.Quo(f, new(big.Float).SetPrec(256).SetFloat64(1e18))
+	return f.Text('f', decimals)
+}
@@ -0,0 +1,49 @@
+package chain
+
+import (
+	"context"
+	"math/big"
+)
+
+// AiDatasets 合约访问
+type ContractDAO struct {
+	rpc     *RPCClient
+	address string
+}
+
+func NewContractDAO(rpc *RPCClient, address string) *ContractDAO {
+	return &ContractDAO{
+		rpc:     rpc,
+		address: address,
+	}
+}
+
+// getter
+func (d ContractDAO) RPC() *RPCClient {
+	return d.rpc
+}
+
+// 合约地址
+func (d ContractDAO) Address() string {
+	return d.address
+}
+
+// 查询用户是否购买过数据集（hasPurchased 返回购买时间戳，非 0 即已购买）
+func (d ContractDAO) HasPurchased(ctx context.Context, datasetID uint, buyer string) (bool, error) {
+	addr, err := encodeAddress(buyer)
+	if err != nil {
+		return false, err
+	}
+	data := "0x" + selector("hasPurchased(uint256,address)") + encodeUint256(new(big.Int).SetUint64(uint64(datasetID))) + addr
+
+	var out string
+	call := map[string]string{"to": d.address, "data": data}
+	if err := d.rpc.Call(ctx, &out, "eth_call", call, "latest"); err != nil {
+		return false, err
+	}
+	ts, err := ParseHexBig(out)
+	if err != nil {
+		return false, err
+	}
+	return ts.Sign() > 0, nil
+}
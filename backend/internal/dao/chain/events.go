@@ -0,0 +1,197 @@
+package chain
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// 合约事件名称
+const (
+	EventDatasetCreated      = "DatasetCreated"
+	EventDatasetUpdated      = "DatasetUpdated"
+	EventDatasetDeactivated  = "DatasetDeactivated"
+	EventDatasetPurchase     = "DatasetPurchase"
+	EventRoleUpdated         = "RoleUpdated"
+	EventDatasetForceRemoval = "DatasetForceRemoval"
+)
+
+// 合约事件 topic0
+var (
+	TopicDatasetCreated      = eventTopic("DatasetCreated(uint256,address,uint256)")
+	TopicDatasetUpdated      = eventTopic("DatasetUpdated(uint256,address,string,string,uint256)")
+	TopicDatasetDeactivated  = eventTopic("DatasetDeactivated(uint256,address)")
+	TopicRoleUpdated         = eventTopic("RoleUpdated(address,uint8)")
+	TopicDatasetForceRemoval = eventTopic("DatasetForceRemoval(uint256,address)")
+)
+
+// 合约中 Role 枚举的取值
+const (
+	ChainRoleUser   = 0
+	ChainRoleSeller = 1
+	ChainRoleAdmin  = 2
+)
+
+var ErrUnknownEvent = errors.New("未知的合约事件")
+
+// 解析后的合约事件，按事件类型填充对应字段
+type Event struct {
+	Name        string
+	TxHash      string
+	BlockHash   string
+	BlockNumber uint64
+	LogIndex    uint64
+	DatasetID   uint64
+	Account     string   // owner / buyer / user / admin，小写地址
+	Seller      string   // 仅 DatasetPurchase
+	Price       *big.Int // DatasetCreated / DatasetUpdated / DatasetPurchase，单位：wei
+	Title       string   // 仅 DatasetUpdated
+	Description string   // 仅 DatasetUpdated
+	Role        uint64   // 仅 RoleUpdated
+}
+
+// 解析本合约的事件日志
+func DecodeEvent(l Log) (*Event, error) {
+	if len(l.Topics) == 0 {
+		return nil, ErrUnknownEvent
+	}
+	e := &Event{
+		TxHash:    l.TransactionHash,
+		BlockHash: l.BlockHash,
+	}
+	var err error
+	if e.BlockNumber, err = ParseHexUint64(l.BlockNumber); err != nil {
+		return nil, err
+	}
+	if e.LogIndex, err = ParseHexUint64(l.LogIndex); err != nil {
+		return nil, err
+	}
+
+	words := splitWords(l.Data)
+	switch strings.ToLower(l.Topics[0]) {
+	case TopicDatasetCreated:
+		if len(l.Topics) != 3 || len(words) < 1 {
+			return nil, fmt.Errorf("DatasetCreated 日志格式错误: %s", l.TransactionHash)
+		}
+		e.Name = EventDatasetCreated
+		e.Account = wordToAddress(l.Topics[2])
+		e.Price, err = ParseHexBig(words[0])
+	case TopicDatasetUpdated:
+		if len(l.Topics) != 3 || len(words) < 3 {
+			return nil, fmt.Errorf("DatasetUpdated 日志格式错误: %s", l.TransactionHash)
+		}
+		e.Name = EventDatasetUpdated
+		e.Account = wordToAddress(l.Topics[2])
+		if e.Title, err = decodeString(words, words[0]); err != nil {
+			return nil, err
+		}
+		if e.Description, err = decodeString(words, words[1]); err != nil {
+			return nil, err
+		}
+		e.Price, err = ParseHexBig(words[2])
+	case TopicDatasetDeactivated:
+		if len(l.Topics) != 3 {
+			return nil, fmt.Errorf("DatasetDeactivated 日志格式错误: %s", l.TransactionHash)
+		}
+		e.Name = EventDatasetDeactivated
+		e.Account = wordToAddress(l.Topics[2])
+	case TopicDatasetForceRemoval:
+		if len(l.Topics) != 3 {
+			return nil, fmt.Errorf("DatasetForceRemoval 日志格式错误: %s", l.TransactionHash)
+		}
+		e.Name = EventDatasetForceRemoval
+		e.Account = wordToAddress(l.Topics[2])
+	case TopicDatasetPurchase:
+		p, err := DecodePurchaseLog(l)
+		if err != nil {
+			return nil, err
+		}
+		e.Name = EventDatasetPurchase
+		e.DatasetID = p.DatasetID
+		e.Account = p.Buyer
+		e.Seller = p.Seller
+		e.Price = p.Price
+		return e, nil
+	case TopicRoleUpdated:
+		if len(l.Topics) != 2 || len(words) < 1 {
+			return nil, fmt.Errorf("RoleUpdated 日志格式错误: %s", l.TransactionHash)
+		}
+		e.Name = EventRoleUpdated
+		e.Account = wordToAddress(l.Topics[1])
+		e.Role, err = ParseHexUint64(words[0])
+		return e, err
+	default:
+		return nil, ErrUnknownEvent
+	}
+	if err != nil {
+		return nil, err
+	}
+	// 除 RoleUpdated 外，topic1 均为 datasetId
+	e.DatasetID, err = ParseHexUint64(l.Topics[1])
+	return e, err
+}
+
+// 解析 ABI 编码的动态 string，offset 为该参数在 data 中的字节偏移
+func decodeString(words []string, offset string) (string, error) {
+	off, err := ParseHexUint64(offset)
+	if err != nil {
+		return "", err
+	}
+	if off%32 != 0 || off/32 >= uint64(len(words)) {
+		return "", fmt.Errorf("string 偏移量越界: %d", off)
+	}
+	start := off / 32
+	length, err := ParseHexUint64(words[start])
+	if err != nil {
+		return "", err
+	}
+	n := (length + 31) / 32
+	if length > uint64(len(words))*32 || start+1+n > uint64(len(words)) {
+		return "", fmt.Errorf("string 长度越界: %d", length)
+	}
+	raw, err := hex.DecodeString(strings.Join(words[start+1:start+1+n], ""))
+	if err != nil {
+		return "", err
+	}
+	return string(raw[:length]), nil
+}
+
+// 查询最新区块高度
+func (d ContractDAO) BlockNumber(ctx context.Context) (uint64, error) {
+	var out string
+	if err := d.rpc.Call(ctx, &out, "eth_blockNumber"); err != nil {
+		return 0, err
+	}
+	return ParseHexUint64(out)
+}
+
+// 查询区块哈希，区块不存在时返回空字符串
+func (d ContractDAO) GetBlockHash(ctx context.Context, blockNumber uint64) (string, error) {
+	var block *struct {
+		Hash string `json:"hash"`
+	}
+	if err := d.rpc.Call(ctx, &block, "eth_getBlockByNumber", fmt.Sprintf("0x%x", blockNumber), false); err != nil {
+		return "", err
+	}
+	if block == nil {
+		return "", nil
+	}
+	return block.Hash, nil
+}
+
+// 查询 [fromBlock, toBlock] 区间内本合约的全部事件日志
+func (d ContractDAO) GetLogs(ctx context.Context, fromBlock, toBlock uint64) ([]Log, error) {
+	var logs []Log
+	filter := map[string]interface{}{
+		"address":   d.address,
+		"fromBlock": fmt.Sprintf("0x%x", fromBlock),
+		"toBlock":   fmt.Sprintf("0x%x", toBlock),
+	}
+	if err := d.rpc.Call(ctx, &logs, "eth_getLogs", filter); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
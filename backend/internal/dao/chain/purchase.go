@@ -0,0 +1,163 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrReceiptNotFound = errors.New("交易尚未上链或不存在")
+	ErrTxReverted      = errors.New("链上交易执行失败")
+	ErrEventNotFound   = errors.New("交易中未找到 DatasetPurchase 事件")
+)
+
+// DatasetPurchase(uint256 indexed datasetId, address indexed buyer, address indexed seller, uint256 price)
+var TopicDatasetPurchase = eventTopic("DatasetPurchase(uint256,address,address,uint256)")
+
+// 交易回执
+type Receipt struct {
+	TransactionHash string `json:"transactionHash"`
+	BlockHash       string `json:"blockHash"`
+	BlockNumber     string `json:"blockNumber"`
+	From            string `json:"from"`
+	GasUsed         string `json:"gasUsed"`
+	Status          string `json:"status"`
+	Logs            []Log  `json:"logs"`
+}
+
+// 事件日志
+type Log struct {
+	Address         string   `json:"address"`
+	Topics          []string `json:"topics"`
+	Data            string   `json:"data"`
+	BlockNumber     string   `json:"blockNumber"`
+	BlockHash       string   `json:"blockHash"`
+	TransactionHash string   `json:"transactionHash"`
+	LogIndex        string   `json:"logIndex"`
+	Removed         bool     `json:"removed"`
+}
+
+// 链上购买事件
+type PurchaseEvent struct {
+	TxHash         string
+	BlockHash      string
+	BlockNumber    uint64
+	BlockTimestamp int64
+	Nonce          uint64
+	GasUsed        uint64
+	DatasetID      uint64
+	Buyer          string   // 小写地址
+	Seller         string   // 小写地址
+	Price          *big.Int // 单位：wei
+}
+
+// 解析 DatasetPurchase 日志
+func DecodePurchaseLog(l Log) (*PurchaseEvent, error) {
+	if len(l.Topics) != 4 || !strings.EqualFold(l.Topics[0], TopicDatasetPurchase) {
+		return nil, ErrEventNotFound
+	}
+	words := splitWords(l.Data)
+	if len(words) < 1 {
+		return nil, fmt.Errorf("DatasetPurchase data 长度错误: %s", l.Data)
+	}
+	datasetID, err := ParseHexUint64(l.Topics[1])
+	if err != nil {
+		return nil, err
+	}
+	price, err := ParseHexBig(words[0])
+	if err != nil {
+		return nil, err
+	}
+	return &PurchaseEvent{
+		TxHash:    l.TransactionHash,
+		BlockHash: l.BlockHash,
+		DatasetID: datasetID,
+		Buyer:     wordToAddress(l.Topics[2]),
+		Seller:    wordToAddress(l.Topics[3]),
+		Price:     price,
+	}, nil
+}
+
+// 获取交易回执，交易未打包时返回 ErrReceiptNotFound
+func (d ContractDAO) GetReceipt(ctx context.Context, txHash string) (*Receipt, error) {
+	var receipt *Receipt
+	if err := d.rpc.Call(ctx, &receipt, "eth_getTransactionReceipt", txHash); err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, ErrReceiptNotFound
+	}
+	return receipt, nil
+}
+
+// 获取区块时间戳
+func (d ContractDAO) GetBlockTimestamp(ctx context.Context, blockNumber uint64) (int64, error) {
+	var block *struct {
+		Timestamp string `json:"timestamp"`
+	}
+	if err := d.rpc.Call(ctx, &block, "eth_getBlockByNumber", fmt.Sprintf("0x%x", blockNumber), false); err != nil {
+		return 0, err
+	}
+	if block == nil {
+		return 0, fmt.Errorf("区块 %d 不存在", blockNumber)
+	}
+	ts, err := ParseHexUint64(block.Timestamp)
+	return int64(ts), err
+}
+
+// 获取交易 nonce
+func (d ContractDAO) GetTransactionNonce(ctx context.Context, txHash string) (uint64, error) {
+	var tx *struct {
+		Nonce string `json:"nonce"`
+	}
+	if err := d.rpc.Call(ctx, &tx, "eth_getTransactionByHash", txHash); err != nil {
+		return 0, err
+	}
+	if tx == nil {
+		return 0, ErrReceiptNotFound
+	}
+	return ParseHexUint64(tx.Nonce)
+}
+
+// 根据交易哈希查询并解析本合约的 DatasetPurchase 事件
+func (d ContractDAO) GetPurchaseEvent(ctx context.Context, txHash string) (*PurchaseEvent, error) {
+	receipt, err := d.GetReceipt(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if status, err := ParseHexUint64(receipt.Status); err != nil || status != 1 {
+		return nil, ErrTxReverted
+	}
+
+	var event *PurchaseEvent
+	for _, l := range receipt.Logs {
+		if !strings.EqualFold(l.Address, d.address) {
+			continue
+		}
+		if event, err = DecodePurchaseLog(l); err == nil {
+			break
+		}
+	}
+	if event == nil {
+		return nil, ErrEventNotFound
+	}
+
+	event.TxHash = receipt.TransactionHash
+	event.BlockHash = receipt.BlockHash
+	if event.BlockNumber, err = ParseHexUint64(receipt.BlockNumber); err != nil {
+		return nil, err
+	}
+	if event.GasUsed, err = ParseHexUint64(receipt.GasUsed); err != nil {
+		return nil, err
+	}
+	if event.BlockTimestamp, err = d.GetBlockTimestamp(ctx, event.BlockNumber); err != nil {
+		return nil, err
+	}
+	if event.Nonce, err = d.GetTransactionNonce(ctx, txHash); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
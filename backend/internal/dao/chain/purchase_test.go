@@ -0,0 +1,130 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	testContract = "0x5fbdb2315678afecb367f032d93f642f64180aa3"
+	testBuyer    = "0x70997970c51812dc3a010c7d01b50e0d17dc79c8"
+	testSeller   = "0x3c44cdddb6a900fa2b585dd299e03d12fa4293bc"
+	testTxHash   = "0x9f2c2b6b2a4f0e2f1c1d2e8a4b6c7d8e9f0a1b2c3d4e5f60718293a4b5c6d7e8"
+)
+
+// 模拟节点，按方法名返回固定结果
+func newFakeNode(t *testing.T, results map[string]interface{}) *ContractDAO {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result, ok := results[req.Method]
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result}
+		if !ok {
+			resp = map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": RPCError{Code: -32601, Message: "method not found: " + req.Method}}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return NewContractDAO(NewRPCClient(srv.URL), testContract)
+}
+
+func topicWord(hex string) string {
+	return "0x" + strings.Repeat("0", 64-len(hex)) + hex
+}
+
+func purchaseLog(address string, datasetID uint64, price *big.Int) map[string]interface{} {
+	return map[string]interface{}{
+		"address": address,
+		"topics": []string{
+			TopicDatasetPurchase,
+			topicWord(fmt.Sprintf("%x", datasetID)),
+			topicWord(strings.TrimPrefix(testBuyer, "0x")),
+			topicWord(strings.TrimPrefix(testSeller, "0x")),
+		},
+		"data":            "0x" + encodeUint256(price),
+		"transactionHash": testTxHash,
+		"logIndex":        "0x0",
+	}
+}
+
+func fakeResults(status string, logs ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"eth_getTransactionReceipt": map[string]interface{}{
+			"transactionHash": testTxHash,
+			"blockHash":       "0xabc",
+			"blockNumber":     "0x10",
+			"from":            testBuyer,
+			"gasUsed":         "0x5208",
+			"status":          status,
+			"logs":            logs,
+		},
+		"eth_getBlockByNumber":     map[string]string{"timestamp": "0x65000000"},
+		"eth_getTransactionByHash": map[string]string{"nonce": "0x7"},
+	}
+}
+
+func TestGetPurchaseEvent(t *testing.T) {
+	price := new(big.Int).Mul(big.NewInt(15), big.NewInt(1e17)) // 1.5 ETH
+	dao := newFakeNode(t, fakeResults("0x1", purchaseLog(testContract, 42, price)))
+
+	e, err := dao.GetPurchaseEvent(context.Background(), testTxHash)
+	if err != nil {
+		t.Fatalf("GetPurchaseEvent: %v", err)
+	}
+	if e.DatasetID != 42 || e.Buyer != testBuyer || e.Seller != testSeller {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+	if e.Price.Cmp(price) != 0 || WeiToEther(e.Price, 8) != "1.50000000" {
+		t.Fatalf("price = %s", e.Price)
+	}
+	if e.BlockNumber != 16 || e.GasUsed != 21000 || e.Nonce != 7 || e.BlockTimestamp != 0x65000000 {
+		t.Fatalf("unexpected block info: %+v", e)
+	}
+}
+
+func TestGetPurchaseEventReverted(t *testing.T) {
+	dao := newFakeNode(t, fakeResults("0x0", purchaseLog(testContract, 42, big.NewInt(1))))
+	if _, err := dao.GetPurchaseEvent(context.Background(), testTxHash); !errors.Is(err, ErrTxReverted) {
+		t.Fatalf("err = %v, want ErrTxReverted", err)
+	}
+}
+
+func TestGetPurchaseEventWrongContract(t *testing.T) {
+	other := "0x0000000000000000000000000000000000000bad"
+	dao := newFakeNode(t, fakeResults("0x1", purchaseLog(other, 42, big.NewInt(1))))
+	if _, err := dao.GetPurchaseEvent(context.Background(), testTxHash); !errors.Is(err, ErrEventNotFound) {
+		t.Fatalf("err = %v, want ErrEventNotFound", err)
+	}
+}
+
+func TestGetPurchaseEventWrongAmount(t *testing.T) {
+	// 金额以链上事件为准，不受客户端提交的数据影响
+	onChain := big.NewInt(1e16) // 0.01 ETH
+	dao := newFakeNode(t, fakeResults("0x1", purchaseLog(testContract, 42, onChain)))
+
+	e, err := dao.GetPurchaseEvent(context.Background(), testTxHash)
+	if err != nil {
+		t.Fatalf("GetPurchaseEvent: %v", err)
+	}
+	if got := WeiToEther(e.Price, 8); got != "0.01000000" {
+		t.Fatalf("price = %s, want on-chain 0.01000000", got)
+	}
+}
+
+func TestGetPurchaseEventPending(t *testing.T) {
+	dao := newFakeNode(t, map[string]interface{}{"eth_getTransactionReceipt": nil})
+	if _, err := dao.GetPurchaseEvent(context.Background(), testTxHash); !errors.Is(err, ErrReceiptNotFound) {
+		t.Fatalf("err = %v, want ErrReceiptNotFound", err)
+	}
+}
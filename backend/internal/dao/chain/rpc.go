@@ -0,0 +1,91 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// 以太坊 JSON-RPC 客户端
+type RPCClient struct {
+	url        string
+	httpClient *http.Client
+	nextID     uint64
+}
+
+func NewRPCClient(url string) *RPCClient {
+	return &RPCClient{
+		url:        url,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// 节点返回的错误
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// 调用 RPC 方法，result 为 nil 时忽略返回值
+func (c *RPCClient) Call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      atomic.AddUint64(&c.nextID, 1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("rpc http status %d", resp.StatusCode)
+	}
+
+	var res rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(res.Result, result)
+}
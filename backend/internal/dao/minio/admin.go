@@ -4,6 +4,7 @@ import (
 	"backend/internal/model"
 	"context"
 	"github.com/minio/minio-go/v7"
+	"io"
 )
 
 type AdminMinioDAO struct {
@@ -61,3 +62,36 @@ func (d AdminMinioDAO) GetObjects(bucket string) ([]model.MinioObjectResponse, e
 func (d AdminMinioDAO) DeleteMinioObject(bucket string, name string) error {
 	return d.minioClient.RemoveObject(context.Background(), bucket, name, minio.RemoveObjectOptions{})
 }
+
+// 获取对象大小，对象不存在时返回 minio 的 NoSuchKey 错误
+func (d AdminMinioDAO) StatObject(ctx context.Context, bucket, name string) (int64, error) {
+	info, err := d.minioClient.StatObject(ctx, bucket, name, minio.StatObjectOptions{})
+	return info.Size, err
+}
+
+// 获取对象读取器（流式读取，不落盘）
+func (d AdminMinioDAO) GetObjectReader(ctx context.Context, bucket, name string) (io.ReadCloser, error) {
+	return d.minioClient.GetObject(ctx, bucket, name, minio.GetObjectOptions{})
+}
+
+// 获取对象读取器及其 ETag，读取过程中对象被覆盖时返回 PreconditionFailed 错误，保证读到的内容与 ETag 对应
+func (d AdminMinioDAO) GetObjectReaderWithETag(ctx context.Context, bucket, name string) (io.ReadCloser, string, error) {
+	info, err := d.minioClient.StatObject(ctx, bucket, name, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, "", err
+	}
+	opts := minio.GetObjectOptions{}
+	if err = opts.SetMatchETag(info.ETag); err != nil {
+		return nil, "", err
+	}
+	obj, err := d.minioClient.GetObject(ctx, bucket, name, opts)
+	return obj, info.ETag, err
+}
+
+// 上传对象
+func (d AdminMinioDAO) PutObject(ctx context.Context, bucket, name string, r io.Reader, size int64) error {
+	_, err := d.minioClient.PutObject(ctx, bucket, name, r, size, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+	})
+	return err
+}
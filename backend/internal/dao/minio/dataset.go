@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"time"
 
@@ -30,9 +31,9 @@ func NewDatasetMinioDAO(minioClient *minio.Client, core *minio.Core, bucket, buc
 	}
 }
 
-// 获取上传预览数据预签名 url
-func (m *DatasetMinioDAO) GetUploadPreviewURL(userID uint) (string, error) {
-	objectName := m.GenerateDatasetObjectName(userID, util.DATASET_EXTENSION)
+// 获取上传预览数据预签名 url，对象扩展名取自 filename
+func (m *DatasetMinioDAO) GetUploadPreviewURL(userID uint, filename string) (string, error) {
+	objectName := m.GenerateDatasetObjectName(userID, filename)
 	url, err := m.minioClient.PresignedPutObject(context.Background(), m.Bucket, objectName, util.DATASET_EXPIRE*time.Minute)
 	if err != nil {
 		return "", err
@@ -46,6 +47,20 @@ func (m *DatasetMinioDAO) GenerateDatasetObjectName(userID uint, originalFilenam
 	return fmt.Sprintf("%d_%d%s", userID, time.Now().Unix(), ext)
 }
 
+var datasetObjectNamePattern = regexp.MustCompile(`^[0-9]+_[0-9]+(\.[^./]*)?$`)
+
+// 是否为 GenerateDatasetObjectName 生成的文件名（管理员放入桶中的其他文件不匹配）
+func IsDatasetObjectName(objectName string) bool {
+	return datasetObjectNamePattern.MatchString(objectName)
+}
+
+// 生成临时桶文件名（买家ID/时间戳/任务ID），不同买家、不同任务的带指纹文件互不覆盖
+// 第二段保持为时间戳，供临时桶清理判断过期
+func (m *DatasetMinioDAO) GenerateTempObjectName(userID, jobID uint, sourceObject string) string {
+	ext := filepath.Ext(sourceObject)
+	return fmt.Sprintf("%d_%d_job%d%s", userID, time.Now().Unix(), jobID, ext)
+}
+
 // 初始化分片上传
 func (m *DatasetMinioDAO) InitiateMultipartUpload(objectName string) (string, error) {
 	uploadID, err := m.core.NewMultipartUpload(context.Background(), m.Bucket, objectName, minio.PutObjectOptions{
@@ -81,6 +96,51 @@ func (m *DatasetMinioDAO) AbortMultipartUpload(objectName, uploadId string) erro
 	return m.core.AbortMultipartUpload(context.Background(), m.Bucket, objectName, uploadId)
 }
 
+// 查询已上传的分片（按分片号升序，自动翻页）
+func (m *DatasetMinioDAO) ListObjectParts(ctx context.Context, objectName, uploadId string) ([]minio.ObjectPart, error) {
+	var parts []minio.ObjectPart
+	marker := 0
+	for {
+		res, err := m.core.ListObjectParts(ctx, m.Bucket, objectName, uploadId, marker, 1000)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, res.ObjectParts...)
+		if !res.IsTruncated {
+			return parts, nil
+		}
+		marker = res.NextPartNumberMarker
+	}
+}
+
+// 查询数据集桶中未完成的分片上传
+func (m *DatasetMinioDAO) ListIncompleteUploads(ctx context.Context) ([]minio.ObjectMultipartInfo, error) {
+	var uploads []minio.ObjectMultipartInfo
+	for u := range m.minioClient.ListIncompleteUploads(ctx, m.Bucket, "", true) {
+		if u.Err != nil {
+			return nil, u.Err
+		}
+		uploads = append(uploads, u)
+	}
+	return uploads, nil
+}
+
+// 从 startAfter 之后按对象名顺序逐个遍历数据集桶中的对象（MinIO 分页列出，不整体加载），fn 返回 false 时停止
+func (m *DatasetMinioDAO) WalkObjects(ctx context.Context, startAfter string, fn func(o minio.ObjectInfo) bool) error {
+	// 提前停止时取消列出，结束后台的分页请求
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for o := range m.minioClient.ListObjects(ctx, m.Bucket, minio.ListObjectsOptions{Recursive: true, StartAfter: startAfter}) {
+		if o.Err != nil {
+			return o.Err
+		}
+		if !fn(o) {
+			return nil
+		}
+	}
+	return nil
+}
+
 // 删除数据集
 func (m *DatasetMinioDAO) DeleteObject(objectName string) error {
 	return m.minioClient.RemoveObject(context.Background(), m.Bucket, objectName, minio.RemoveObjectOptions{})
@@ -92,9 +152,21 @@ func (m *DatasetMinioDAO) GetDownloadFreeDatasetURL(objectName string) (string,
 	return url.String(), err
 }
 
-// 获取数据集读取器
-func (m *DatasetMinioDAO) GetDatesetReader(ctx context.Context, objectName string) (io.Reader, error) {
-	return m.minioClient.GetObject(ctx, m.Bucket, objectName, minio.GetObjectOptions{})
+// 获取数据集读取器，etag 不为空时要求对象 ETag 一致，否则读取时返回 PreconditionFailed 错误
+func (m *DatasetMinioDAO) GetDatesetReader(ctx context.Context, objectName, etag string) (io.Reader, error) {
+	opts := minio.GetObjectOptions{}
+	if etag != "" {
+		if err := opts.SetMatchETag(etag); err != nil {
+			return nil, err
+		}
+	}
+	return m.minioClient.GetObject(ctx, m.Bucket, objectName, opts)
+}
+
+// 获取数据集对象的 ETag
+func (m *DatasetMinioDAO) GetObjectETag(ctx context.Context, objectName string) (string, error) {
+	info, err := m.minioClient.StatObject(ctx, m.Bucket, objectName, minio.StatObjectOptions{})
+	return info.ETag, err
 }
 
 // 上传到 minio 临时桶
@@ -23,14 +23,24 @@ func (d DatasetsPreviewDAO) Client() *mongo.Database {
 	return d.mongoClient
 }
 
-// 存入预览数据
+// 存入预览数据（卖家上传），按对象名覆盖，不影响服务端生成的字段
 func (d DatasetsPreviewDAO) SavePreviewData(req *model.UploadPreviewRequest) error {
 	collection := d.mongoClient.Collection("datasets_preview")
-	_, err := collection.InsertOne(context.Background(), req, options.InsertOne())
-	if err != nil {
-		return err
+	filter := bson.M{"objectName": req.ObjectName}
+	_, err := collection.UpdateOne(context.Background(), filter, bson.M{"$set": req}, options.Update().SetUpsert(true))
+	return err
+}
+
+// 写入服务端生成的预览数据（文件校验 worker），文档不存在时创建
+func (d DatasetsPreviewDAO) SaveGeneratedPreview(objectName string, fileSize int64, preview *model.GeneratedPreview) error {
+	collection := d.mongoClient.Collection("datasets_preview")
+	filter := bson.M{"objectName": objectName}
+	update := bson.M{
+		"$set":         preview,
+		"$setOnInsert": bson.M{"fileSize": fileSize},
 	}
-	return nil
+	_, err := collection.UpdateOne(context.Background(), filter, update, options.Update().SetUpsert(true))
+	return err
 }
 
 // 删除预览数据
@@ -41,12 +51,16 @@ func (d DatasetsPreviewDAO) DeletePreviewData(objectName string) error {
 	return err
 }
 
-// 获取预览数据
-func (d DatasetsPreviewDAO) GetPreviewData(name string) (model.PreviewResponse, error) {
+// 获取预览数据，limit 大于 0 时只返回 sample 中从 skip 开始的 limit 条
+func (d DatasetsPreviewDAO) GetPreviewData(name string, skip, limit int) (model.PreviewResponse, error) {
 	collection := d.mongoClient.Collection("datasets_preview")
 	filter := bson.M{"objectName": name}
+	opts := options.FindOne()
+	if limit > 0 {
+		opts.SetProjection(bson.M{"sample": bson.M{"$slice": []int{skip, limit}}})
+	}
 	var res model.PreviewResponse
-	err := collection.FindOne(context.Background(), filter).Decode(&res)
+	err := collection.FindOne(context.Background(), filter, opts).Decode(&res)
 	if err != nil {
 		return model.PreviewResponse{}, err
 	}
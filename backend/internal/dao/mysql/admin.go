@@ -3,7 +3,9 @@ package mysql
 import (
 	"backend/internal/model"
 	"backend/internal/util"
+	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"time"
 )
 
@@ -188,21 +190,18 @@ func (d AdminDAO) AddUser(m *model.AdminAddUserRequest) error {
 
 // 连表
 // 获取数据集列表
-func (d AdminDAO) GetDatasets(page int, limit int) ([]model.AdminDatasetListResponse, int64, int, error) {
+func (d AdminDAO) GetDatasets(pq *model.PageQuery, q *model.DatasetQuery) ([]model.AdminDatasetListResponse, model.PageInfo, error) {
 	// 连表查询，查询数据集列表所有数据并根据其中的钱包地址查询user表中的用户名
 	db := d.db.Table("datasets").
 		Select("datasets.*, users.username").
 		Joins("left join users on datasets.author_wallet_address = users.wallet_address").
-		Order("datasets.created_at DESC")
-
-	var total int64
-	db.Count(&total)
-	offset := (page - 1) * limit
+		Scopes(DatasetQueryScope(q))
+	return paginateDatasets(db, pq, adminDatasetListKey)
+}
 
-	var datasets []model.AdminDatasetListResponse
-	err := db.Limit(limit).Offset(offset).Find(&datasets).Error
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-	return datasets, total, totalPages, err
+func adminDatasetListKey(ds *model.AdminDatasetListResponse) (datasetKeyRow, error) {
+	createdAt, err := parseCreatedAt(ds.CreatedAt)
+	return datasetKeyRow{ID: ds.ID, CreatedAt: createdAt, DownloadCount: int64(ds.DownloadCount), Price: ds.Price, FileSize: ds.FileSize}, err
 }
 
 // 删除数据集
@@ -210,6 +209,11 @@ func (d AdminDAO) DeleteDataset(tx *gorm.DB, datasetID int) error {
 	return tx.Model(&model.Dataset{}).Where("id = ?", datasetID).Update("deleted_at", time.Now()).Error
 }
 
+// 数据集全部版本的对象名（管理员删除/恢复数据集时处理每个版本的文件）
+func (d AdminDAO) GetVersionObjectNamesTX(tx *gorm.DB, datasetID int) ([]string, error) {
+	return versionObjectNamesTX(tx, uint(datasetID))
+}
+
 // 恢复删除的数据集
 func (d AdminDAO) RestoreDataset(tx *gorm.DB, datasetID int) error {
 	// Unscoped() 禁用deleted_at = NULL 的查询
@@ -217,59 +221,154 @@ func (d AdminDAO) RestoreDataset(tx *gorm.DB, datasetID int) error {
 }
 
 // 添加指纹检测记录
-func (d AdminDAO) CreateFingerprintDetectRecord(filename string, fileSize int64, detectionResult, detectTime string) error {
-	record := model.DetectRecord{
-		DatasetID:       nil,
-		Filename:        filename,
-		FileSize:        fileSize,
-		Timestamp:       nil,
-		DetectionTime:   detectTime,
-		DetectionResult: detectionResult,
-	}
-	if detectionResult == "无指纹" {
-		return d.db.Create(&record).Error
-	}
-	// 有指纹 => detectionResult是时间戳
-	var result struct {
-		BuyerWalletAddress string
-		DatasetID          int
-	}
-	// 根据时间戳查询买家钱包地址和数据集id
-	if err := d.db.Table("transactions").Where("block_timestamp = ?", detectionResult).Pluck("buyer_wallet_address, dataset_id", &result).Error; err != nil {
-		return err
+func (d AdminDAO) CreateFingerprintDetectRecord(record *model.DetectRecord) error {
+	return d.db.Create(record).Error
+}
+
+// 保存检测记录的匹配报告及其摘要，已保存过的记录不再覆盖；返回 false 表示报告已存在
+func (d AdminDAO) SaveDetectReport(id int, reportJSON, digest string) (bool, error) {
+	result := d.db.Model(&model.DetectRecord{}).
+		Where("id = ? AND (report_digest IS NULL OR report_digest = '')", id).
+		Updates(map[string]interface{}{
+			"report_json":   reportJSON,
+			"report_digest": digest,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// 查询单条指纹检测记录，不存在时返回 nil
+func (d AdminDAO) GetDetectRecord(id int) (*model.DetectRecord, error) {
+	var record model.DetectRecord
+	err := d.db.First(&record, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
 	}
-	record.Timestamp = &detectionResult
-	if result.BuyerWalletAddress == "" {
-		record.DetectionResult = "未知指纹"
-		return d.db.Create(&record).Error
+	if err != nil {
+		return nil, err
 	}
-	record.DetectionResult = result.BuyerWalletAddress
-	record.DatasetID = &result.DatasetID
-	return d.db.Create(&record).Error
+	return &record, nil
 }
 
-// 获取指纹检测记录
-func (d AdminDAO) GetDetectRecords(page int, limit int) ([]model.DetectRecordResponse, int64, int, error) {
+// 获取指纹检测记录，按状态、数据集、交易、买家、置信度和检测日期筛选
+func (d AdminDAO) GetDetectRecords(q *model.DetectRecordQuery) ([]model.DetectRecordResponse, int64, int, error) {
 	db := d.db.Model(&model.DetectRecord{})
+	if q.Status != "" {
+		db = db.Where("status = ?", q.Status)
+	}
+	if q.DatasetID != 0 {
+		db = db.Where("dataset_id = ?", q.DatasetID)
+	}
+	if q.TransactionID != 0 {
+		db = db.Where("transaction_id = ?", q.TransactionID)
+	}
+	if q.BuyerWallet != "" {
+		db = db.Where("buyer_wallet = ?", q.BuyerWallet)
+	}
+	if q.BuyerUserID != 0 {
+		db = db.Where("buyer_user_id = ?", q.BuyerUserID)
+	}
+	if q.MinConfidence > 0 {
+		db = db.Where("confidence >= ?", q.MinConfidence)
+	}
+	if q.CreatedFrom != nil {
+		db = db.Where("created_at >= ?", *q.CreatedFrom)
+	}
+	if q.CreatedTo != nil {
+		db = db.Where("created_at < ?", *q.CreatedTo)
+	}
 
 	var total int64
-	db.Count(&total)
-	offset := (page - 1) * limit
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, 0, err
+	}
+	offset := (q.Page - 1) * q.Limit
 
 	var records []model.DetectRecordResponse
-	err := db.Order("created_at DESC").Limit(limit).Offset(offset).Find(&records).Error
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	err := db.Order("created_at DESC").Limit(q.Limit).Offset(offset).Find(&records).Error
+	totalPages := int((total + int64(q.Limit) - 1) / int64(q.Limit))
 	return records, total, totalPages, err
 }
 
-// 获取指纹地址
-func (d AdminDAO) GetFingerprintAddressByTimeStamp(timestamp string) (string, error) {
-	var buyerWalletAddress string
-	if err := d.db.Table("transactions").Where("block_timestamp = ?", timestamp).Pluck("buyer_wallet_address", &buyerWalletAddress).Error; err != nil {
+// 根据钱包地址查询用户 ID，不存在时返回 0
+func (d AdminDAO) GetUserIDByWallet(wallet string) (uint, error) {
+	var ids []uint
+	err := d.db.Model(&model.User{}).Where("wallet_address = ?", wallet).Limit(1).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// 查询数据集标题（包含已删除的数据集）
+func (d AdminDAO) GetDatasetTitle(datasetID uint) (string, error) {
+	var titles []string
+	err := d.db.Unscoped().Model(&model.Dataset{}).Where("id = ?", datasetID).Limit(1).Pluck("title", &titles).Error
+	if err != nil || len(titles) == 0 {
 		return "", err
 	}
-	if buyerWalletAddress == "" {
-		buyerWalletAddress = "未知指纹"
+	return titles[0], nil
+}
+
+// 根据交易记录 ID 查询已完成交易（v2 指纹），不存在时返回 nil
+func (d AdminDAO) GetCompletedTransactionByID(id uint64) (*model.Transaction, error) {
+	var tx model.Transaction
+	err := d.db.Where("id = ? AND status = ?", id, "completed").First(&tx).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
-	return buyerWalletAddress, nil
+	return &tx, nil
+}
+
+// 根据区块时间戳查询交易（v1 指纹），同一秒内的多笔购买会全部返回
+func (d AdminDAO) GetTransactionsByTimestamp(timestamp string) ([]model.Transaction, error) {
+	var txs []model.Transaction
+	err := d.db.Where("block_timestamp = ?", timestamp).Order("id ASC").Find(&txs).Error
+	return txs, err
+}
+
+// 获取重复文件记录，附带双方数据集的标题和作者，按发现时间倒序
+func (d AdminDAO) GetDuplicates(q *model.DuplicateQuery) ([]model.DuplicateResponse, int64, int, error) {
+	db := d.db.Model(&model.DatasetDuplicate{})
+	if q.Status != "" {
+		db = db.Where("dataset_duplicates.status = ?", q.Status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, 0, err
+	}
+	offset := (q.Page - 1) * q.Limit
+
+	var records []model.DuplicateResponse
+	err := db.Select("dataset_duplicates.*, d.title, d.author_wallet_address, o.title AS original_title, o.author_wallet_address AS original_author_address").
+		Joins("LEFT JOIN datasets d ON d.id = dataset_duplicates.dataset_id").
+		Joins("LEFT JOIN datasets o ON o.id = dataset_duplicates.original_dataset_id").
+		Order("dataset_duplicates.created_at DESC").Limit(q.Limit).Offset(offset).Find(&records).Error
+	totalPages := int((total + int64(q.Limit) - 1) / int64(q.Limit))
+	return records, total, totalPages, err
+}
+
+// 锁定重复文件记录 TX
+func (d AdminDAO) LockDuplicateTX(tx *gorm.DB, id uint) (*model.DatasetDuplicate, error) {
+	var m model.DatasetDuplicate
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&m).Error
+	return &m, err
+}
+
+// 写入审核结论 TX
+func (d AdminDAO) ReviewDuplicateTX(tx *gorm.DB, id uint, status string, reviewerID uint, note string) error {
+	return tx.Model(&model.DatasetDuplicate{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":      status,
+		"reviewer_id": reviewerID,
+		"review_note": note,
+		"reviewed_at": time.Now(),
+	}).Error
+}
+
+// 更新数据集状态 TX（包含已删除的数据集）
+func (d AdminDAO) UpdateDatasetStatusTX(tx *gorm.DB, datasetID uint, status string) error {
+	return tx.Unscoped().Model(&model.Dataset{}).Where("id = ?", datasetID).Update("status", status).Error
 }
@@ -0,0 +1,233 @@
+package mysql
+
+import (
+	"backend/internal/model"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ChainIndexerDAO struct {
+	db *gorm.DB
+}
+
+func NewChainIndexerDAO(db *gorm.DB) *ChainIndexerDAO {
+	return &ChainIndexerDAO{db: db}
+}
+
+// getter
+func (d ChainIndexerDAO) DB() *gorm.DB {
+	return d.db
+}
+
+// 查询同步进度，不存在时返回 nil
+func (d ChainIndexerDAO) GetCheckpoint(name string) (*model.ChainCheckpoint, error) {
+	var checkpoint model.ChainCheckpoint
+	err := d.db.Where("name = ?", name).First(&checkpoint).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// 保存同步进度 TX
+func (d ChainIndexerDAO) SaveCheckpointTX(tx *gorm.DB, name string, blockNumber uint64, blockHash string) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"block_number", "block_hash", "updated_at"}),
+	}).Create(&model.ChainCheckpoint{
+		Name:        name,
+		BlockNumber: blockNumber,
+		BlockHash:   blockHash,
+	}).Error
+}
+
+// 保存撤销事件所需的数据 TX
+func (d ChainIndexerDAO) SaveEventUndoTX(tx *gorm.DB, id uint, undo []byte) error {
+	return tx.Model(&model.ChainEvent{}).Where("id = ?", id).Update("undo", undo).Error
+}
+
+// 查询 fromBlock 及之后已处理的事件，按处理顺序倒序排列
+func (d ChainIndexerDAO) ListEventsFromBlock(fromBlock uint64) ([]model.ChainEvent, error) {
+	var events []model.ChainEvent
+	err := d.db.Where("block_number >= ?", fromBlock).Order("block_number DESC, log_index DESC").Find(&events).Error
+	return events, err
+}
+
+// 删除已处理事件 TX
+func (d ChainIndexerDAO) DeleteEventTX(tx *gorm.DB, id uint) error {
+	return tx.Delete(&model.ChainEvent{}, id).Error
+}
+
+// 记录已处理事件，事件已存在时返回 false
+func (d ChainIndexerDAO) InsertEventTX(tx *gorm.DB, m *model.ChainEvent) (bool, error) {
+	var count int64
+	if err := tx.Model(&model.ChainEvent{}).Where("tx_hash = ? AND log_index = ?", m.TxHash, m.LogIndex).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	return true, tx.Create(m).Error
+}
+
+// 按链上数据更新数据集（仅更新作者匹配的记录）
+func (d ChainIndexerDAO) UpdateDatasetTX(tx *gorm.DB, id uint, authorWalletAddress string, updates map[string]interface{}) error {
+	return tx.Model(&model.Dataset{}).
+		Where("id = ? AND author_wallet_address = ?", id, authorWalletAddress).
+		Updates(updates).Error
+}
+
+// 下架数据集（软删除），authorWalletAddress 为空时不校验作者，返回是否有记录被下架
+func (d ChainIndexerDAO) DeactivateDatasetTX(tx *gorm.DB, id uint, authorWalletAddress string) (bool, error) {
+	db := tx.Where("id = ?", id)
+	if authorWalletAddress != "" {
+		db = db.Where("author_wallet_address = ?", authorWalletAddress)
+	}
+	res := db.Delete(&model.Dataset{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// 恢复被下架的数据集
+func (d ChainIndexerDAO) RestoreDatasetTX(tx *gorm.DB, id uint) error {
+	return tx.Unscoped().Model(&model.Dataset{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+// 按 ID 查询数据集 TX（恢复下架后同步搜索索引）
+func (d ChainIndexerDAO) GetDatasetByIDTX(tx *gorm.DB, id uint) (*model.Dataset, error) {
+	var dataset model.Dataset
+	err := tx.Where("id = ?", id).First(&dataset).Error
+	return &dataset, err
+}
+
+// 根据交易哈希查询交易记录，不存在时返回 nil
+func (d ChainIndexerDAO) GetTransactionByTxHashTX(tx *gorm.DB, txHash string) (*model.Transaction, error) {
+	var transaction model.Transaction
+	err := tx.Where("tx_hash = ?", txHash).First(&transaction).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// 查询买家对该数据集最早一条未确认的交易记录，不存在时返回 nil
+func (d ChainIndexerDAO) GetPendingTransactionTX(tx *gorm.DB, buyerWalletAddress string, datasetID uint) (*model.Transaction, error) {
+	var transaction model.Transaction
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("buyer_wallet_address = ? AND dataset_id = ?", buyerWalletAddress, datasetID).
+		Where("(status = ? OR status = '' OR status IS NULL)", "pending").
+		Order("id ASC").
+		First(&transaction).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// 使用链上数据完成交易记录 TX
+func (d ChainIndexerDAO) CompleteTransactionTX(tx *gorm.DB, id uint, updates map[string]interface{}) error {
+	updates["status"] = "completed"
+	return tx.Model(&model.Transaction{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// 创建交易记录 TX
+func (d ChainIndexerDAO) CreateTransactionTX(tx *gorm.DB, m *model.Transaction) error {
+	return tx.Create(m).Error
+}
+
+// 删除交易记录 TX
+func (d ChainIndexerDAO) DeleteTransactionTX(tx *gorm.DB, id uint) error {
+	return tx.Delete(&model.Transaction{}, id).Error
+}
+
+// 将打包在被回滚区块中的交易记录恢复为待确认，保留 tx_hash 以便重新同步时匹配
+func (d ChainIndexerDAO) ReopenTransactionTX(tx *gorm.DB, id uint, blockHash string) error {
+	return tx.Model(&model.Transaction{}).Where("id = ? AND block_hash = ?", id, blockHash).
+		Updates(map[string]interface{}{
+			"status":          "pending",
+			"block_hash":      "",
+			"block_number":    0,
+			"block_timestamp": 0,
+			"gas":             "",
+			"nonce":           0,
+		}).Error
+}
+
+// 根据钱包地址查询用户ID，不存在时返回 0
+func (d ChainIndexerDAO) GetUserIDByWalletTX(tx *gorm.DB, walletAddress string) (uint, error) {
+	var ids []uint
+	err := tx.Model(&model.User{}).Where("wallet_address = ?", walletAddress).Limit(1).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// 查询用户角色 TX
+func (d ChainIndexerDAO) GetUserRoleTX(tx *gorm.DB, userID uint) (string, error) {
+	var roles []string
+	err := tx.Model(&model.User{}).Where("id = ?", userID).Limit(1).Pluck("role", &roles).Error
+	if err != nil || len(roles) == 0 {
+		return "", err
+	}
+	return roles[0], nil
+}
+
+// 更新用户角色 TX
+func (d ChainIndexerDAO) UpdateUserRoleTX(tx *gorm.DB, userID uint, role string) error {
+	return tx.Model(&model.User{}).Where("id = ?", userID).Update("role", role).Error
+}
+
+// 查询作者匹配的数据集（加锁），不存在或作者不匹配时返回 nil
+func (d ChainIndexerDAO) GetDatasetTX(tx *gorm.DB, id uint, authorWalletAddress string) (*model.Dataset, error) {
+	var dataset model.Dataset
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND author_wallet_address = ?", id, authorWalletAddress).
+		First(&dataset).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dataset, nil
+}
+
+// 写入数据集修改记录 TX
+func (d ChainIndexerDAO) CreateDatasetEditTX(tx *gorm.DB, e *model.DatasetEdit) error {
+	return tx.Create(e).Error
+}
+
+// 按链上价格确认待上链的修改：价格一致的标记为已确认，不一致的标记为已取代
+func (d ChainIndexerDAO) ResolvePendingEditsTX(tx *gorm.DB, datasetID uint, price float64, txHash string) error {
+	return tx.Model(&model.DatasetEdit{}).
+		Where("dataset_id = ? AND status = ?", datasetID, model.DatasetEditPendingChain).
+		Updates(map[string]interface{}{
+			"status":  gorm.Expr("IF(pending_price = ?, ?, ?)", price, model.DatasetEditConfirmed, model.DatasetEditSuperseded),
+			"tx_hash": txHash,
+		}).Error
+}
+
+// 撤销链上事件对修改记录的影响：删除该交易写入的链上修改记录，被其确认或取代的卖家修改恢复为待上链
+func (d ChainIndexerDAO) RevertDatasetEditsTX(tx *gorm.DB, datasetID uint, txHash string) error {
+	if err := tx.Where("dataset_id = ? AND source = ? AND tx_hash = ?", datasetID, model.DatasetEditSourceChain, txHash).
+		Delete(&model.DatasetEdit{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&model.DatasetEdit{}).
+		Where("dataset_id = ? AND source = ? AND tx_hash = ? AND pending_price IS NOT NULL", datasetID, model.DatasetEditSourceUser, txHash).
+		Updates(map[string]interface{}{
+			"status":  model.DatasetEditPendingChain,
+			"tx_hash": "",
+		}).Error
+}
@@ -2,9 +2,9 @@ package mysql
 
 import (
 	"backend/internal/model"
-	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type DatasetDAO struct {
@@ -33,6 +33,7 @@ func (d DatasetDAO) UploadDataset(tx *gorm.DB, m *model.UploadDatasetRequest, bu
 		IsFree:              m.IsFree,
 		ObjectName:          m.ObjectName,
 		FileSize:            m.FileSize,
+		Format:              m.Format,
 		AuthorWalletAddress: m.AuthorWalletAddress,
 		License:             m.License,
 		BucketName:          bucket,
@@ -49,37 +50,97 @@ func (d DatasetDAO) DeleteDataset(tx *gorm.DB, id uint) error {
 	return tx.Delete(&model.Dataset{}, id).Error
 }
 
-// 查询数据集列表，支持分页、免费/付费、分类、搜索、文件大小等筛选
-func (d DatasetDAO) ListDatasets(page, limit int, filters map[string]interface{}) ([]model.DatasetListResponse, int64, int, error) {
-	db := d.db.Model(&model.Dataset{})
+// 全文检索表达式，对应 datasets 表上的 FULLTEXT(title, description, tags) WITH PARSER ngram 索引
+const DatasetFulltextMatch = "MATCH(datasets.title, datasets.description, datasets.tags) AGAINST (? IN NATURAL LANGUAGE MODE)"
 
-	if v, ok := filters["is_free"]; ok {
-		db = db.Where("is_free = ?", v)
+// 按筛选条件过滤数据集，字段以 datasets. 限定，可用于连表查询
+func DatasetQueryScope(q *model.DatasetQuery) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if q.Text != "" {
+			tx = tx.Where(DatasetFulltextMatch, q.Text)
+		}
+		if q.Category != "" {
+			tx = tx.Where("datasets.category = ?", q.Category)
+		}
+		if q.License != "" {
+			tx = tx.Where("datasets.license = ?", q.License)
+		}
+		if q.AuthorWallet != "" {
+			tx = tx.Where("datasets.author_wallet_address = ?", q.AuthorWallet)
+		}
+		if q.IsFree != nil {
+			tx = tx.Where("datasets.is_free = ?", *q.IsFree)
+		}
+		if q.MinSize != nil {
+			tx = tx.Where("datasets.file_size >= ?", *q.MinSize)
+		}
+		if q.MaxSize != nil {
+			tx = tx.Where("datasets.file_size <= ?", *q.MaxSize)
+		}
+		if q.MinPrice != nil {
+			tx = tx.Where("datasets.price >= ?", *q.MinPrice)
+		}
+		if q.MaxPrice != nil {
+			tx = tx.Where("datasets.price <= ?", *q.MaxPrice)
+		}
+		if q.CreatedFrom != nil {
+			tx = tx.Where("datasets.created_at >= ?", *q.CreatedFrom)
+		}
+		if q.CreatedTo != nil {
+			tx = tx.Where("datasets.created_at < ?", *q.CreatedTo)
+		}
+		if q.Status != "" {
+			tx = tx.Where("datasets.status = ?", q.Status)
+		}
+		return tx.Scopes(DatasetTagScope(q.Tags, q.MatchAllTags))
 	}
-	if v, ok := filters["category"]; ok {
-		db = db.Where("category = ?", v)
+}
+
+// 查询数据集列表，按筛选条件过滤后排序分页
+func (d DatasetDAO) ListDatasets(pq *model.PageQuery, q *model.DatasetQuery) ([]model.DatasetListResponse, model.PageInfo, error) {
+	db := d.db.Model(&model.Dataset{}).Scopes(DatasetQueryScope(q))
+	return paginateDatasets(db, pq, datasetListKey)
+}
+
+func datasetListKey(ds *model.DatasetListResponse) (datasetKeyRow, error) {
+	createdAt, err := parseCreatedAt(ds.CreatedAt)
+	return datasetKeyRow{ID: ds.ID, CreatedAt: createdAt, DownloadCount: int64(ds.DownloadCount), Price: ds.Price, FileSize: ds.FileSize}, err
+}
+
+// 按 ID 批量查询数据集列表项，按 ids 的顺序返回（用于搜索结果），已删除的数据集跳过
+func (d DatasetDAO) GetDatasetsByIDs(ids []uint) ([]model.DatasetListResponse, error) {
+	if len(ids) == 0 {
+		return nil, nil
 	}
-	if v, ok := filters["search"]; ok {
-		like := "%" + v.(string) + "%"
-		db = db.Where("title LIKE ? OR description LIKE ? OR tags LIKE ?", like, like, like)
+	var datasets []model.DatasetListResponse
+	if err := d.db.Model(&model.Dataset{}).Where("id IN ?", ids).Find(&datasets).Error; err != nil {
+		return nil, err
 	}
-	if v, ok := filters["fileSizeRange"]; ok {
-		size := strings.Split(v.(string), "-")
-		db = db.Where("file_size >= ? AND file_size <= ?", size[0], size[1])
+	byID := make(map[uint]model.DatasetListResponse, len(datasets))
+	for _, ds := range datasets {
+		byID[ds.ID] = ds
 	}
-	if v, ok := filters["priceRange"]; ok {
-		price := strings.Split(v.(string), "-")
-		db = db.Where("price >= ? AND price <= ?", price[0], price[1])
+	ordered := make([]model.DatasetListResponse, 0, len(datasets))
+	for _, id := range ids {
+		if ds, ok := byID[id]; ok {
+			ordered = append(ordered, ds)
+		}
 	}
+	return ordered, nil
+}
 
-	var total int64
-	db.Count(&total)
-	offset := (page - 1) * limit
+// 查询全部未删除的数据集（重建搜索索引）
+func (d DatasetDAO) GetAllDatasets() ([]model.Dataset, error) {
+	var datasets []model.Dataset
+	err := d.db.Find(&datasets).Error
+	return datasets, err
+}
 
-	var datasets []model.DatasetListResponse
-	err := db.Order("created_at DESC").Limit(limit).Offset(offset).Find(&datasets).Error
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-	return datasets, total, totalPages, err
+// 查询完整的数据集记录
+func (d DatasetDAO) GetDataset(id uint) (*model.Dataset, error) {
+	var ds model.Dataset
+	err := d.db.First(&ds, id).Error
+	return &ds, err
 }
 
 // 获取数据集详情
@@ -90,16 +151,9 @@ func (d DatasetDAO) GetDatasetDetail(id uint) (model.DatasetListResponse, error)
 }
 
 // 作者的付费数据集
-func (d DatasetDAO) GetAuthorPaidDatasets(authorWalletAddress string, page, limit int) ([]model.DatasetListResponse, int64, int, error) {
-	db := d.db.Model(&model.Dataset{}).Where("author_wallet_address = ? AND is_free = ?", authorWalletAddress, false)
-	var total int64
-	db.Count(&total)
-	offset := (page - 1) * limit
-
-	var datasets []model.DatasetListResponse
-	err := db.Order("created_at DESC").Limit(limit).Offset(offset).Find(&datasets).Error
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-	return datasets, total, totalPages, err
+func (d DatasetDAO) GetAuthorPaidDatasets(authorWalletAddress string, q *model.PageQuery) ([]model.DatasetListResponse, model.PageInfo, error) {
+	db := d.db.Model(&model.Dataset{}).Where("author_wallet_address = ? AND is_free = ? AND status = ?", authorWalletAddress, false, model.DatasetStatusReady)
+	return paginateDatasets(db, q, datasetListKey)
 }
 
 // 通过数据集ID获取对象名
@@ -119,24 +173,17 @@ func (d DatasetDAO) GetObjectNameByDatasetIDTX(tx *gorm.DB, id uint) (string, er
 	return objectName, nil
 }
 
-// 添加下载记录
-func (d DatasetDAO) AddDownloadRecord(tx *gorm.DB, userID uint, DatasetID uint) error {
+// 添加下载记录，返回是否为该用户首次下载该数据集
+func (d DatasetDAO) AddDownloadRecord(tx *gorm.DB, userID uint, DatasetID uint) (bool, error) {
 	// 查询用户钱包地址
 	var userWalletAddress string
 	if err := tx.Model(&model.User{}).Where("id = ?", userID).Pluck("wallet_address", &userWalletAddress).Error; err != nil {
-		return err
+		return false, err
 	}
 	// 查询dataset是否免费
 	var isFree uint
 	if err := tx.Model(&model.Dataset{}).Where("id = ?", DatasetID).Pluck("is_free", &isFree).Error; err != nil {
-		return err
-	}
-	var count int64
-	if err := tx.Model(&model.DownloadRecord{}).Where("user_wallet_address = ? AND dataset_id = ?", userWalletAddress, DatasetID).Count(&count).Error; err != nil {
-		return err
-	}
-	if count > 0 {
-		return tx.Model(&model.DownloadRecord{}).Where("user_wallet_address = ? AND dataset_id = ?", userWalletAddress, DatasetID).Update("download_count", gorm.Expr("download_count + ?", 1)).Error
+		return false, err
 	}
 	record := model.DownloadRecord{
 		UserWalletAddress: userWalletAddress,
@@ -144,7 +191,15 @@ func (d DatasetDAO) AddDownloadRecord(tx *gorm.DB, userID uint, DatasetID uint)
 		Type:              isFree,
 		DownloadCount:     1,
 	}
-	return tx.Create(&record).Error
+	// 依赖 idx_wallet_dataset 唯一索引：INSERT ... ON DUPLICATE KEY UPDATE，并发的首次下载不会重复插入
+	// MySQL 插入新行时 RowsAffected 为 1，更新已有行时为 2
+	result := tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{"download_count": gorm.Expr("download_count + ?", 1)}),
+	}).Create(&record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
 }
 
 // 更新下载次数
@@ -152,32 +207,89 @@ func (d DatasetDAO) UpdateDownloadCount(tx *gorm.DB, id uint) error {
 	return tx.Model(&model.Dataset{}).Where("id = ?", id).UpdateColumn("download_count", gorm.Expr("download_count + ?", 1)).Error
 }
 
-// 通过数据集ID获取对象名和文件大小
-func (d DatasetDAO) GetObjectNameAndFileSizeByDatasetID(id uint) (string, int64, error) {
+// 通过数据集ID获取对象名、文件大小和格式
+func (d DatasetDAO) GetDatasetFileByID(id uint) (*model.Dataset, error) {
 	var ds model.Dataset
-	err := d.db.Select("object_name, file_size").Where("id = ?", id).First(&ds, id).Error
-	return ds.ObjectName, ds.FileSize, err
+	err := d.db.Select("object_name, file_size, format").Where("id = ?", id).First(&ds, id).Error
+	return &ds, err
 }
 
-// 获取交易时间戳
-func (d DatasetDAO) GetTransactionTimestamp(userId, datasetId uint) (int64, error) {
-	// 获取用户钱包地址
+// 获取用户购买该数据集的交易记录 ID（取最早一笔已完成交易，保证同一买家的指纹固定）
+func (d DatasetDAO) GetCompletedTransactionID(userId, datasetId uint) (uint, error) {
 	var walletAddress string
-	err := d.db.Model(&model.User{}).Where("id = ?", userId).Pluck("wallet_address", &walletAddress).Error
-	var timestamp int64
-	err = d.db.Model(&model.Transaction{}).Where("buyer_wallet_address = ? AND dataset_id = ?", walletAddress, datasetId).Pluck("block_timestamp", &timestamp).Error
-	return timestamp, err
+	if err := d.db.Model(&model.User{}).Where("id = ?", userId).Pluck("wallet_address", &walletAddress).Error; err != nil {
+		return 0, err
+	}
+	var tx model.Transaction
+	err := d.db.Select("id").
+		Where("buyer_wallet_address = ? AND dataset_id = ? AND status = ?", walletAddress, datasetId, "completed").
+		Order("id ASC").First(&tx).Error
+	return tx.ID, err
 }
 
 // 获取作者的数据集
-func (d DatasetDAO) GetAuthorDatasets(address string, page int, limit int) ([]model.DatasetListResponse, int64, int, error) {
+func (d DatasetDAO) GetAuthorDatasets(address string, q *model.PageQuery) ([]model.DatasetListResponse, model.PageInfo, error) {
 	db := d.db.Model(&model.Dataset{}).Where("author_wallet_address = ?", address)
-	var total int64
-	db.Count(&total)
-	offset := (page - 1) * limit
+	return paginateDatasets(db, q, datasetListKey)
+}
 
-	var datasets []model.DatasetListResponse
-	err := db.Order("created_at DESC").Limit(limit).Offset(offset).Find(&datasets).Error
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-	return datasets, total, totalPages, err
+// 查询用户钱包地址和角色
+func (d DatasetDAO) GetUserWalletAndRole(userID uint) (string, string, error) {
+	var user model.User
+	err := d.db.Select("wallet_address, role").Where("id = ?", userID).First(&user).Error
+	return user.WalletAddress, user.Role, err
+}
+
+// 获取数据集作者钱包地址和对象名-tx
+func (d DatasetDAO) GetDatasetOwnerTX(tx *gorm.DB, id uint) (string, string, error) {
+	var ds model.Dataset
+	err := tx.Select("author_wallet_address, object_name").Where("id = ?", id).First(&ds).Error
+	return ds.AuthorWalletAddress, ds.ObjectName, err
+}
+
+// 查询数据集是否免费
+func (d DatasetDAO) GetDatasetIsFree(id uint) (bool, error) {
+	var ds model.Dataset
+	err := d.db.Select("is_free").Where("id = ?", id).First(&ds).Error
+	return ds.IsFree, err
+}
+
+// 检查钱包是否有已完成的购买交易
+func (d DatasetDAO) HasCompletedTransaction(walletAddress string, datasetId uint) (bool, error) {
+	var count int64
+	err := d.db.Model(&model.Transaction{}).
+		Where("buyer_wallet_address = ? AND dataset_id = ? AND status = ?", walletAddress, datasetId, "completed").
+		Count(&count).Error
+	return count > 0, err
+}
+
+// 检查对象名是否已被数据集使用（包含已删除的数据集）
+func (d DatasetDAO) CheckObjectNameUsed(tx *gorm.DB, objectName string) bool {
+	var count int64
+	tx.Model(&model.Dataset{}).Unscoped().Where("object_name = ?", objectName).Count(&count)
+	if count > 0 {
+		return true
+	}
+	// 历史版本的对象同样不可复用
+	tx.Model(&model.DatasetVersion{}).Where("object_name = ?", objectName).Count(&count)
+	return count > 0
+}
+
+// 批量查询已被数据集（含已删除）或版本使用的对象名
+func (d DatasetDAO) GetUsedObjectNames(objectNames []string) (map[string]bool, error) {
+	used := make(map[string]bool)
+	if len(objectNames) == 0 {
+		return used, nil
+	}
+	var names, versionNames []string
+	if err := d.db.Model(&model.Dataset{}).Unscoped().Where("object_name IN ?", objectNames).Pluck("object_name", &names).Error; err != nil {
+		return nil, err
+	}
+	if err := d.db.Model(&model.DatasetVersion{}).Where("object_name IN ?", objectNames).Pluck("object_name", &versionNames).Error; err != nil {
+		return nil, err
+	}
+	for _, name := range append(names, versionNames...) {
+		used[name] = true
+	}
+	return used, nil
 }
@@ -0,0 +1,43 @@
+package mysql
+
+import (
+	"backend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 锁定 SHA-256 相同的全部版本 TX，同一文件的校验结论依次写入，后写入的一方能查到先通过的版本
+// 两个版本都未声明 SHA-256 时只能锁到索引间隙，并发写入可能死锁，由数据库回滚其中一方，任务租约过期后重新校验
+func (d DatasetDAO) LockVersionsByChecksumTX(tx *gorm.DB, checksum string) error {
+	var ids []uint
+	return tx.Model(&model.DatasetVersion{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("checksum = ?", checksum).Pluck("id", &ids).Error
+}
+
+// 查询其他作者最早上传的、SHA-256 相同的已通过校验版本（未删除的数据集），没有时返回 gorm.ErrRecordNotFound
+func (d DatasetDAO) FindOriginalVersionTX(tx *gorm.DB, checksum string, datasetID uint) (*model.DatasetVersion, error) {
+	var v model.DatasetVersion
+	err := tx.Select("dataset_versions.*").
+		Joins("JOIN datasets ON datasets.id = dataset_versions.dataset_id AND datasets.deleted_at IS NULL").
+		Where("dataset_versions.checksum = ? AND dataset_versions.status = ?", checksum, model.DatasetStatusReady).
+		Where("dataset_versions.dataset_id <> ?", datasetID).
+		Where("LOWER(datasets.author_wallet_address) <> (SELECT LOWER(author_wallet_address) FROM datasets WHERE id = ?)", datasetID).
+		Order("dataset_versions.created_at, dataset_versions.id").
+		First(&v).Error
+	return &v, err
+}
+
+// 写入重复文件记录 TX，同一版本已有记录时忽略
+func (d DatasetDAO) CreateDuplicateTX(tx *gorm.DB, m *model.DatasetDuplicate) error {
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(m).Error
+}
+
+// 数据集是否已被确认为重复上传 TX（确认后不再因新版本通过校验而重新上架）
+func (d DatasetDAO) HasConfirmedDuplicateTX(tx *gorm.DB, datasetID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&model.DatasetDuplicate{}).
+		Where("dataset_id = ? AND status = ?", datasetID, model.DuplicateConfirmed).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,24 @@
+package mysql
+
+import (
+	"backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 更新数据集元数据字段 TX
+func (d DatasetDAO) UpdateDatasetFieldsTX(tx *gorm.DB, id uint, updates map[string]interface{}) error {
+	return tx.Model(&model.Dataset{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// 写入数据集修改记录 TX
+func (d DatasetDAO) CreateEditTX(tx *gorm.DB, e *model.DatasetEdit) error {
+	return tx.Create(e).Error
+}
+
+// 将数据集尚未确认的价格修改标记为已取代（同一数据集只保留最新一次待确认价格）
+func (d DatasetDAO) SupersedePendingEditsTX(tx *gorm.DB, datasetID uint) error {
+	return tx.Model(&model.DatasetEdit{}).
+		Where("dataset_id = ? AND status = ?", datasetID, model.DatasetEditPendingChain).
+		Update("status", model.DatasetEditSuperseded).Error
+}
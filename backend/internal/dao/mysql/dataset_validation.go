@@ -0,0 +1,84 @@
+package mysql
+
+import (
+	"backend/internal/model"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DatasetValidationDAO struct {
+	db *gorm.DB
+}
+
+func NewDatasetValidationDAO(db *gorm.DB) *DatasetValidationDAO {
+	return &DatasetValidationDAO{db: db}
+}
+
+// getter
+func (d DatasetValidationDAO) DB() *gorm.DB {
+	return d.db
+}
+
+// 创建校验任务 TX（与数据集/版本记录在同一事务中创建）
+func (d DatasetValidationDAO) CreateJobTX(tx *gorm.DB, m *model.DatasetValidation) error {
+	m.Status = model.ValidationJobQueued
+	return tx.Create(m).Error
+}
+
+// 查询版本最近一次校验任务，不存在时返回 nil
+func (d DatasetValidationDAO) GetLatestJob(versionID uint) (*model.DatasetValidation, error) {
+	var job model.DatasetValidation
+	err := d.db.Where("version_id = ?", versionID).Order("id DESC").First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+var validationJobStatus = leaseJobStatus{Queued: model.ValidationJobQueued, Running: model.ValidationJobRunning, Failed: model.ValidationJobFailed}
+
+// 认领待执行任务：排队中的任务，以及租约已过期的执行中任务
+func (d DatasetValidationDAO) ClaimJobs(limit int, lease time.Duration) ([]*model.DatasetValidation, error) {
+	return claimLeaseJobs[model.DatasetValidation](d.db, validationJobStatus, limit, lease)
+}
+
+// 更新进度并续约；任务已不在执行中时返回 false
+func (d DatasetValidationDAO) UpdateProgress(id uint, progress uint, lease time.Duration) (bool, error) {
+	return updateLeaseJobProgress[model.DatasetValidation](d.db, validationJobStatus, id, progress, lease)
+}
+
+// 续约执行中的任务（租约心跳），任务已不在执行中时返回 false
+func (d DatasetValidationDAO) RenewLease(id uint, lease time.Duration) (bool, error) {
+	return renewLeaseJob[model.DatasetValidation](d.db, validationJobStatus, id, lease)
+}
+
+// 标记任务完成并写入校验结论和报告 TX；任务已不在执行中时返回 false
+func (d DatasetValidationDAO) MarkDoneTX(tx *gorm.DB, id uint, result string, report json.RawMessage) (bool, error) {
+	res := tx.Model(&model.DatasetValidation{}).
+		Where("id = ? AND status = ?", id, model.ValidationJobRunning).
+		Updates(map[string]interface{}{
+			"status":      model.ValidationJobDone,
+			"progress":    100,
+			"result":      result,
+			"report":      report,
+			"lease_until": nil,
+			"finished_at": time.Now(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// 标记任务失败 TX（仅处理排队中或执行中的任务），result 为空表示不给出校验结论
+func (d DatasetValidationDAO) MarkFailedTX(tx *gorm.DB, id uint, result, reason string) (bool, error) {
+	return markLeaseJobFailed[model.DatasetValidation](tx, validationJobStatus, id, reason, map[string]interface{}{"result": result})
+}
+
+// 将执行中的任务重新排队（进程退出前释放）
+func (d DatasetValidationDAO) RequeueJob(id uint) error {
+	return requeueLeaseJob[model.DatasetValidation](d.db, validationJobStatus, id)
+}
@@ -0,0 +1,116 @@
+package mysql
+
+import (
+	"backend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 锁定数据集行（SELECT ... FOR UPDATE），串行化同一数据集的版本发布和元数据修改
+func (d DatasetDAO) LockDatasetTX(tx *gorm.DB, id uint) (*model.Dataset, error) {
+	var ds model.Dataset
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&ds).Error
+	return &ds, err
+}
+
+// 数据集当前最大版本号，没有版本时为 0
+func (d DatasetDAO) GetLatestVersionNumberTX(tx *gorm.DB, datasetID uint) (int, error) {
+	var version int
+	err := tx.Model(&model.DatasetVersion{}).Where("dataset_id = ?", datasetID).
+		Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// 写入版本记录
+func (d DatasetDAO) CreateVersionTX(tx *gorm.DB, v *model.DatasetVersion) error {
+	return tx.Create(v).Error
+}
+
+// 将数据集的文件信息更新为指定版本
+func (d DatasetDAO) UpdateDatasetFileTX(tx *gorm.DB, v *model.DatasetVersion) error {
+	return tx.Model(&model.Dataset{}).Where("id = ?", v.DatasetID).Updates(map[string]interface{}{
+		"object_name": v.ObjectName,
+		"file_size":   v.FileSize,
+		"format":      v.Format,
+		"checksum":    v.Checksum,
+	}).Error
+}
+
+// 数据集的版本，按版本号倒序；readyOnly 时只返回已通过校验的版本
+func (d DatasetDAO) ListVersions(datasetID uint, readyOnly bool) ([]model.DatasetVersion, error) {
+	var versions []model.DatasetVersion
+	db := d.db.Where("dataset_id = ?", datasetID)
+	if readyOnly {
+		db = db.Where("status = ?", model.DatasetStatusReady)
+	}
+	err := db.Order("version DESC").Find(&versions).Error
+	return versions, err
+}
+
+// 查询未删除数据集的指定版本，version 为 0 时返回最新的已通过校验版本
+func (d DatasetDAO) GetVersion(datasetID uint, version int) (*model.DatasetVersion, error) {
+	var v model.DatasetVersion
+	db := d.db.Select("dataset_versions.*").
+		Joins("JOIN datasets ON datasets.id = dataset_versions.dataset_id AND datasets.deleted_at IS NULL").
+		Where("dataset_versions.dataset_id = ?", datasetID)
+	if version > 0 {
+		db = db.Where("dataset_versions.version = ?", version)
+	} else {
+		db = db.Where("dataset_versions.status = ?", model.DatasetStatusReady)
+	}
+	err := db.Order("dataset_versions.version DESC").First(&v).Error
+	return &v, err
+}
+
+// 按 ID 查询版本 TX
+func (d DatasetDAO) GetVersionByIDTX(tx *gorm.DB, id uint) (*model.DatasetVersion, error) {
+	var v model.DatasetVersion
+	err := tx.Where("id = ?", id).First(&v).Error
+	return &v, err
+}
+
+// 数据集最大的已通过校验版本号，没有时为 0
+func (d DatasetDAO) GetLatestReadyVersionNumberTX(tx *gorm.DB, datasetID uint) (int, error) {
+	var version int
+	err := tx.Model(&model.DatasetVersion{}).Where("dataset_id = ? AND status = ?", datasetID, model.DatasetStatusReady).
+		Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// 写入版本校验结论 TX，integrity 不为空时记录校验时计算的 SHA-256、MD5 和对象 ETag
+// 声明的 SHA-256 与文件不一致的版本不会通过校验，因此直接以计算值为准
+func (d DatasetDAO) UpdateVersionStatusTX(tx *gorm.DB, id uint, status string, integrity *model.FileIntegrity) error {
+	updates := map[string]interface{}{"status": status}
+	if integrity != nil {
+		updates["checksum"] = integrity.Checksum
+		updates["md5"] = integrity.MD5
+		updates["etag"] = integrity.ETag
+	}
+	return tx.Model(&model.DatasetVersion{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// 更新数据集状态 TX
+func (d DatasetDAO) UpdateDatasetStatusTX(tx *gorm.DB, id uint, status string) error {
+	return tx.Model(&model.Dataset{}).Where("id = ?", id).Update("status", status).Error
+}
+
+// 数据集全部版本的对象名（删除数据集时清理文件和预览）
+func (d DatasetDAO) GetVersionObjectNamesTX(tx *gorm.DB, datasetID uint) ([]string, error) {
+	return versionObjectNamesTX(tx, datasetID)
+}
+
+func versionObjectNamesTX(tx *gorm.DB, datasetID uint) ([]string, error) {
+	var names []string
+	err := tx.Model(&model.DatasetVersion{}).Where("dataset_id = ?", datasetID).Pluck("object_name", &names).Error
+	return names, err
+}
+
+// 为尚无版本记录的数据集（含已删除）补建初始版本，可重复执行，返回补建数量
+func (d DatasetDAO) BackfillInitialVersions() (int64, error) {
+	result := d.db.Exec(`INSERT INTO dataset_versions (dataset_id, version, object_name, file_size, format, changelog, created_at)
+		SELECT id, 1, object_name, file_size, format, ?, created_at FROM datasets
+		WHERE NOT EXISTS (SELECT 1 FROM dataset_versions WHERE dataset_versions.dataset_id = datasets.id)`,
+		model.InitialVersionChangelog)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,77 @@
+package mysql
+
+import (
+	"backend/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DetectJobDAO struct {
+	db *gorm.DB
+}
+
+func NewDetectJobDAO(db *gorm.DB) *DetectJobDAO {
+	return &DetectJobDAO{db: db}
+}
+
+// 创建检测任务
+func (d DetectJobDAO) CreateJob(m *model.DetectJob) error {
+	m.Status = model.DetectJobQueued
+	return d.db.Create(m).Error
+}
+
+// 查询检测任务，不存在时返回 nil
+func (d DetectJobDAO) GetJob(id uint) (*model.DetectJob, error) {
+	var job model.DetectJob
+	err := d.db.First(&job, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+var detectJobStatus = leaseJobStatus{Queued: model.DetectJobQueued, Running: model.DetectJobRunning, Failed: model.DetectJobFailed}
+
+// 认领待执行任务：排队中的任务，以及租约已过期的执行中任务
+func (d DetectJobDAO) ClaimJobs(limit int, lease time.Duration) ([]*model.DetectJob, error) {
+	return claimLeaseJobs[model.DetectJob](d.db, detectJobStatus, limit, lease)
+}
+
+// 更新进度并续约；任务已不在执行中（被取消）时返回 false
+func (d DetectJobDAO) UpdateProgress(id uint, progress uint, lease time.Duration) (bool, error) {
+	return updateLeaseJobProgress[model.DetectJob](d.db, detectJobStatus, id, progress, lease)
+}
+
+// 续约；任务已不在执行中（被取消）时返回 false
+func (d DetectJobDAO) RenewLease(id uint, lease time.Duration) (bool, error) {
+	return renewLeaseJob[model.DetectJob](d.db, detectJobStatus, id, lease)
+}
+
+// 标记任务完成并关联检测记录
+func (d DetectJobDAO) MarkDone(id uint, recordID int) error {
+	now := time.Now()
+	return d.db.Model(&model.DetectJob{}).
+		Where("id = ? AND status = ?", id, model.DetectJobRunning).
+		Updates(map[string]interface{}{
+			"status":      model.DetectJobDone,
+			"progress":    100,
+			"record_id":   recordID,
+			"lease_until": nil,
+			"finished_at": now,
+		}).Error
+}
+
+// 标记任务失败（仅处理排队中或执行中的任务）
+func (d DetectJobDAO) MarkFailed(id uint, reason string) (bool, error) {
+	return markLeaseJobFailed[model.DetectJob](d.db, detectJobStatus, id, reason, nil)
+}
+
+// 将执行中的任务重新排队（进程退出前释放）
+func (d DetectJobDAO) RequeueJob(id uint) error {
+	return requeueLeaseJob[model.DetectJob](d.db, detectJobStatus, id)
+}
@@ -0,0 +1,109 @@
+package mysql
+
+import (
+	"backend/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DownloadJobDAO struct {
+	db *gorm.DB
+}
+
+func NewDownloadJobDAO(db *gorm.DB) *DownloadJobDAO {
+	return &DownloadJobDAO{db: db}
+}
+
+// 创建下载任务 TX
+func (d DownloadJobDAO) CreateJobTX(tx *gorm.DB, m *model.DownloadJob) error {
+	m.Status = model.DownloadJobQueued
+	return tx.Create(m).Error
+}
+
+// 设置临时桶文件名 TX（依赖任务 ID，需在创建后设置）
+func (d DownloadJobDAO) SetTempObjectTX(tx *gorm.DB, id uint, tempObject string) error {
+	return tx.Model(&model.DownloadJob{}).Where("id = ?", id).Update("temp_object", tempObject).Error
+}
+
+// 查询进行中（排队或执行中）的任务，不存在时返回 nil
+func (d DownloadJobDAO) GetActiveJob(activeKey string) (*model.DownloadJob, error) {
+	var job model.DownloadJob
+	err := d.db.Where("active_key = ?", activeKey).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// 查询下载任务，不存在时返回 nil
+func (d DownloadJobDAO) GetJob(id uint) (*model.DownloadJob, error) {
+	var job model.DownloadJob
+	err := d.db.First(&job, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// 查询用户对该数据集最近一次下载任务，不存在时返回 nil
+func (d DownloadJobDAO) GetLatestJob(userID, datasetID uint) (*model.DownloadJob, error) {
+	var job model.DownloadJob
+	err := d.db.Where("user_id = ? AND dataset_id = ?", userID, datasetID).Order("id DESC").First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+var downloadJobStatus = leaseJobStatus{Queued: model.DownloadJobQueued, Running: model.DownloadJobRunning, Failed: model.DownloadJobFailed}
+
+// 认领待执行任务：排队中的任务，以及租约已过期的执行中任务（worker 重启或崩溃遗留）
+func (d DownloadJobDAO) ClaimJobs(limit int, lease time.Duration) ([]*model.DownloadJob, error) {
+	return claimLeaseJobs[model.DownloadJob](d.db, downloadJobStatus, limit, lease)
+}
+
+// 更新进度并续约；任务已不在执行中（被取消）时返回 false
+func (d DownloadJobDAO) UpdateProgress(id uint, progress uint, lease time.Duration) (bool, error) {
+	return updateLeaseJobProgress[model.DownloadJob](d.db, downloadJobStatus, id, progress, lease)
+}
+
+// 续约；任务已不在执行中（被取消）时返回 false
+func (d DownloadJobDAO) RenewLease(id uint, lease time.Duration) (bool, error) {
+	return renewLeaseJob[model.DownloadJob](d.db, downloadJobStatus, id, lease)
+}
+
+// 标记任务完成
+func (d DownloadJobDAO) MarkDone(id uint, checksum string) error {
+	now := time.Now()
+	return d.db.Model(&model.DownloadJob{}).
+		Where("id = ? AND status = ?", id, model.DownloadJobRunning).
+		Updates(map[string]interface{}{
+			"status":      model.DownloadJobDone,
+			"progress":    100,
+			"checksum":    checksum,
+			"active_key":  nil,
+			"lease_until": nil,
+			"finished_at": now,
+		}).Error
+}
+
+// 标记任务失败（仅处理排队中或执行中的任务），释放进行中任务的唯一键
+func (d DownloadJobDAO) MarkFailed(id uint, reason string) (bool, error) {
+	return markLeaseJobFailed[model.DownloadJob](d.db, downloadJobStatus, id, reason, map[string]interface{}{"active_key": nil})
+}
+
+// 将执行中的任务重新排队（进程退出前释放）
+func (d DownloadJobDAO) RequeueJob(id uint) error {
+	return requeueLeaseJob[model.DownloadJob](d.db, downloadJobStatus, id)
+}
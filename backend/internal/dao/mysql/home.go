@@ -47,7 +47,7 @@ func (d HomeDAO) GetDatasetsByIDs(ids []uint) ([]model.DatasetListResponse, erro
 func (d HomeDAO) GetTopByDownloads(limit int) ([]model.DatasetListResponse, error) {
     // 1. TopN 按下载量排序
     var datasets []model.DatasetListResponse
-    if err := d.db.Model(&model.Dataset{}).Order("download_count DESC").Limit(limit).Find(&datasets).Error; err != nil {
+    if err := d.db.Model(&model.Dataset{}).Where("status = ?", model.DatasetStatusReady).Order("download_count DESC").Limit(limit).Find(&datasets).Error; err != nil {
         return nil, err
     }
     return datasets, nil
@@ -56,7 +56,7 @@ func (d HomeDAO) GetTopByDownloads(limit int) ([]model.DatasetListResponse, erro
 func (d HomeDAO) GetLatestByCreatedAt(limit int) ([]model.DatasetListResponse, error) {
     // 1. TopN 按创建时间排序
     var datasets []model.DatasetListResponse
-    if err := d.db.Model(&model.Dataset{}).Order("created_at DESC").Limit(limit).Find(&datasets).Error; err != nil {
+    if err := d.db.Model(&model.Dataset{}).Where("status = ?", model.DatasetStatusReady).Order("created_at DESC").Limit(limit).Find(&datasets).Error; err != nil {
         return nil, err
     }
     return datasets, nil
@@ -0,0 +1,97 @@
+package mysql
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 基于租约的任务表（download_jobs、detect_jobs、dataset_validations）的状态值
+// 三张表都有 status、progress、attempts、error、lease_until、started_at、finished_at 列
+type leaseJobStatus struct {
+	Queued  string
+	Running string
+	Failed  string
+}
+
+// 认领待执行任务：排队中的任务，以及租约已过期的执行中任务（worker 重启或崩溃遗留）
+// SKIP LOCKED 跳过其他实例正在认领的任务，返回的任务已是执行中状态，attempts 已加 1
+func claimLeaseJobs[J any](db *gorm.DB, s leaseJobStatus, limit int, lease time.Duration) ([]*J, error) {
+	var jobs []*J
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var ids []uint
+		err := tx.Model(new(J)).Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND (lease_until IS NULL OR lease_until < ?))", s.Queued, s.Running, now).
+			Order("id ASC").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		err = tx.Model(new(J)).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":      s.Running,
+			"progress":    0,
+			"attempts":    gorm.Expr("attempts + 1"),
+			"error":       "",
+			"lease_until": now.Add(lease),
+			"started_at":  now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Order("id ASC").Find(&jobs).Error
+	})
+	return jobs, err
+}
+
+// 更新进度并续约；任务已不在执行中（被取消）时返回 false
+func updateLeaseJobProgress[J any](db *gorm.DB, s leaseJobStatus, id uint, progress uint, lease time.Duration) (bool, error) {
+	result := db.Model(new(J)).
+		Where("id = ? AND status = ?", id, s.Running).
+		Updates(map[string]interface{}{
+			"progress":    progress,
+			"lease_until": time.Now().Add(lease),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// 续约（租约心跳）；任务已不在执行中（被取消）时返回 false
+func renewLeaseJob[J any](db *gorm.DB, s leaseJobStatus, id uint, lease time.Duration) (bool, error) {
+	result := db.Model(new(J)).
+		Where("id = ? AND status = ?", id, s.Running).
+		Update("lease_until", time.Now().Add(lease))
+	return result.RowsAffected > 0, result.Error
+}
+
+// 标记任务失败（仅处理排队中或执行中的任务），extra 为各表额外更新的列
+func markLeaseJobFailed[J any](db *gorm.DB, s leaseJobStatus, id uint, reason string, extra map[string]interface{}) (bool, error) {
+	if len(reason) > 500 {
+		reason = reason[:500]
+	}
+	updates := map[string]interface{}{
+		"status":      s.Failed,
+		"error":       reason,
+		"lease_until": nil,
+		"finished_at": time.Now(),
+	}
+	for k, v := range extra {
+		updates[k] = v
+	}
+	result := db.Model(new(J)).
+		Where("id = ? AND status IN ?", id, []string{s.Queued, s.Running}).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// 将执行中的任务重新排队（进程退出前释放）
+func requeueLeaseJob[J any](db *gorm.DB, s leaseJobStatus, id uint) error {
+	return db.Model(new(J)).
+		Where("id = ? AND status = ?", id, s.Running).
+		Updates(map[string]interface{}{
+			"status":      s.Queued,
+			"progress":    0,
+			"lease_until": nil,
+		}).Error
+}
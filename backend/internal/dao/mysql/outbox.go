@@ -2,8 +2,12 @@ package mysql
 
 import (
 	"backend/internal/model"
+	"encoding/json"
 	"fmt"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OutboxDAO struct {
@@ -14,16 +18,85 @@ func NewOutboxDAO(db *gorm.DB) *OutboxDAO {
 	return &OutboxDAO{db: db}
 }
 
-// 插入任务
+// 插入任务（任务需通过 model.NewOutbox 创建）
 func (d OutboxDAO) InsertTask(tx *gorm.DB, m *model.Outbox) error {
+	if m.EventType == "" || !json.Valid([]byte(m.Payload)) {
+		return fmt.Errorf("无效的异步任务: %s %s", m.EventType, m.Payload)
+	}
 	return tx.Create(m).Error
 }
 
-// 获取待处理任务
-func (d OutboxDAO) GetPendingTasks() ([]*model.Outbox, error) {
-	var task []*model.Outbox
-	err := d.db.Where("status = ?", "pending").Find(&task).Error
-	return task, err
+// 认领到期的待处理任务：行锁 + SKIP LOCKED 保证多实例不会拿到同一批任务，
+// 认领后将 next_run_at 推迟 lease，执行中的任务在租约到期前不会被再次认领
+func (d OutboxDAO) ClaimDueTasks(limit int, lease time.Duration) ([]*model.Outbox, error) {
+	var tasks []*model.Outbox
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND (next_run_at IS NULL OR next_run_at <= ?)", "pending", now).
+			Order("id ASC").
+			Limit(limit).
+			Find(&tasks).Error
+		if err != nil || len(tasks) == 0 {
+			return err
+		}
+		ids := make([]uint, 0, len(tasks))
+		for _, t := range tasks {
+			ids = append(ids, t.ID)
+		}
+		return tx.Model(&model.Outbox{}).Where("id IN ?", ids).Update("next_run_at", now.Add(lease)).Error
+	})
+	return tasks, err
+}
+
+// 记录任务执行失败；failed 为 true 时任务进入 failed 状态不再重试
+func (d OutboxDAO) MarkTaskAttemptFailed(id uint, attempts int, nextRunAt time.Time, lastError string, failed bool) error {
+	status := "pending"
+	if failed {
+		status = "failed"
+	}
+	if len(lastError) > 500 {
+		lastError = lastError[:500]
+	}
+	return d.db.Model(&model.Outbox{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":      status,
+		"attempts":    attempts,
+		"next_run_at": nextRunAt,
+		"last_error":  lastError,
+	}).Error
+}
+
+// 查询任务列表，status 为空时查询全部
+func (d OutboxDAO) ListTasks(status string, page, limit int) ([]model.Outbox, int64, int, error) {
+	db := d.db.Model(&model.Outbox{})
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	var total int64
+	db.Count(&total)
+	offset := (page - 1) * limit
+
+	var tasks []model.Outbox
+	err := db.Order("id DESC").Limit(limit).Offset(offset).Find(&tasks).Error
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	return tasks, total, totalPages, err
+}
+
+// 重试失败任务：重置为待处理并清空执行次数
+func (d OutboxDAO) RetryFailedTask(id uint) (bool, error) {
+	result := d.db.Model(&model.Outbox{}).Where("id = ? AND status = ?", id, "failed").Updates(map[string]interface{}{
+		"status":      "pending",
+		"attempts":    0,
+		"next_run_at": gorm.Expr("NULL"),
+	})
+	return result.RowsAffected > 0, result.Error
+}
+
+// 丢弃失败任务
+func (d OutboxDAO) DiscardFailedTask(id uint) (bool, error) {
+	result := d.db.Where("id = ? AND status = ?", id, "failed").Delete(&model.Outbox{})
+	return result.RowsAffected > 0, result.Error
 }
 
 // 更新任务状态
@@ -36,8 +109,11 @@ func (d OutboxDAO) MarkTasksDoneTX(tx *gorm.DB, u uint) error {
 	return tx.Model(&model.Outbox{}).Where("id = ?", u).Update("status", "done").Error
 }
 
-// 取消删除数据集文件任务
+// 取消删除数据集文件任务（仅取消未执行的任务）
 func (d OutboxDAO) CancelDeleteMinioObjectTask(tx *gorm.DB, objectName string) error {
-	payload := fmt.Sprintf(`{"objectName": "%s"}`, objectName)
-	return tx.Where("event_type = ? AND payload = ?", "delete_minio_object", payload).Delete(&model.Outbox{}).Error
+	// 兼容 task_key 字段加入前按 fmt.Sprintf 写入的旧任务
+	legacyPayload := fmt.Sprintf(`{"objectName": "%s"}`, objectName)
+	return tx.Where("event_type = ? AND status = ?", model.OutboxDeleteMinioObject, "pending").
+		Where("task_key = ? OR ((task_key = '' OR task_key IS NULL) AND payload = ?)", objectName, legacyPayload).
+		Delete(&model.Outbox{}).Error
 }
@@ -0,0 +1,151 @@
+package mysql
+
+import (
+	"backend/internal/model"
+	"backend/internal/util"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 数据集排序键：按 (字段, id) 同向排序，id 保证相同取值时顺序稳定
+// 游标中的取值以字符串保存，value/parse 负责与 SQL 参数互转
+type datasetSortKey struct {
+	column string
+	desc   bool
+	value  func(row *datasetKeyRow) string
+	parse  func(s string) (interface{}, error)
+}
+
+// 生成游标所需的排序字段，由列表项本身提供，不需要额外查询
+type datasetKeyRow struct {
+	ID            uint
+	CreatedAt     time.Time
+	DownloadCount int64
+	Price         float64
+	FileSize      int64
+}
+
+func parseIntValue(s string) (interface{}, error) {
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func parseFloatValue(s string) (interface{}, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+func priceValue(row *datasetKeyRow) string {
+	return strconv.FormatFloat(row.Price, 'f', -1, 64)
+}
+
+var datasetSortKeys = map[string]datasetSortKey{
+	model.SortNewest: {
+		column: "created_at",
+		desc:   true,
+		// 纳秒时间戳，避免时区和精度问题
+		value: func(row *datasetKeyRow) string { return strconv.FormatInt(row.CreatedAt.UnixNano(), 10) },
+		parse: func(s string) (interface{}, error) {
+			n, err := strconv.ParseInt(s, 10, 64)
+			return time.Unix(0, n), err
+		},
+	},
+	// 下载量在翻页期间可能变化：游标只记录上一页最后一条当时的取值，
+	// 下载量跨过该值的数据集可能在后续页重复出现或被跳过，不保证快照一致
+	model.SortDownloads: {
+		column: "download_count",
+		desc:   true,
+		value:  func(row *datasetKeyRow) string { return strconv.FormatInt(row.DownloadCount, 10) },
+		parse:  parseIntValue,
+	},
+	model.SortPriceAsc:  {column: "price", value: priceValue, parse: parseFloatValue},
+	model.SortPriceDesc: {column: "price", desc: true, value: priceValue, parse: parseFloatValue},
+	model.SortSize: {
+		column: "file_size",
+		desc:   true,
+		value:  func(row *datasetKeyRow) string { return strconv.FormatInt(row.FileSize, 10) },
+		parse:  parseIntValue,
+	},
+}
+
+// 是否为数据集列表支持的排序方式（相关度排序由搜索索引处理，不在此列）
+func IsDatasetSort(sort string) bool {
+	_, ok := datasetSortKeys[sort]
+	return ok
+}
+
+// 对数据集列表查询分页，db 为已带筛选条件的查询（可连表，字段需以 datasets. 限定）
+// 游标模式以行构造器 (排序字段, id) 比较定位到上一页最后一条之后，走 (排序字段, id) 联合索引，翻页深度不影响性能；
+// 页码模式保留 OFFSET 以兼容旧接口。多取一条判断是否还有下一页，总数仅在请求时统计
+// keyOf 从列表项取出排序字段，下一页游标直接由本页最后一条生成
+func paginateDatasets[T any](db *gorm.DB, q *model.PageQuery, keyOf func(*T) (datasetKeyRow, error)) ([]T, model.PageInfo, error) {
+	var info model.PageInfo
+	key, ok := datasetSortKeys[q.Sort]
+	if !ok {
+		key = datasetSortKeys[model.SortNewest]
+	}
+
+	if q.WithTotal {
+		var total int64
+		if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, info, err
+		}
+		info.Total = &total
+	}
+
+	column := "datasets." + key.column
+	dir, cmp := "ASC", ">"
+	if key.desc {
+		dir, cmp = "DESC", "<"
+	}
+	tx := db.Session(&gorm.Session{}).Order(column + " " + dir).Order("datasets.id " + dir)
+	if q.Page > 0 {
+		tx = tx.Offset((q.Page - 1) * q.Limit)
+	} else if q.Cursor != nil {
+		v, err := key.parse(q.Cursor.Value)
+		if err != nil {
+			return nil, info, util.ErrInvalidCursor
+		}
+		tx = tx.Where("("+column+", datasets.id) "+cmp+" (?, ?)", v, q.Cursor.ID)
+	}
+
+	var items []T
+	if err := tx.Limit(q.Limit + 1).Find(&items).Error; err != nil {
+		return nil, info, err
+	}
+	if len(items) <= q.Limit {
+		return items, info, nil
+	}
+	items = items[:q.Limit]
+	info.HasMore = true
+
+	// 按最后一条的排序字段生成下一页游标
+	last, err := keyOf(&items[len(items)-1])
+	if err != nil {
+		return nil, info, err
+	}
+	info.NextCursor = util.EncodeCursor(model.PageCursor{Sort: q.Sort, Value: key.value(&last), ID: last.ID})
+	return items, info, nil
+}
+
+// 列表项中的创建时间为驱动格式化后的字符串（RFC 3339，保留纳秒），解析回时间用于游标
+func parseCreatedAt(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// 被 (排序字段, id) 联合索引取代的单列索引，AutoMigrate 不会删除旧索引
+var supersededSortIndexes = []string{"idx_created_at", "idx_download_count", "idx_price", "idx_file_size"}
+
+// 删除已被联合索引取代的单列排序索引，可重复执行
+func (d DatasetDAO) DropSupersededSortIndexes() error {
+	m := d.db.Migrator()
+	for _, name := range supersededSortIndexes {
+		if !m.HasIndex(&model.Dataset{}, name) {
+			continue
+		}
+		if err := m.DropIndex(&model.Dataset{}, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package mysql
+
+import (
+	"backend/internal/model"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagDAO struct {
+	db *gorm.DB
+}
+
+func NewTagDAO(db *gorm.DB) *TagDAO {
+	return &TagDAO{
+		db: db,
+	}
+}
+
+// 设置数据集的标签（覆盖原有关联），不存在的标签自动创建
+func (d TagDAO) SetDatasetTagsTX(tx *gorm.DB, datasetID uint, names []string) error {
+	if err := tx.Where("dataset_id = ?", datasetID).Delete(&model.DatasetTag{}).Error; err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	tags := make([]model.Tag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, model.Tag{Name: name})
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+		return err
+	}
+	var tagIDs []uint
+	if err := tx.Model(&model.Tag{}).Where("name IN ?", names).Pluck("id", &tagIDs).Error; err != nil {
+		return err
+	}
+
+	links := make([]model.DatasetTag, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		links = append(links, model.DatasetTag{DatasetID: datasetID, TagID: id})
+	}
+	return tx.Create(&links).Error
+}
+
+// 统计标签关联的公开数据集（未删除且已通过校验）数量
+func (d TagDAO) tagCounts() *gorm.DB {
+	return d.db.Table("tags").
+		Select("tags.name AS name, COUNT(*) AS count").
+		Joins("JOIN dataset_tags ON dataset_tags.tag_id = tags.id").
+		Joins("JOIN datasets ON datasets.id = dataset_tags.dataset_id AND datasets.deleted_at IS NULL AND datasets.status = ?", model.DatasetStatusReady).
+		Group("tags.id, tags.name")
+}
+
+// 热门标签（按数据集数量倒序）
+func (d TagDAO) GetPopularTags(limit int) ([]model.TagCount, error) {
+	var tags []model.TagCount
+	err := d.tagCounts().Order("count DESC, tags.name ASC").Limit(limit).Scan(&tags).Error
+	return tags, err
+}
+
+// 按前缀补全标签（走 name 唯一索引），按数据集数量倒序
+func (d TagDAO) AutocompleteTags(prefix string, limit int) ([]model.TagCount, error) {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix)
+	var tags []model.TagCount
+	err := d.tagCounts().Where("tags.name LIKE ?", escaped+"%").Order("count DESC, tags.name ASC").Limit(limit).Scan(&tags).Error
+	return tags, err
+}
+
+// 查询尚未建立标签关联的数据集（含已删除），按 ID 递增分批返回，用于迁移旧的逗号分隔标签
+func (d TagDAO) GetUntaggedDatasets(afterID uint, limit int) ([]model.Dataset, error) {
+	var datasets []model.Dataset
+	err := d.db.Unscoped().Model(&model.Dataset{}).Select("id, tags").
+		Where("id > ? AND tags <> ''", afterID).
+		Where("NOT EXISTS (SELECT 1 FROM dataset_tags WHERE dataset_tags.dataset_id = datasets.id)").
+		Order("id ASC").Limit(limit).Find(&datasets).Error
+	return datasets, err
+}
+
+// 按标签筛选数据集：matchAll 为 true 时需包含全部标签（AND），否则包含任一标签（OR）
+func DatasetTagScope(names []string, matchAll bool) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if len(names) == 0 {
+			return tx
+		}
+		sub := "SELECT dataset_tags.dataset_id FROM dataset_tags JOIN tags ON tags.id = dataset_tags.tag_id WHERE tags.name IN ?"
+		if matchAll {
+			return tx.Where("datasets.id IN ("+sub+" GROUP BY dataset_tags.dataset_id HAVING COUNT(*) = ?)", names, len(names))
+		}
+		return tx.Where("datasets.id IN ("+sub+")", names)
+	}
+}
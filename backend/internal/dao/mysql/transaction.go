@@ -3,6 +3,7 @@ package mysql
 import (
 	"backend/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TransactionDAO struct {
@@ -35,9 +36,12 @@ func (d TransactionDAO) CreateTransaction(tx *gorm.DB, userID uint, m *model.Cre
 		BuyerWalletAddress:  userWalletAddress,
 		SellerWalletAddress: sellerWalletAddress,
 		DatasetID:           m.DatasetID,
+		Type:                "purchase",
 		Amount:              m.Amount,
+		Status:              "pending",
 	}
-	return transaction.ID, tx.Create(&transaction).Error
+	err := tx.Create(&transaction).Error
+	return transaction.ID, err
 }
 
 // 根据ID获取交易记录
@@ -45,9 +49,38 @@ func (d TransactionDAO) GetTransactionById(id uint) error {
 	return d.db.First(&model.Transaction{}, id).Error
 }
 
-// 确认交易记录
-func (d TransactionDAO) ConfirmTransaction(m *model.TransactionConfirmRequest) error {
-	return d.db.Model(&model.Transaction{}).Where("id = ?", m.ID).Updates(m).Error
+// 根据ID查询交易记录
+func (d TransactionDAO) GetTransaction(id uint) (*model.Transaction, error) {
+	var transaction model.Transaction
+	if err := d.db.First(&transaction, id).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// 查询用户钱包地址
+func (d TransactionDAO) GetUserWalletAddress(userID uint) (string, error) {
+	var walletAddress string
+	err := d.db.Model(&model.User{}).Where("id = ?", userID).Pluck("wallet_address", &walletAddress).Error
+	return walletAddress, err
+}
+
+// 检查交易哈希是否已被其他交易记录使用 TX
+// 加锁读取，与完成交易记录在同一事务中时，并发确认同一哈希的请求只有一个能完成
+func (d TransactionDAO) CheckTxHashUsedTX(tx *gorm.DB, txHash string, excludeID uint) (bool, error) {
+	var ids []uint
+	err := tx.Model(&model.Transaction{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tx_hash = ? AND id <> ?", txHash, excludeID).Limit(1).Pluck("id", &ids).Error
+	return len(ids) > 0, err
+}
+
+// 使用链上数据完成交易记录 TX（仅处理待确认的记录）
+func (d TransactionDAO) CompleteTransactionTX(tx *gorm.DB, id uint, updates map[string]interface{}) (bool, error) {
+	updates["status"] = "completed"
+	result := tx.Model(&model.Transaction{}).
+		Where("id = ? AND (status = ? OR status = '' OR status IS NULL)", id, "pending").
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
 }
 
 // 获取交易金额
@@ -0,0 +1,123 @@
+package mysql
+
+import (
+	"backend/internal/model"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UploadSessionDAO struct {
+	db *gorm.DB
+}
+
+func NewUploadSessionDAO(db *gorm.DB) *UploadSessionDAO {
+	return &UploadSessionDAO{db: db}
+}
+
+// 创建上传会话
+func (d UploadSessionDAO) CreateSession(m *model.UploadSession) error {
+	m.Status = model.UploadSessionUploading
+	return d.db.Create(m).Error
+}
+
+// 按对象名查询上传会话
+func (d UploadSessionDAO) GetSessionByObjectName(objectName string) (*model.UploadSession, error) {
+	var s model.UploadSession
+	err := d.db.Where("object_name = ?", objectName).First(&s).Error
+	return &s, err
+}
+
+// 上传会话是否存在（清理历史对象时跳过有会话的对象）
+func (d UploadSessionDAO) SessionExists(objectName, uploadID string) (bool, error) {
+	var count int64
+	db := d.db.Model(&model.UploadSession{})
+	if objectName != "" {
+		db = db.Where("object_name = ?", objectName)
+	}
+	if uploadID != "" {
+		db = db.Where("upload_id = ?", uploadID)
+	}
+	err := db.Count(&count).Error
+	return count > 0, err
+}
+
+// 批量查询有上传会话的对象名
+func (d UploadSessionDAO) GetSessionObjectNames(objectNames []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	if len(objectNames) == 0 {
+		return found, nil
+	}
+	var names []string
+	if err := d.db.Model(&model.UploadSession{}).Where("object_name IN ?", objectNames).Pluck("object_name", &names).Error; err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		found[name] = true
+	}
+	return found, nil
+}
+
+// 续期上传中的会话
+func (d UploadSessionDAO) TouchSession(id uint, expiresAt time.Time) error {
+	return d.db.Model(&model.UploadSession{}).
+		Where("id = ? AND status = ?", id, model.UploadSessionUploading).
+		Update("expires_at", expiresAt).Error
+}
+
+// 更新已上传分片并续期（续传查询时）
+func (d UploadSessionDAO) UpdateUploadedParts(id uint, parts json.RawMessage, uploadedBytes int64, expiresAt time.Time) error {
+	return d.db.Model(&model.UploadSession{}).
+		Where("id = ? AND status = ?", id, model.UploadSessionUploading).
+		Updates(map[string]interface{}{
+			"uploaded_parts": parts,
+			"uploaded_bytes": uploadedBytes,
+			"expires_at":     expiresAt,
+		}).Error
+}
+
+// 标记分片已合并，expiresAt 之后仍未被使用的文件由清理任务删除
+func (d UploadSessionDAO) MarkCompleted(id uint, parts json.RawMessage, uploadedBytes int64, expiresAt time.Time) error {
+	return d.db.Model(&model.UploadSession{}).
+		Where("id = ? AND status = ?", id, model.UploadSessionUploading).
+		Updates(map[string]interface{}{
+			"status":         model.UploadSessionCompleted,
+			"uploaded_parts": parts,
+			"uploaded_bytes": uploadedBytes,
+			"expires_at":     expiresAt,
+			"completed_at":   time.Now(),
+		}).Error
+}
+
+// 更新会话状态（仅当当前状态为 from 时），返回是否更新
+func (d UploadSessionDAO) UpdateStatus(id uint, from, to string) (bool, error) {
+	res := d.db.Model(&model.UploadSession{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	return res.RowsAffected > 0, res.Error
+}
+
+// 文件被数据集或版本使用 TX（与数据集/版本记录在同一事务中更新），返回文件是否可以使用：
+// 有会话时只有已合并的会话可以使用，已过期、正在清理或已清理的会话返回 false；没有会话的对象（分片上传之前的文件）可以使用
+func (d UploadSessionDAO) MarkUsedTX(tx *gorm.DB, objectName string) (bool, error) {
+	res := tx.Model(&model.UploadSession{}).
+		Where("object_name = ? AND status = ?", objectName, model.UploadSessionCompleted).
+		Update("status", model.UploadSessionUsed)
+	if res.Error != nil || res.RowsAffected == 1 {
+		return res.Error == nil, res.Error
+	}
+	var count int64
+	err := tx.Model(&model.UploadSession{}).Where("object_name = ?", objectName).Count(&count).Error
+	return count == 0, err
+}
+
+// 查询指定状态下已过期的会话
+func (d UploadSessionDAO) ListExpiredSessions(status string, now time.Time, limit int) ([]model.UploadSession, error) {
+	var sessions []model.UploadSession
+	err := d.db.Where("status = ? AND expires_at < ?", status, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&sessions).Error
+	return sessions, err
+}
@@ -17,16 +17,6 @@ func NewDatasetRedisDAO(redis *redis.Client) *DatasetRedisDAO {
 	}
 }
 
-// 记录进度
-func (d DatasetRedisDAO) RecordDownloadTask(ctx context.Context, key string, progress uint) error {
-	return d.redis.Set(ctx, key, progress, util.DATASET_EXPIRE*time.Minute).Err()
-}
-
-// 获取进度
-func (d DatasetRedisDAO) GetDownloadTask(ctx context.Context, key string) (string, error) {
-	return d.redis.Get(ctx, key).Result()
-}
-
 // 设置下载 url
 func (d DatasetRedisDAO) SetDownloadURL(ctx context.Context, key string, url string) error {
 	return d.redis.Set(ctx, key, url, util.DATASET_EXPIRE*time.Minute).Err()
@@ -36,3 +26,23 @@ func (d DatasetRedisDAO) SetDownloadURL(ctx context.Context, key string, url str
 func (d DatasetRedisDAO) GetDownloadURL(ctx context.Context, key string) (string, error) {
 	return d.redis.Get(ctx, key).Result()
 }
+
+// 缓存流式下载的输出大小（带指纹后的字节数）
+func (d DatasetRedisDAO) SetStreamSize(ctx context.Context, key string, size int64) error {
+	return d.redis.Set(ctx, key, size, 24*time.Hour).Err()
+}
+
+// 获取流式下载的输出大小
+func (d DatasetRedisDAO) GetStreamSize(ctx context.Context, key string) (int64, error) {
+	return d.redis.Get(ctx, key).Int64()
+}
+
+// 缓存流式下载输出的 SHA-256
+func (d DatasetRedisDAO) SetStreamChecksum(ctx context.Context, key, checksum string) error {
+	return d.redis.Set(ctx, key, checksum, 24*time.Hour).Err()
+}
+
+// 获取流式下载输出的 SHA-256
+func (d DatasetRedisDAO) GetStreamChecksum(ctx context.Context, key string) (string, error) {
+	return d.redis.Get(ctx, key).Result()
+}
@@ -103,3 +103,36 @@ func (d RankRedisDAO) GetTopLatestJSON(limit int) ([]map[string]interface{}, err
 	}
 	return out, nil
 }
+
+func (d RankRedisDAO) UpdateDataset(id uint, fields map[string]interface{}) error {
+	// 1. 数据集信息修改后，同步热门榜和最近上传榜中的快照字段，分数不变，不在榜单中时忽略
+	ctx := context.Background()
+	for _, key := range []string{util.RANK_DATASET_HOT, util.RANK_DATASET_LATEST} {
+		members, err := d.redis.ZRangeWithScores(ctx, key, 0, -1).Result()
+		if err != nil {
+			return err
+		}
+		for _, z := range members {
+			s, _ := z.Member.(string)
+			var m map[string]interface{}
+			if err := json.Unmarshal([]byte(s), &m); err != nil {
+				continue
+			}
+			if v, ok := m["id"].(float64); !ok || uint(v) != id {
+				continue
+			}
+			// 2. 替换成员：删除旧快照，按原分数写入新快照
+			for k, v := range fields {
+				m[k] = v
+			}
+			b, _ := json.Marshal(m)
+			pipe := d.redis.TxPipeline()
+			pipe.ZRem(ctx, key, s)
+			pipe.ZAdd(ctx, key, &redis.Z{Score: z.Score, Member: string(b)})
+			if _, err := pipe.Exec(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 描述字段高亮片段的最大字符数
+const descriptionSnippetRunes = 120
+
+// 分词结果：词项及其在原文中的字节区间
+type token struct {
+	term       string
+	start, end int
+}
+
+// 中日韩文字不以空格分词，按相邻两字切分（与 MySQL ngram 解析器的 ngram_token_size=2 一致）
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// 分词：拉丁字母和数字按单词切分并转小写，中日韩文字按二元组切分，其余字符作为分隔符
+func analyze(text string) []token {
+	var tokens []token
+	wordStart := -1
+	var cjk []int // 当前中日韩文字串中每个字的起始字节
+
+	flushWord := func(end int) {
+		if wordStart != -1 {
+			tokens = append(tokens, token{term: strings.ToLower(text[wordStart:end]), start: wordStart, end: end})
+			wordStart = -1
+		}
+	}
+	flushCJK := func(end int) {
+		switch len(cjk) {
+		case 0:
+			return
+		case 1:
+			tokens = append(tokens, token{term: text[cjk[0]:end], start: cjk[0], end: end})
+		default:
+			for i := 0; i+1 < len(cjk); i++ {
+				e := end
+				if i+2 < len(cjk) {
+					e = cjk[i+2]
+				}
+				tokens = append(tokens, token{term: text[cjk[i]:e], start: cjk[i], end: e})
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for i, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord(i)
+			cjk = append(cjk, i)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK(i)
+			if wordStart == -1 {
+				wordStart = i
+			}
+		default:
+			flushWord(i)
+			flushCJK(i)
+		}
+	}
+	flushWord(len(text))
+	flushCJK(len(text))
+	return tokens
+}
+
+// 查询词项集合
+func queryTerms(text string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, t := range analyze(text) {
+		terms[t.term] = true
+	}
+	return terms
+}
+
+// 生成高亮片段：原文转义后，命中的词项用 <mark> 包裹；maxRunes > 0 时截取第一个命中附近的片段
+func highlight(text string, terms map[string]bool, maxRunes int) (string, bool) {
+	// 合并重叠的命中区间（相邻二元组会重叠）
+	var spans [][2]int
+	for _, t := range analyze(text) {
+		if !terms[t.term] {
+			continue
+		}
+		if n := len(spans); n > 0 && t.start <= spans[n-1][1] {
+			spans[n-1][1] = max(spans[n-1][1], t.end)
+			continue
+		}
+		spans = append(spans, [2]int{t.start, t.end})
+	}
+	if len(spans) == 0 {
+		return "", false
+	}
+
+	from, to := 0, len(text)
+	if maxRunes > 0 && utf8.RuneCountInString(text) > maxRunes {
+		// 命中词之前保留约四分之一的上下文
+		from = backRunes(text, spans[0][0], maxRunes/4)
+		to = forwardRunes(text, from, maxRunes)
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, span := range spans {
+		start, end := max(span[0], from), min(span[1], to)
+		if start >= end {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[start:end]))
+		b.WriteString("</mark>")
+		pos = end
+	}
+	b.WriteString(html.EscapeString(text[pos:to]))
+	if to < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
+
+// 从字节位置 pos 向前 n 个字符的字节位置
+func backRunes(text string, pos, n int) int {
+	for ; n > 0 && pos > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(text[:pos])
+		pos -= size
+	}
+	return pos
+}
+
+// 从字节位置 pos 向后 n 个字符的字节位置
+func forwardRunes(text string, pos, n int) int {
+	for ; n > 0 && pos < len(text); n-- {
+		_, size := utf8.DecodeRuneInString(text[pos:])
+		pos += size
+	}
+	return pos
+}
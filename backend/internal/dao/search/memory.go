@@ -0,0 +1,168 @@
+package search
+
+import (
+	"backend/internal/model"
+	"math"
+	"sort"
+	"sync"
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// 字段权重：标题命中比标签、描述更相关
+var fieldWeights = struct{ title, tags, description float64 }{title: 3, tags: 2, description: 1}
+
+type memoryDoc struct {
+	dataset model.Dataset
+	terms   map[string]float64 // 加权词频
+	length  float64            // 加权词数
+}
+
+// 进程内倒排索引（BM25 排序），启动时由 Rebuild 从数据库全量加载，之后随数据集变更增量更新
+// 适合单实例部署或没有 ngram 全文索引的 MySQL
+type MemorySearchIndex struct {
+	mu       sync.RWMutex
+	docs     map[uint]*memoryDoc
+	postings map[string]map[uint]float64
+	totalLen float64
+}
+
+func NewMemorySearchIndex() *MemorySearchIndex {
+	return &MemorySearchIndex{
+		docs:     make(map[uint]*memoryDoc),
+		postings: make(map[string]map[uint]float64),
+	}
+}
+
+// 全量重建索引
+func (m *MemorySearchIndex) Rebuild(datasets []model.Dataset) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.docs = make(map[uint]*memoryDoc, len(datasets))
+	m.postings = make(map[string]map[uint]float64)
+	m.totalLen = 0
+	for i := range datasets {
+		m.add(&datasets[i])
+	}
+}
+
+func (m *MemorySearchIndex) Index(dataset *model.Dataset) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(dataset.ID)
+	if !dataset.DeletedAt.Valid {
+		m.add(dataset)
+	}
+	return nil
+}
+
+func (m *MemorySearchIndex) Delete(datasetID uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(datasetID)
+	return nil
+}
+
+func (m *MemorySearchIndex) add(ds *model.Dataset) {
+	doc := &memoryDoc{dataset: *ds, terms: make(map[string]float64)}
+	for _, field := range []struct {
+		text   string
+		weight float64
+	}{{ds.Title, fieldWeights.title}, {ds.Tags, fieldWeights.tags}, {ds.Description, fieldWeights.description}} {
+		for _, t := range analyze(field.text) {
+			doc.terms[t.term] += field.weight
+			doc.length += field.weight
+		}
+	}
+	for term, tf := range doc.terms {
+		if m.postings[term] == nil {
+			m.postings[term] = make(map[uint]float64)
+		}
+		m.postings[term][ds.ID] = tf
+	}
+	m.docs[ds.ID] = doc
+	m.totalLen += doc.length
+}
+
+func (m *MemorySearchIndex) remove(id uint) {
+	doc, ok := m.docs[id]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		delete(m.postings[term], id)
+		if len(m.postings[term]) == 0 {
+			delete(m.postings, term)
+		}
+	}
+	m.totalLen -= doc.length
+	delete(m.docs, id)
+}
+
+func (m *MemorySearchIndex) Search(q *model.DatasetSearchQuery) (*model.SearchResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	terms := queryTerms(q.Text)
+	scores := make(map[uint]float64)
+	if len(terms) == 0 {
+		for id := range m.docs {
+			scores[id] = 0
+		}
+	} else {
+		n := float64(len(m.docs))
+		avgLen := m.totalLen / math.Max(n, 1)
+		for term := range terms {
+			postings := m.postings[term]
+			df := float64(len(postings))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			for id, tf := range postings {
+				norm := bm25K1 * (1 - bm25B + bm25B*m.docs[id].length/math.Max(avgLen, 1))
+				scores[id] += idf * tf * (bm25K1 + 1) / (tf + norm)
+			}
+		}
+	}
+
+	result := &model.SearchResult{Facets: model.NewSearchFacets()}
+	hits := make([]*memoryDoc, 0, len(scores))
+	for id := range scores {
+		doc := m.docs[id]
+		if !matchFilters(&doc.dataset, &q.DatasetQuery) {
+			continue
+		}
+		hits = append(hits, doc)
+		result.Facets.Category[doc.dataset.Category]++
+		result.Facets.License[doc.dataset.License]++
+		result.Facets.Pricing[pricing(doc.dataset.IsFree)]++
+		result.Facets.SizeBucket[model.SizeBucket(doc.dataset.FileSize)]++
+	}
+	result.Total = int64(len(hits))
+
+	// 相关度倒序，相同得分按创建时间倒序
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if sa, sb := scores[a.dataset.ID], scores[b.dataset.ID]; sa != sb {
+			return sa > sb
+		}
+		if !a.dataset.CreatedAt.Equal(b.dataset.CreatedAt) {
+			return a.dataset.CreatedAt.After(b.dataset.CreatedAt)
+		}
+		return a.dataset.ID > b.dataset.ID
+	})
+
+	offset := (q.Page - 1) * q.Limit
+	if offset < len(hits) {
+		for _, doc := range hits[offset:min(offset+q.Limit, len(hits))] {
+			result.Hits = append(result.Hits, model.SearchHit{
+				ID:         doc.dataset.ID,
+				Score:      scores[doc.dataset.ID],
+				Highlights: highlightDataset(doc.dataset.Title, doc.dataset.Description, doc.dataset.Tags, terms),
+			})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,97 @@
+package search
+
+import (
+	"backend/internal/dao/mysql"
+	"backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 基于 MySQL FULLTEXT（ngram 解析器，支持中文）的搜索索引
+// 索引由 InnoDB 随 datasets 表写入自动维护，Index/Delete 无需额外操作，软删除的数据集由 gorm 默认条件过滤
+type MySQLSearchIndex struct {
+	db *gorm.DB
+}
+
+func NewMySQLSearchIndex(db *gorm.DB) *MySQLSearchIndex {
+	return &MySQLSearchIndex{db: db}
+}
+
+func (s *MySQLSearchIndex) Index(*model.Dataset) error {
+	return nil
+}
+
+func (s *MySQLSearchIndex) Delete(uint) error {
+	return nil
+}
+
+type facetRow struct {
+	Value string
+	Count int64
+}
+
+type hitRow struct {
+	ID          uint
+	Title       string
+	Description string
+	Tags        string
+	Score       float64
+}
+
+func (s *MySQLSearchIndex) Search(q *model.DatasetSearchQuery) (*model.SearchResult, error) {
+	query := func() *gorm.DB {
+		return s.db.Model(&model.Dataset{}).Scopes(mysql.DatasetQueryScope(&q.DatasetQuery))
+	}
+
+	result := &model.SearchResult{Facets: model.NewSearchFacets()}
+	if err := query().Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+
+	// 分面统计
+	bounds := model.SizeBucketBounds
+	facets := []struct {
+		expr string
+		args []interface{}
+		dest map[string]int64
+	}{
+		{"category", nil, result.Facets.Category},
+		{"license", nil, result.Facets.License},
+		{"CASE WHEN is_free THEN '" + PricingFree + "' ELSE '" + PricingPaid + "' END", nil, result.Facets.Pricing},
+		{"CASE WHEN file_size >= ? THEN '" + model.SizeBucketHuge + "' WHEN file_size >= ? THEN '" + model.SizeBucketLarge +
+			"' WHEN file_size >= ? THEN '" + model.SizeBucketMedium + "' ELSE '" + model.SizeBucketSmall + "' END",
+			[]interface{}{bounds[3], bounds[2], bounds[1]}, result.Facets.SizeBucket},
+	}
+	for _, f := range facets {
+		var rows []facetRow
+		if err := query().Select(f.expr+" AS value, COUNT(*) AS count", f.args...).Group("value").Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			f.dest[row.Value] = row.Count
+		}
+	}
+
+	// 当前页命中
+	var rows []hitRow
+	tx := query()
+	if q.Text != "" {
+		tx = tx.Select("id, title, description, tags, "+mysql.DatasetFulltextMatch+" AS score", q.Text).Order("score DESC")
+	} else {
+		tx = tx.Select("id, title, description, tags, 0 AS score")
+	}
+	offset := (q.Page - 1) * q.Limit
+	if err := tx.Order("created_at DESC").Order("id DESC").Limit(q.Limit).Offset(offset).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	terms := queryTerms(q.Text)
+	for _, row := range rows {
+		result.Hits = append(result.Hits, model.SearchHit{
+			ID:         row.ID,
+			Score:      row.Score,
+			Highlights: highlightDataset(row.Title, row.Description, row.Tags, terms),
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,104 @@
+package search
+
+import (
+	"backend/internal/model"
+	"backend/internal/util"
+	"strings"
+)
+
+// 付费分面的取值
+const (
+	PricingFree = "free"
+	PricingPaid = "paid"
+)
+
+// 数据集搜索索引：上传、删除、恢复数据集时同步，搜索返回按相关度排序的命中、分面统计和高亮片段
+type SearchIndex interface {
+	// 写入或更新数据集，已删除的数据集从索引中移除
+	Index(dataset *model.Dataset) error
+	// 从索引中移除数据集
+	Delete(datasetID uint) error
+	Search(q *model.DatasetSearchQuery) (*model.SearchResult, error)
+}
+
+// 判断数据集是否满足筛选条件（不含关键词）
+func matchFilters(ds *model.Dataset, q *model.DatasetQuery) bool {
+	if q.Category != "" && ds.Category != q.Category {
+		return false
+	}
+	if q.License != "" && ds.License != q.License {
+		return false
+	}
+	if q.AuthorWallet != "" && !strings.EqualFold(ds.AuthorWalletAddress, q.AuthorWallet) {
+		return false
+	}
+	if q.IsFree != nil && ds.IsFree != *q.IsFree {
+		return false
+	}
+	if q.MinSize != nil && ds.FileSize < *q.MinSize {
+		return false
+	}
+	if q.MaxSize != nil && ds.FileSize > *q.MaxSize {
+		return false
+	}
+	if q.MinPrice != nil && ds.Price < *q.MinPrice {
+		return false
+	}
+	if q.MaxPrice != nil && ds.Price > *q.MaxPrice {
+		return false
+	}
+	if q.CreatedFrom != nil && ds.CreatedAt.Before(*q.CreatedFrom) {
+		return false
+	}
+	if q.CreatedTo != nil && !ds.CreatedAt.Before(*q.CreatedTo) {
+		return false
+	}
+	if q.Status != "" && ds.Status != q.Status {
+		return false
+	}
+	if len(q.Tags) > 0 {
+		return matchTags(util.ParseTags(ds.Tags), q.Tags, q.MatchAllTags)
+	}
+	return true
+}
+
+func matchTags(tags, want []string, matchAll bool) bool {
+	has := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		has[t] = true
+	}
+	for _, t := range want {
+		if has[t] && !matchAll {
+			return true
+		}
+		if !has[t] && matchAll {
+			return false
+		}
+	}
+	return matchAll
+}
+
+func pricing(isFree bool) string {
+	if isFree {
+		return PricingFree
+	}
+	return PricingPaid
+}
+
+// 计算标题、描述、标签的高亮片段，未命中的字段不返回
+func highlightDataset(title, description, tags string, terms map[string]bool) map[string]string {
+	if len(terms) == 0 {
+		return nil
+	}
+	highlights := make(map[string]string)
+	if s, ok := highlight(title, terms, 0); ok {
+		highlights["title"] = s
+	}
+	if s, ok := highlight(description, terms, descriptionSnippetRunes); ok {
+		highlights["description"] = s
+	}
+	if s, ok := highlight(tags, terms, 0); ok {
+		highlights["tags"] = s
+	}
+	return highlights
+}
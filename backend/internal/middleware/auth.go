@@ -31,3 +31,16 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// 可选的 JWT 认证：携带有效令牌时记录用户信息，没有或无效时按未登录继续（公开接口按当前用户区分返回内容）
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tokenStr, err := c.Cookie("auth_token"); err == nil && tokenStr != "" {
+			if claims, err := util.ParseToken(tokenStr); err == nil {
+				c.Set("userID", claims.Id)
+				c.Set("username", claims.Username)
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"backend/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 角色查询接口，由 service 层实现（Redis 缓存优先，未命中查 MySQL）
+type RoleGetter interface {
+	GetUserRole(userID uint) (string, error)
+}
+
+// 角色鉴权中间件，需在 AuthMiddleware 之后使用
+func RequireRole(getter RoleGetter, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIdStr, exists := c.Get("userID")
+		if !exists {
+			util.Unauthorized(c, "请登陆后再访问")
+			c.Abort()
+			return
+		}
+
+		// 查询当前用户角色
+		role, err := getter.GetUserRole(userIdStr.(uint))
+		if err != nil {
+			util.Error("获取用户角色失败", zap.Error(err))
+			util.Unauthorized(c, "用户不存在或角色获取失败")
+			c.Abort()
+			return
+		}
+
+		for _, r := range roles {
+			if r == role {
+				// 将角色存储在上下文
+				c.Set("role", role)
+				c.Next()
+				return
+			}
+		}
+
+		util.Warn("用户角色权限不足", zap.Uint("userID", userIdStr.(uint)), zap.String("role", role))
+		util.Forbidden(c, "权限不足")
+		c.Abort()
+	}
+}
@@ -57,8 +57,9 @@ type AdminUserStatsResponse struct {
 
 // 指纹检测响应
 type AdminFingerprintDetectResponse struct {
-	MatchResult   string `json:"matchResult"`
-	DetectionTime string `json:"detectionTime"`
+	MatchResult   string        `json:"matchResult"`
+	DetectionTime string        `json:"detectionTime"`
+	Report        *DetectReport `json:"report"` // 匹配报告
 }
 
 // 管理员数据集列表响应体
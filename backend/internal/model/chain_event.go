@@ -0,0 +1,43 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ChainCheckpoint 链上事件同步进度
+// 记录已处理到的区块高度和区块哈希，哈希用于检测超过确认深度的回滚
+type ChainCheckpoint struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"type:varchar(50);uniqueIndex;not null" json:"name"`
+	BlockNumber uint64    `gorm:"type:bigint;not null" json:"blockNumber"`
+	BlockHash   string    `gorm:"type:varchar(100)" json:"blockHash"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime(3)" json:"updatedAt"`
+}
+
+// ChainEvent 已处理的链上事件
+// tx_hash + log_index 唯一，保证同一事件只被处理一次；undo 记录撤销该事件所需的旧值，区块回滚时使用
+type ChainEvent struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	TxHash      string          `gorm:"type:varchar(100);not null;index:idx_tx_log,unique" json:"txHash"`
+	LogIndex    uint64          `gorm:"not null;index:idx_tx_log,unique" json:"logIndex"`
+	BlockNumber uint64          `gorm:"type:bigint;not null;index:idx_block_number" json:"blockNumber"`
+	BlockHash   string          `gorm:"type:varchar(100)" json:"blockHash"`
+	EventName   string          `gorm:"type:varchar(50);not null" json:"eventName"`
+	DatasetID   uint64          `gorm:"type:bigint;index:idx_dataset" json:"datasetId"`
+	Account     string          `gorm:"type:varchar(42)" json:"account"`
+	Undo        json.RawMessage `gorm:"type:json" json:"undo"`
+	CreatedAt   time.Time       `gorm:"autoCreateTime(3)" json:"createdAt"`
+}
+
+// ChainEventUndo 撤销链上事件对账结果所需的数据
+type ChainEventUndo struct {
+	Fields        map[string]*FieldChange `json:"fields,omitempty"`        // 数据集字段修改前后值
+	Restore       bool                    `json:"restore,omitempty"`       // 数据集由该事件下架
+	TransactionID uint                    `json:"transactionId,omitempty"` // 该事件完成或补建的交易记录
+	Created       bool                    `json:"created,omitempty"`       // 交易记录由该事件补建
+	BuyerID       uint                    `json:"buyerId,omitempty"`       // 补建记录时计入花费的买家
+	Amount        float64                 `json:"amount,omitempty"`
+	UserID        uint                    `json:"userId,omitempty"` // 角色被修改的用户
+	Role          string                  `json:"role,omitempty"`   // 修改前角色
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -9,19 +11,26 @@ import (
 // 字段含义详见 db/mysql_schema.md
 // 注意：使用钱包地址而不是用户ID来标识作者，确保与区块链数据一致
 type Dataset struct {
-	gorm.Model
-	Title               string  `gorm:"type:varchar(200);not null" json:"title"`
-	Description         string  `gorm:"type:text" json:"description"`
-	Category            string  `gorm:"type:varchar(50);not null;index:idx_category" json:"category"`
-	Tags                string  `gorm:"type:varchar(200)" json:"tags"`
-	Price               float64 `gorm:"type:decimal(10,2);default:0.00" json:"price"`
-	IsFree              bool    `gorm:"type:boolean;default:false;index:idx_is_free" json:"isFree"`
-	BucketName          string  `gorm:"type:varchar(100);not null" json:"-"`
-	ObjectName          string  `gorm:"type:varchar(200);not null" json:"objectName"`
-	FileSize            int64   `gorm:"type:bigint;not null;index:idx_file_size" json:"fileSize"`
-	AuthorWalletAddress string  `gorm:"type:varchar(42);not null;index:idx_author_wallet" json:"authorWalletAddress"`
-	DownloadCount       int     `gorm:"type:int;default:0" json:"download_count"`
-	License             string  `gorm:"type:varchar(100);not null" json:"license"`
+	// 与 gorm.Model 相同的字段；列表的每种排序字段都与 id 建联合索引，用于排序和键集分页
+	ID                  uint      `gorm:"primarykey;index:idx_created_at_id,priority:2;index:idx_download_count_id,priority:2;index:idx_price_id,priority:2;index:idx_file_size_id,priority:2"`
+	CreatedAt           time.Time `gorm:"index:idx_created_at_id,priority:1"`
+	UpdatedAt           time.Time
+	DeletedAt           gorm.DeletedAt `gorm:"index"`
+	Title               string         `gorm:"type:varchar(200);not null;index:idx_fulltext_search,class:FULLTEXT,option:WITH PARSER ngram" json:"title"`
+	Description         string         `gorm:"type:text;index:idx_fulltext_search,class:FULLTEXT,option:WITH PARSER ngram" json:"description"`
+	Category            string         `gorm:"type:varchar(50);not null;index:idx_category" json:"category"`
+	Tags                string         `gorm:"type:varchar(200);index:idx_fulltext_search,class:FULLTEXT,option:WITH PARSER ngram" json:"tags"`
+	Price               float64        `gorm:"type:decimal(10,2);default:0.00;index:idx_price_id,priority:1" json:"price"`
+	IsFree              bool           `gorm:"type:boolean;default:false;index:idx_is_free" json:"isFree"`
+	BucketName          string         `gorm:"type:varchar(100);not null" json:"-"`
+	ObjectName          string         `gorm:"type:varchar(200);not null" json:"objectName"`
+	FileSize            int64          `gorm:"type:bigint;not null;index:idx_file_size_id,priority:1" json:"fileSize"`
+	Checksum            string         `gorm:"type:char(64);index:idx_checksum" json:"checksum"`        // 当前文件的 SHA-256，与最新的已通过校验版本一致，历史数据集可能为空
+	Format              string         `gorm:"type:varchar(20);not null;default:'jsonl'" json:"format"` // 数据集格式，决定指纹写入方式
+	AuthorWalletAddress string         `gorm:"type:varchar(42);not null;index:idx_author_wallet" json:"authorWalletAddress"`
+	DownloadCount       int            `gorm:"type:int;default:0;index:idx_download_count_id,priority:1" json:"download_count"`
+	License             string         `gorm:"type:varchar(100);not null" json:"license"`
+	Status              string         `gorm:"type:varchar(20);not null;default:'ready';index:idx_status" json:"status"` // validating / ready / rejected，见 DatasetValidation
 }
 
 // 上传预览数据请求体
@@ -31,15 +40,64 @@ type UploadPreviewRequest struct {
 	PreviewData  []interface{} `json:"previewData" bson:"previewData" binding:"required"`
 	ObjectName   string        `json:"objectName" bson:"objectName" binding:"required"`
 	FileSize     int64         `json:"fileSize" bson:"fileSize" binding:"required"`
+	Format       string        `json:"format" bson:"format,omitempty"` // jsonl/csv/tsv/text/json，为空时按对象名扩展名推断
 }
 
-// 预览数据响应体
+// 预览数据文档（datasets_preview）：previewData 为卖家上传的预览，仅用于文件校验时核对；
+// 对外展示的抽样记录和字段概况由文件校验 worker 从实际文件生成
 type PreviewResponse struct {
-	ObjectName  string   `json:"objectName" bson:"objectName"`
-	FileSize    int64    `json:"fileSize" bson:"fileSize"`
-	PreviewData []string `json:"preview" bson:"previewData"`
+	ObjectName       string   `json:"objectName" bson:"objectName"`
+	FileSize         int64    `json:"fileSize" bson:"fileSize"`
+	PreviewData      []string `json:"preview" bson:"previewData"`
+	GeneratedPreview `bson:",inline"`
+}
+
+// 服务端生成的预览数据
+type GeneratedPreview struct {
+	Generated   bool           `json:"generated" bson:"generated"`
+	Sample      []string       `json:"sample" bson:"sample"`           // 抽样记录（JSON 对象），前 sampleHead 条为文件开头的记录，其余为随机抽样
+	SampleHead  int            `json:"sampleHead" bson:"sampleHead"`   // 文件开头的记录数
+	SampleTotal int            `json:"sampleTotal" bson:"sampleTotal"` // 抽样记录总数（分页查询时 sample 只包含当前页）
+	Records     int64          `json:"records" bson:"records"`         // 文件记录数
+	Fields      []PreviewField `json:"fields" bson:"fields"`
+	GeneratedAt time.Time      `json:"generatedAt" bson:"generatedAt"`
+}
+
+// 字段结构及取值统计
+type PreviewField struct {
+	Name              string           `json:"name" bson:"name"`
+	Types             map[string]int64 `json:"types" bson:"types"` // 类型 → 出现次数（不含 null）
+	NullRate          float64          `json:"nullRate" bson:"nullRate"`
+	Min               *float64         `json:"min,omitempty" bson:"min,omitempty"`
+	Max               *float64         `json:"max,omitempty" bson:"max,omitempty"`
+	Mean              *float64         `json:"mean,omitempty" bson:"mean,omitempty"`
+	MinLength         *int             `json:"minLength,omitempty" bson:"minLength,omitempty"`
+	MaxLength         *int             `json:"maxLength,omitempty" bson:"maxLength,omitempty"`
+	Distinct          int64            `json:"distinct" bson:"distinct"`
+	DistinctTruncated bool             `json:"distinctTruncated,omitempty" bson:"distinctTruncated,omitempty"` // distinct 为下限
+	Examples          []string         `json:"examples" bson:"examples"`
 }
 
+// 预览数据分页查询结果
+type PreviewPage struct {
+	Source      string         `json:"source"` // server：服务端抽样；seller：历史数据集，为卖家上传的预览
+	Items       []string       `json:"data"`   // 当前页记录（JSON 对象字符串），字段名与旧接口一致
+	Page        int            `json:"page"`
+	Limit       int            `json:"limit"`
+	Total       int            `json:"total"`
+	TotalPages  int            `json:"totalPages"`
+	SampleHead  int            `json:"sampleHead"`
+	Records     int64          `json:"records"`
+	Fields      []PreviewField `json:"fields"`
+	GeneratedAt *time.Time     `json:"generatedAt"`
+}
+
+// 预览数据来源
+const (
+	PreviewSourceServer = "server"
+	PreviewSourceSeller = "seller"
+)
+
 // 上传数据集请求体
 type UploadDatasetRequest struct {
 	Title               string  `json:"title" binding:"required"`
@@ -50,6 +108,8 @@ type UploadDatasetRequest struct {
 	IsFree              bool    `json:"isFree"`
 	ObjectName          string  `json:"objectName" binding:"required"`
 	FileSize            int64   `json:"fileSize" binding:"required"`
+	Format              string  `json:"format"`                                          // jsonl/csv/tsv/text/json，为空时按对象名扩展名推断
+	Checksum            string  `json:"checksum" binding:"omitempty,len=64,hexadecimal"` // 文件 SHA-256，记入初始版本
 	AuthorWalletAddress string  `json:"authorWalletAddress" binding:"required"`
 	License             string  `json:"license" binding:"required"`
 }
@@ -71,9 +131,11 @@ type DatasetListResponse struct {
 	IsFree              bool    `json:"isFree"`
 	ObjectName          string  `json:"objectName"`
 	FileSize            int64   `json:"fileSize"`
+	Checksum            string  `json:"checksum"`
 	AuthorWalletAddress string  `json:"authorWalletAddress"`
 	DownloadCount       int     `json:"downloadCount"`
 	License             string  `json:"license"`
+	Status              string  `json:"status"`
 	CreatedAt           string  `json:"createdAt"`
 	UpdatedAt           string  `json:"updatedAt"`
 }
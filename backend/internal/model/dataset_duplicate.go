@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// 重复文件审核状态
+const (
+	DuplicatePending   = "pending"   // 待审核
+	DuplicateConfirmed = "confirmed" // 确认为重复上传，数据集下架（status 置为 rejected）
+	DuplicateDismissed = "dismissed" // 误报（如作者本人换钱包后重新上传、获得授权的转售）
+)
+
+// DatasetDuplicate 重复文件记录表结构体
+// 版本通过文件校验时，若其他卖家已有 SHA-256 相同的已通过版本，记录一条待管理员审核
+// 每个版本最多一条，original 为最早上传该文件的版本
+type DatasetDuplicate struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	DatasetID         uint       `gorm:"not null;index:idx_duplicate_dataset" json:"datasetId"`
+	VersionID         uint       `gorm:"not null;uniqueIndex" json:"versionId"`
+	Version           int        `gorm:"not null" json:"version"`
+	Checksum          string     `gorm:"type:char(64);not null;index" json:"checksum"`
+	OriginalDatasetID uint       `gorm:"not null" json:"originalDatasetId"`
+	OriginalVersionID uint       `gorm:"not null" json:"originalVersionId"`
+	OriginalVersion   int        `gorm:"not null" json:"originalVersion"`
+	Status            string     `gorm:"type:enum('pending','confirmed','dismissed');not null;default:'pending';index:idx_duplicate_status" json:"status"`
+	ReviewerID        *uint      `json:"reviewerId"`
+	ReviewNote        string     `gorm:"type:varchar(500)" json:"reviewNote"`
+	ReviewedAt        *time.Time `gorm:"type:datetime(3)" json:"reviewedAt"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime(3);index:idx_duplicate_status" json:"createdAt"`
+}
+
+// 重复文件列表查询参数
+type DuplicateQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending confirmed dismissed"`
+	Page   int    `form:"page"`
+	Limit  int    `form:"limit"`
+}
+
+// 重复文件列表项，附带双方数据集的标题和作者
+type DuplicateResponse struct {
+	DatasetDuplicate
+	Title                 string `json:"title"`
+	AuthorWalletAddress   string `json:"authorWalletAddress"`
+	OriginalTitle         string `json:"originalTitle"`
+	OriginalAuthorAddress string `json:"originalAuthorAddress"`
+}
+
+// 审核重复文件请求体
+type ReviewDuplicateRequest struct {
+	Action string `json:"action" binding:"required,oneof=confirm dismiss"`
+	Note   string `json:"note" binding:"max=500"`
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 数据集修改来源
+const (
+	DatasetEditSourceUser  = "user"  // 卖家通过接口修改
+	DatasetEditSourceChain = "chain" // 链上 DatasetCreated/DatasetUpdated 事件同步
+)
+
+// 数据集修改状态
+const (
+	DatasetEditApplied      = "applied"       // 已生效
+	DatasetEditPendingChain = "pending_chain" // 链下字段已生效，价格等待链上事件确认
+	DatasetEditConfirmed    = "confirmed"     // 链上事件已确认
+	DatasetEditSuperseded   = "superseded"    // 被之后的修改或不一致的链上价格取代，未生效
+)
+
+// 数据集修改记录表（审计），每次修改一条，记录前后值
+// 付费数据集的价格以合约为准：卖家修改价格时先记为 pending_chain，由链上事件同步后确认
+type DatasetEdit struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	DatasetID    uint            `gorm:"not null;index:idx_dataset_status,priority:1" json:"datasetId"`
+	UserID       *uint           `json:"userId"` // 链上同步时为空
+	Source       string          `gorm:"type:varchar(10);not null" json:"source"`
+	Changes      json.RawMessage `gorm:"type:json;not null" json:"changes"`      // {"字段": {"old": 旧值, "new": 新值}}
+	PendingPrice *float64        `gorm:"type:decimal(10,2)" json:"pendingPrice"` // 等待链上确认的价格
+	Status       string          `gorm:"type:enum('applied','pending_chain','confirmed','superseded');not null;index:idx_dataset_status,priority:2" json:"status"`
+	TxHash       string          `gorm:"type:varchar(66)" json:"txHash"` // 确认该修改的链上交易
+	CreatedAt    time.Time       `gorm:"autoCreateTime(3)" json:"createdAt"`
+	UpdatedAt    time.Time       `gorm:"autoUpdateTime(3)" json:"updatedAt"`
+}
+
+// 单个字段的修改前后值
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// 修改数据集请求体，字段为空表示不修改
+type UpdateDatasetRequest struct {
+	Title       *string  `json:"title" binding:"omitempty,max=200"`
+	Description *string  `json:"description"`
+	Category    *string  `json:"category" binding:"omitempty,max=50"`
+	Tags        *string  `json:"tags" binding:"omitempty,max=200"`
+	Price       *float64 `json:"price" binding:"omitempty,min=0,max=99999999.99"`
+	IsFree      *bool    `json:"isFree"`
+	License     *string  `json:"license" binding:"omitempty,max=100"`
+}
+
+// 需要卖家发起的合约调用
+const (
+	ChainActionCreateDataset = "createDataset" // 免费改为付费：数据集首次上链
+	ChainActionUpdateDataset = "updateDataset" // 付费数据集的标题、描述或价格变化
+)
+
+// 修改数据集响应体
+type UpdateDatasetResponse struct {
+	Dataset     DatasetListResponse `json:"dataset"`
+	Edit        *DatasetEdit        `json:"edit"`
+	ChainAction string              `json:"chainAction,omitempty"` // 为空表示无需上链
+}
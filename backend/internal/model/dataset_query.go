@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// 数据集列表的查询参数（原始值），由 service.NewDatasetQuery 校验并转换为 DatasetQuery
+type DatasetQueryParams struct {
+	Search       string `form:"search"`
+	Q            string `form:"q"` // 搜索接口的关键词参数，与 search 等价
+	Category     string `form:"category"`
+	License      string `form:"license"`
+	AuthorWallet string `form:"authorWalletAddress" binding:"omitempty,eth_addr"`
+	IsFree       *bool  `form:"is_free"`
+	// 范围格式为 min-max，可省略一端（100-、-50），all 表示不限；文件大小支持单位（10MB-1GB），默认字节
+	FileSizeRange string `form:"fileSizeRange"`
+	PriceRange    string `form:"priceRange"`
+	CreatedFrom   string `form:"createdFrom"` // YYYY-MM-DD
+	CreatedTo     string `form:"createdTo"`   // YYYY-MM-DD，包含当天
+	Tags          string `form:"tags"`        // 逗号分隔
+	TagMode       string `form:"tagMode" binding:"omitempty,oneof=and or"`
+}
+
+// 数据集筛选条件（已校验），公开列表、搜索和管理端列表共用；指针字段为 nil 表示不筛选
+type DatasetQuery struct {
+	Text         string // 搜索关键词
+	Category     string
+	License      string
+	AuthorWallet string
+	IsFree       *bool
+	MinSize      *int64 // 文件大小（字节），闭区间
+	MaxSize      *int64
+	MinPrice     *float64 // 价格，闭区间
+	MaxPrice     *float64
+	CreatedFrom  *time.Time // 创建时间下界（含）
+	CreatedTo    *time.Time // 创建时间上界（不含）
+	Tags         []string   // 标签（已规范化）
+	// 为 true 时需包含全部标签（AND），否则包含任一标签（OR）
+	MatchAllTags bool
+	Status       string // 数据集状态，由服务层设置（公开列表只展示已通过校验的数据集）
+}
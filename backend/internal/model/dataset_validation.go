@@ -0,0 +1,46 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 数据集（版本）状态：上传后先校验文件，通过后才对外展示和下载
+const (
+	DatasetStatusValidating = "validating"
+	DatasetStatusReady      = "ready"
+	DatasetStatusRejected   = "rejected"
+)
+
+// 校验任务状态
+const (
+	ValidationJobQueued  = "queued"
+	ValidationJobRunning = "running"
+	ValidationJobDone    = "done"
+	ValidationJobFailed  = "failed"
+)
+
+// DatasetValidation 数据集文件校验任务表结构体
+// 每个版本上传后创建一条，由 worker 流式读取对象，统计记录数、格式和编码问题、字段结构并计算 SHA-256
+// 任务状态与指纹检测任务一致，result 为校验结论（ready / rejected），report 为校验报告
+type DatasetValidation struct {
+	ID               uint            `gorm:"primaryKey" json:"id"`
+	DatasetID        uint            `gorm:"not null;index" json:"datasetId"`
+	VersionID        uint            `gorm:"not null;index" json:"versionId"`
+	Version          int             `gorm:"not null" json:"version"`
+	ObjectName       string          `gorm:"type:varchar(200);not null" json:"objectName"`
+	Format           string          `gorm:"type:varchar(20);not null" json:"format"`
+	DeclaredSize     int64           `gorm:"type:bigint;not null" json:"declaredSize"` // 上传请求中声明的文件大小
+	DeclaredChecksum string          `gorm:"type:char(64)" json:"declaredChecksum"`    // 上传请求中声明的 SHA-256，可为空
+	Status           string          `gorm:"type:enum('queued','running','done','failed');not null;index:idx_validation_status_lease" json:"status"`
+	Progress         uint            `gorm:"type:tinyint unsigned;default:0" json:"progress"`             // 0-100
+	Attempts         int             `gorm:"type:int;not null;default:0" json:"attempts"`                 // 已执行次数
+	Error            string          `gorm:"type:varchar(500)" json:"error"`                              // 失败原因
+	LeaseUntil       *time.Time      `gorm:"type:datetime(3);index:idx_validation_status_lease" json:"-"` // 执行租约
+	Result           string          `gorm:"type:varchar(20)" json:"result"`                              // ready / rejected，完成前为空
+	Report           json.RawMessage `gorm:"type:json" json:"report"`                                     // 校验报告
+	StartedAt        *time.Time      `gorm:"type:datetime(3)" json:"startedAt"`
+	FinishedAt       *time.Time      `gorm:"type:datetime(3)" json:"finishedAt"`
+	CreatedAt        time.Time       `gorm:"autoCreateTime(3)" json:"createdAt"`
+	UpdatedAt        time.Time       `gorm:"autoUpdateTime(3)" json:"updatedAt"`
+}
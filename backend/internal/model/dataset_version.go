@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// 数据集版本表：每个版本对应独立的文件对象，发布后不可修改
+// 版本上传后需通过文件校验（status 为 ready）才能下载，datasets 表的 object_name/file_size/format 与最新的已通过版本一致
+// 预览数据按对象名存于 MongoDB，每个版本各有一份
+type DatasetVersion struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	DatasetID  uint      `gorm:"not null;uniqueIndex:idx_dataset_version,priority:1" json:"datasetId"`
+	Version    int       `gorm:"not null;uniqueIndex:idx_dataset_version,priority:2" json:"version"` // 从 1 开始递增
+	ObjectName string    `gorm:"type:varchar(200);not null;uniqueIndex:idx_version_object" json:"objectName"`
+	FileSize   int64     `gorm:"type:bigint;not null" json:"fileSize"`
+	Format     string    `gorm:"type:varchar(20);not null;default:'jsonl'" json:"format"`
+	Checksum   string    `gorm:"type:char(64);index:idx_version_checksum" json:"checksum"` // 文件 SHA-256（十六进制），未提供时由文件校验计算
+	MD5        string    `gorm:"type:char(32)" json:"md5"`                                 // 文件 MD5（十六进制），由文件校验计算
+	ETag       string    `gorm:"type:varchar(100)" json:"etag"`                            // 校验时 MinIO 对象的 ETag，下载前核对对象未被替换
+	Changelog  string    `gorm:"type:text" json:"changelog"`
+	Status     string    `gorm:"type:varchar(20);not null;default:'ready'" json:"status"` // validating / ready / rejected
+	CreatedAt  time.Time `gorm:"autoCreateTime(3)" json:"createdAt"`
+}
+
+// 文件完整性信息，由文件校验从实际文件计算
+type FileIntegrity struct {
+	Checksum string // SHA-256
+	MD5      string
+	ETag     string // MinIO 对象 ETag，分片上传的对象不是文件 MD5
+}
+
+// 初始版本的默认更新说明
+const InitialVersionChangelog = "初始版本"
+
+// 发布新版本请求体
+type PublishDatasetVersionRequest struct {
+	DatasetID  uint   `json:"datasetId" binding:"required"`
+	ObjectName string `json:"objectName" binding:"required"`
+	FileSize   int64  `json:"fileSize" binding:"required"`
+	Format     string `json:"format"` // 为空时按对象名扩展名推断
+	Checksum   string `json:"checksum" binding:"omitempty,len=64,hexadecimal"`
+	Changelog  string `json:"changelog" binding:"required"`
+}
+
+// 数据集详情响应体，包含全部版本（按版本号倒序）
+type DatasetDetailResponse struct {
+	DatasetListResponse
+	Versions []DatasetVersion `json:"versions"`
+}
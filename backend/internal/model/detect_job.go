@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// 指纹检测任务来源
+const (
+	DetectSourceUpload = "upload" // 管理员上传的文件（暂存到临时桶）
+	DetectSourceMinio  = "minio"  // MinIO 中已有的对象
+)
+
+// 指纹检测任务状态
+const (
+	DetectJobQueued  = "queued"
+	DetectJobRunning = "running"
+	DetectJobDone    = "done"
+	DetectJobFailed  = "failed"
+)
+
+// DetectJob 指纹检测任务表结构体
+// 大文件检测耗时较长，由 worker 异步流式扫描，状态与付费下载任务一致
+type DetectJob struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	AdminID    uint       `gorm:"not null;index" json:"adminId"`
+	Source     string     `gorm:"type:varchar(10);not null" json:"source"`
+	Bucket     string     `gorm:"type:varchar(100);not null" json:"bucket"`
+	ObjectName string     `gorm:"type:varchar(255);not null" json:"objectName"`
+	Filename   string     `gorm:"type:varchar(255);not null" json:"filename"` // 写入检测记录的文件名
+	Format     string     `gorm:"type:varchar(20);not null" json:"format"`
+	FileSize   int64      `gorm:"type:bigint;not null" json:"fileSize"`
+	Status     string     `gorm:"type:enum('queued','running','done','failed');not null;index:idx_detect_status_lease" json:"status"`
+	Progress   uint       `gorm:"type:tinyint unsigned;default:0" json:"progress"`         // 0-100
+	Attempts   int        `gorm:"type:int;not null;default:0" json:"attempts"`             // 已执行次数
+	Error      string     `gorm:"type:varchar(500)" json:"error"`                          // 失败原因
+	LeaseUntil *time.Time `gorm:"type:datetime(3);index:idx_detect_status_lease" json:"-"` // 执行租约
+	RecordID   *int       `json:"recordId"`                                                // 完成后对应的检测记录
+	StartedAt  *time.Time `gorm:"type:datetime(3)" json:"startedAt"`
+	FinishedAt *time.Time `gorm:"type:datetime(3)" json:"finishedAt"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime(3)" json:"createdAt"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime(3)" json:"updatedAt"`
+}
+
+// 创建 MinIO 对象检测任务请求
+type CreateDetectJobRequest struct {
+	Bucket     string `json:"bucket" form:"bucket" binding:"required"`
+	ObjectName string `json:"objectName" form:"objectName" binding:"required"`
+	Format     string `json:"format" form:"format"` // 为空时按对象名扩展名推断
+}
+
+// 检测任务状态响应
+type DetectJobStatusResponse struct {
+	JobID    uint                            `json:"jobId"`
+	State    string                          `json:"state"`    // queued / running / done / failed
+	Progress uint                            `json:"progress"` // 0-100
+	Filename string                          `json:"filename"`
+	Error    string                          `json:"error"`
+	Result   *AdminFingerprintDetectResponse `json:"result,omitempty"`
+}
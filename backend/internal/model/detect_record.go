@@ -1,25 +1,142 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+// 检测状态
+const (
+	DetectStatusNoFingerprint = "no_fingerprint" // 未检测到指纹
+	DetectStatusUnknown       = "unknown"        // 检测到指纹但未找到对应交易
+	DetectStatusMatched       = "matched"        // 唯一匹配到一笔交易
+	DetectStatusConflict      = "conflict"       // 匹配到多笔交易（v1 指纹同一秒内多笔购买）
+)
 
 type DetectRecord struct {
-	ID              int       `json:"id" gorm:"primaryKey"`
-	DatasetID       *int      `json:"datasetID" gorm:"size:255;index:idx_dataset_id"`
-	Filename        string    `json:"filename" gorm:"size:255;index:idx_filename;not null"`
-	FileSize        int64     `json:"fileSize" gorm:"size:255"`
-	DetectionTime   string    `json:"detectionTime" gorm:"size:20"`
-	Timestamp       *string   `json:"timestamp" gorm:"size:255"`
-	DetectionResult string    `json:"detectionResult" gorm:"size:255;index:idx_detection_result;not null"`
-	CreatedAt       time.Time `gorm:"autoCreateTime(3)" json:"createdAt"`
+	ID              int        `json:"id" gorm:"primaryKey"`
+	DatasetID       *int       `json:"datasetID" gorm:"size:255;index:idx_dataset_id"`
+	Filename        string     `json:"filename" gorm:"size:255;index:idx_filename;not null"`
+	FileSize        int64      `json:"fileSize" gorm:"size:255"`
+	DetectionTime   string     `json:"detectionTime" gorm:"size:20"`
+	Timestamp       *string    `json:"timestamp" gorm:"size:255"`
+	Version         int        `json:"version" gorm:"type:tinyint;default:0"` // 指纹版本，0=未检测到
+	TransactionID   *uint      `json:"transactionId" gorm:"index"`            // v2 指纹中的购买 ID
+	Confidence      float64    `json:"confidence" gorm:"type:decimal(5,4)"`   // 多数投票的得票占比
+	DetectionResult string     `json:"detectionResult" gorm:"size:255;index:idx_detection_result;not null"`
+	Status          string     `json:"status" gorm:"size:20;index:idx_detect_status"`
+	BuyerUserID     *uint      `json:"buyerUserId" gorm:"index:idx_buyer_user"`
+	BuyerWallet     string     `json:"buyerWallet" gorm:"size:42;index:idx_buyer_wallet"`
+	TxHash          string     `json:"txHash" gorm:"size:100"`
+	PurchasedAt     *time.Time `json:"purchasedAt"`
+	Copies          int        `json:"copies"`                   // 文件中找到的指纹份数（各符号最少得票数）
+	Candidates      string     `json:"-" gorm:"type:text"`       // 候选交易列表（JSON）
+	ReportJSON      string     `json:"-" gorm:"type:mediumtext"` // 检测时生成的匹配报告（JSON），导出时原样输出
+	ReportDigest    string     `json:"-" gorm:"size:64"`         // 匹配报告 JSON 的 SHA-256 摘要
+	CreatedAt       time.Time  `gorm:"autoCreateTime(3)" json:"createdAt"`
+}
+
+// 候选交易
+type DetectCandidate struct {
+	TransactionID uint       `json:"transactionId"`
+	DatasetID     uint       `json:"datasetId"`
+	BuyerUserID   uint       `json:"buyerUserId"`
+	BuyerWallet   string     `json:"buyerWallet"`
+	TxHash        string     `json:"txHash"`
+	PurchasedAt   *time.Time `json:"purchasedAt"`
+}
+
+// 指纹匹配报告：将泄露文件关联到买家、交易和购买时间，可导出作为证据
+type DetectReport struct {
+	RecordID      int               `json:"recordId"`
+	Filename      string            `json:"filename"`
+	FileSize      int64             `json:"fileSize"`
+	DetectedAt    time.Time         `json:"detectedAt"`
+	DetectionTime string            `json:"detectionTime"`
+	Status        string            `json:"status"`
+	Version       int               `json:"version"`
+	Timestamp     string            `json:"timestamp,omitempty"` // v1 指纹中的区块时间戳
+	DatasetID     uint              `json:"datasetId"`
+	DatasetTitle  string            `json:"datasetTitle,omitempty"`
+	BuyerUserID   uint              `json:"buyerUserId"`
+	BuyerWallet   string            `json:"buyerWallet"`
+	TransactionID uint              `json:"transactionId"`
+	TxHash        string            `json:"txHash"`
+	PurchasedAt   *time.Time        `json:"purchasedAt"`
+	Confidence    float64           `json:"confidence"`
+	Copies        int               `json:"copies"`
+	Candidates    []DetectCandidate `json:"candidates"`
+}
+
+// 转换为匹配报告
+func (r *DetectRecord) ToReport() DetectReport {
+	report := DetectReport{
+		RecordID:      r.ID,
+		Filename:      r.Filename,
+		FileSize:      r.FileSize,
+		DetectedAt:    r.CreatedAt,
+		DetectionTime: r.DetectionTime,
+		Status:        r.Status,
+		Version:       r.Version,
+		BuyerWallet:   r.BuyerWallet,
+		TxHash:        r.TxHash,
+		PurchasedAt:   r.PurchasedAt,
+		Confidence:    r.Confidence,
+		Copies:        r.Copies,
+		Candidates:    []DetectCandidate{},
+	}
+	if r.Timestamp != nil {
+		report.Timestamp = *r.Timestamp
+	}
+	if r.DatasetID != nil {
+		report.DatasetID = uint(*r.DatasetID)
+	}
+	if r.BuyerUserID != nil {
+		report.BuyerUserID = *r.BuyerUserID
+	}
+	if r.TransactionID != nil {
+		report.TransactionID = *r.TransactionID
+	}
+	if r.Candidates != "" {
+		_ = json.Unmarshal([]byte(r.Candidates), &report.Candidates)
+	}
+	return report
 }
 
 // 检测记录响应体
 type DetectRecordResponse struct {
-	DatasetID       int    `json:"datasetID"`
-	Filename        string `json:"filename"`
-	FileSize        uint64 `json:"fileSize"`
-	Timestamp       string `json:"timestamp"`
-	DetectionTime   string `json:"detectionTime"`
-	DetectionResult string `json:"detectionResult"`
-	CreatedAt       string `json:"createdAt"`
+	ID              int        `json:"id"`
+	DatasetID       int        `json:"datasetID"`
+	Filename        string     `json:"filename"`
+	FileSize        uint64     `json:"fileSize"`
+	Timestamp       string     `json:"timestamp"`
+	Version         int        `json:"version"`
+	Status          string     `json:"status"`
+	TransactionID   uint       `json:"transactionId"`
+	BuyerUserID     uint       `json:"buyerUserId"`
+	BuyerWallet     string     `json:"buyerWallet"`
+	TxHash          string     `json:"txHash"`
+	PurchasedAt     *time.Time `json:"purchasedAt"`
+	Confidence      float64    `json:"confidence"`
+	Copies          int        `json:"copies"`
+	DetectionTime   string     `json:"detectionTime"`
+	DetectionResult string     `json:"detectionResult"`
+	CreatedAt       string     `json:"createdAt"`
+}
+
+// 检测记录筛选条件
+type DetectRecordQuery struct {
+	Page          int     `form:"page"`
+	Limit         int     `form:"limit"`
+	Status        string  `form:"status" binding:"omitempty,oneof=no_fingerprint unknown matched conflict"`
+	DatasetID     uint    `form:"datasetId"`
+	TransactionID uint    `form:"transactionId"`
+	BuyerWallet   string  `form:"buyerWallet"`
+	BuyerUserID   uint    `form:"buyerUserId"`
+	MinConfidence float64 `form:"minConfidence" binding:"omitempty,min=0,max=1"`
+	StartDate     string  `form:"startDate"` // YYYY-MM-DD
+	EndDate       string  `form:"endDate"`   // YYYY-MM-DD，包含当天
+	// 由服务层根据 StartDate、EndDate 解析，nil 表示不筛选
+	CreatedFrom *time.Time `form:"-"` // 检测时间下界（含）
+	CreatedTo   *time.Time `form:"-"` // 检测时间上界（不含）
 }
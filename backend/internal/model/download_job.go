@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// 付费下载任务状态
+const (
+	DownloadJobQueued  = "queued"
+	DownloadJobRunning = "running"
+	DownloadJobDone    = "done"
+	DownloadJobFailed  = "failed"
+)
+
+// DownloadJob 付费下载任务表结构体
+// 记录"插入指纹并拷贝到临时桶"任务的状态，进程重启后由 worker 重新认领
+type DownloadJob struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"not null;index:idx_user_dataset" json:"userId"`
+	DatasetID    uint       `gorm:"not null;index:idx_user_dataset" json:"datasetId"`
+	Version      int        `gorm:"not null;default:1" json:"version"`      // 下载的数据集版本
+	ActiveKey    *string    `gorm:"type:varchar(100);uniqueIndex" json:"-"` // 排队或执行中时为 paid_download_task_id:<user>-<dataset>-v<version>，结束后置空，保证同一时间只有一个任务
+	SourceObject string     `gorm:"type:varchar(200);not null" json:"-"`    // 数据集桶中的源文件
+	SourceETag   string     `gorm:"type:varchar(100)" json:"-"`             // 源文件校验时的 ETag，读取时要求一致，历史版本为空
+	SourceSHA256 string     `gorm:"type:char(64)" json:"-"`                 // 源文件的 SHA-256，读完后核对，历史版本为空
+	TempObject   string     `gorm:"type:varchar(200);not null" json:"-"`    // 临时桶中带指纹的文件，按买家和任务命名
+	FileSize     int64      `gorm:"type:bigint;not null" json:"fileSize"`   // 源文件大小，用于计算进度
+	Fingerprint  string     `gorm:"type:varchar(100);not null" json:"-"`    // 写入文件的指纹内容
+	Format       string     `gorm:"type:varchar(20)" json:"-"`              // 数据集格式，决定指纹写入方式
+	Checksum     string     `gorm:"type:char(64)" json:"checksum"`          // 带指纹文件（买家下载的文件）的 SHA-256，完成后写入
+	Status       string     `gorm:"type:enum('queued','running','done','failed');not null;index:idx_status_lease" json:"status"`
+	Progress     uint       `gorm:"type:tinyint unsigned;default:0" json:"progress"`  // 0-100
+	Attempts     int        `gorm:"type:int;not null;default:0" json:"attempts"`      // 已执行次数
+	Error        string     `gorm:"type:varchar(500)" json:"error"`                   // 失败原因
+	LeaseUntil   *time.Time `gorm:"type:datetime(3);index:idx_status_lease" json:"-"` // 执行租约，超时未续约视为 worker 已退出
+	StartedAt    *time.Time `gorm:"type:datetime(3)" json:"startedAt"`
+	FinishedAt   *time.Time `gorm:"type:datetime(3)" json:"finishedAt"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime(3)" json:"createdAt"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime(3)" json:"updatedAt"`
+}
+
+// 下载任务状态响应
+type DownloadStatusResponse struct {
+	JobID    uint   `json:"jobId"`
+	State    string `json:"state"`    // queued / running / done / failed
+	Progress uint   `json:"progress"` // 0-100
+	Status   uint   `json:"status"`   // 1=已完成，兼容旧前端
+	URL      string `json:"url"`
+	Checksum string `json:"checksum"` // 下载文件的 SHA-256，完成后才有；文件带有买家指纹，与数据集原文件不同
+	Error    string `json:"error"`
+}
+
+// 免费数据集下载链接响应，附带文件校验值供用户核对下载结果
+type DownloadURLResponse struct {
+	URL      string `json:"url"`
+	Version  int    `json:"version"`
+	FileSize int64  `json:"fileSize"`
+	Checksum string `json:"checksum"` // SHA-256，历史版本可能为空
+	MD5      string `json:"md5"`
+}
@@ -1,12 +1,73 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 type Outbox struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	EventType string    `json:"eventType" gorm:"type:varchar(100);index:idx_type_payload,not null"`
-	Payload   string    `json:"payload" gorm:"type:varchar(255);index:idx_type_payload,not null"`
-	Status    string    `json:"status" gorm:"type:enum('pending', 'done', 'failed');not null"`
-	CreatedAt time.Time `json:"createdAt" gorm:"type:timestamp;not null"`
-	UpdatedAt time.Time `json:"updatedAt" gorm:"type:timestamp;not null"`
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	EventType string     `json:"eventType" gorm:"type:varchar(100);index:idx_type_payload,not null;index:idx_type_key"`
+	TaskKey   string     `json:"taskKey" gorm:"type:varchar(200);index:idx_type_key"` // 业务键（如 objectName），用于按业务查找/取消任务
+	Payload   string     `json:"payload" gorm:"type:varchar(255);index:idx_type_payload,not null"`
+	Status    string     `json:"status" gorm:"type:enum('pending', 'done', 'failed');not null;index:idx_status_next_run"`
+	Attempts  int        `json:"attempts" gorm:"type:int;not null;default:0"`                 // 已执行次数
+	NextRunAt *time.Time `json:"nextRunAt" gorm:"type:datetime(3);index:idx_status_next_run"` // 下次执行时间，为空表示立即执行
+	LastError string     `json:"lastError" gorm:"type:varchar(500)"`                          // 最近一次失败原因
+	CreatedAt time.Time  `json:"createdAt" gorm:"type:timestamp;not null"`
+	UpdatedAt time.Time  `json:"updatedAt" gorm:"type:timestamp;not null"`
+}
+
+// 异步任务类型
+const (
+	OutboxDeleteMongoPreview = "delete_mongo_preview"
+	OutboxDeleteMinioObject  = "delete_minio_object"
+)
+
+// 异步任务载荷，每种任务类型对应一个结构体
+type OutboxPayload interface {
+	Validate() error
+	Key() string // 业务键，写入 task_key
+}
+
+// 删除预览数据 / 删除数据集文件 的载荷
+type ObjectNamePayload struct {
+	ObjectName string `json:"objectName"`
+}
+
+func (p ObjectNamePayload) Validate() error {
+	if p.ObjectName == "" {
+		return errors.New("objectName 不能为空")
+	}
+	if strings.Contains(p.ObjectName, "/") {
+		return fmt.Errorf("非法的 objectName: %s", p.ObjectName)
+	}
+	return nil
+}
+
+func (p ObjectNamePayload) Key() string {
+	return p.ObjectName
+}
+
+// 创建待处理任务，载荷经校验后以 JSON 存储
+func NewOutbox(eventType string, payload OutboxPayload) (*Outbox, error) {
+	if err := payload.Validate(); err != nil {
+		return nil, fmt.Errorf("任务 %s 载荷校验失败: %w", eventType, err)
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > 255 {
+		return nil, fmt.Errorf("任务 %s 载荷过长: %d", eventType, len(b))
+	}
+	return &Outbox{
+		EventType: eventType,
+		TaskKey:   payload.Key(),
+		Payload:   string(b),
+		Status:    "pending",
+	}, nil
 }
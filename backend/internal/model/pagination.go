@@ -0,0 +1,38 @@
+package model
+
+// 数据集列表排序方式
+const (
+	SortNewest    = "newest"     // 创建时间倒序
+	SortDownloads = "downloads"  // 下载量倒序
+	SortPriceAsc  = "price_asc"  // 价格升序
+	SortPriceDesc = "price_desc" // 价格倒序
+	SortSize      = "size"       // 文件大小倒序
+	SortRelevance = "relevance"  // 搜索相关度，仅在有关键词时可用，按页码翻页且限制深度
+)
+
+// 分页游标：记录上一页最后一条的排序字段值和 ID，下一页从其之后开始（键集分页）
+// 相关度排序由搜索索引计算，无法按键定位，改为记录页码
+type PageCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    uint   `json:"i,omitempty"`
+	Page  int    `json:"p,omitempty"`
+}
+
+// 列表分页参数
+type PageQuery struct {
+	Sort   string
+	Cursor *PageCursor // 游标模式，nil 表示第一页
+	// 页码模式（兼容旧接口），大于 0 时按 OFFSET 分页
+	Page  int
+	Limit int
+	// 是否统计总数（额外一次 COUNT 查询）
+	WithTotal bool
+}
+
+// 分页结果
+type PageInfo struct {
+	NextCursor string // 下一页游标，没有更多数据时为空
+	HasMore    bool
+	Total      *int64 // 未请求总数时为 nil
+}
@@ -0,0 +1,73 @@
+package model
+
+// 文件大小分档（用于搜索分面统计）
+const (
+	SizeBucketSmall  = "small"  // < 10MB
+	SizeBucketMedium = "medium" // 10MB ~ 100MB
+	SizeBucketLarge  = "large"  // 100MB ~ 1GB
+	SizeBucketHuge   = "huge"   // >= 1GB
+
+	sizeBucketMediumMin = 10 << 20
+	sizeBucketLargeMin  = 100 << 20
+	sizeBucketHugeMin   = 1 << 30
+)
+
+// 文件大小分档的下界，依次对应 small/medium/large/huge
+var SizeBucketBounds = []int64{0, sizeBucketMediumMin, sizeBucketLargeMin, sizeBucketHugeMin}
+
+var SizeBuckets = []string{SizeBucketSmall, SizeBucketMedium, SizeBucketLarge, SizeBucketHuge}
+
+// 文件大小所在的分档
+func SizeBucket(fileSize int64) string {
+	for i := len(SizeBucketBounds) - 1; i > 0; i-- {
+		if fileSize >= SizeBucketBounds[i] {
+			return SizeBuckets[i]
+		}
+	}
+	return SizeBucketSmall
+}
+
+// 数据集搜索条件：筛选条件和当前页，Text 为空时按创建时间倒序
+type DatasetSearchQuery struct {
+	DatasetQuery
+	Page  int
+	Limit int
+}
+
+// 单条命中：数据集 ID、相关度得分和高亮片段（字段名 -> 已转义的 HTML，命中词用 <mark> 包裹）
+type SearchHit struct {
+	ID         uint              `json:"id"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// 分面统计：各取值的命中数量
+type SearchFacets struct {
+	Category   map[string]int64 `json:"category"`
+	License    map[string]int64 `json:"license"`
+	Pricing    map[string]int64 `json:"pricing"`    // free / paid
+	SizeBucket map[string]int64 `json:"sizeBucket"` // small / medium / large / huge
+}
+
+func NewSearchFacets() SearchFacets {
+	return SearchFacets{
+		Category:   make(map[string]int64),
+		License:    make(map[string]int64),
+		Pricing:    make(map[string]int64),
+		SizeBucket: make(map[string]int64),
+	}
+}
+
+// 搜索结果（按相关度排序的当前页）
+type SearchResult struct {
+	Hits   []SearchHit
+	Total  int64
+	Facets SearchFacets
+}
+
+// 数据集搜索结果项
+type DatasetSearchItem struct {
+	DatasetListResponse
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
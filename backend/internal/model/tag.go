@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Tag 标签表结构体，名称统一为小写，全局唯一
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_tag_name" json:"name"`
+	CreatedAt time.Time `gorm:"autoCreateTime(3)" json:"createdAt"`
+}
+
+// DatasetTag 数据集与标签的关联表
+type DatasetTag struct {
+	DatasetID uint      `gorm:"primaryKey" json:"datasetId"`
+	TagID     uint      `gorm:"primaryKey;index:idx_tag_dataset" json:"tagId"`
+	CreatedAt time.Time `gorm:"autoCreateTime(3)" json:"createdAt"`
+}
+
+// 标签及其关联的（未删除）数据集数量
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 分片上传会话状态
+const (
+	UploadSessionUploading = "uploading" // 上传中，可续传
+	UploadSessionCompleted = "completed" // 分片已合并，等待创建数据集或发布版本
+	UploadSessionUsed      = "used"      // 文件已被数据集或版本使用
+	UploadSessionAborted   = "aborted"   // 卖家取消
+	UploadSessionExpired   = "expired"   // 长时间未完成，已由清理任务中断
+	UploadSessionDeleting  = "deleting"  // 合并后长时间未使用，清理任务正在删除文件，不能再被使用
+	UploadSessionDeleted   = "deleted"   // 合并后长时间未使用，文件已由清理任务删除
+)
+
+// 分片上传会话表：记录上传者、对象名、MinIO uploadId、文件大小、分片大小和已上传分片，
+// 用于换浏览器续传和清理被放弃的上传
+type UploadSession struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	UserID        uint            `gorm:"not null;index" json:"userId"`
+	ObjectName    string          `gorm:"type:varchar(200);not null;uniqueIndex" json:"objectName"`
+	UploadID      string          `gorm:"type:varchar(200);not null;uniqueIndex" json:"uploadId"`
+	FileName      string          `gorm:"type:varchar(255);not null" json:"fileName"`
+	ExpectedSize  int64           `gorm:"type:bigint;not null" json:"expectedSize"` // 文件大小（字节）
+	PartSize      int64           `gorm:"type:bigint;not null" json:"partSize"`     // 分片大小（字节），最后一片可以更小
+	TotalParts    int             `gorm:"type:int;not null" json:"totalParts"`
+	UploadedParts json.RawMessage `gorm:"type:json" json:"uploadedParts"`             // 最近一次从 MinIO 查询到的已上传分片 [{partNumber, etag, size}]
+	UploadedBytes int64           `gorm:"type:bigint;default:0" json:"uploadedBytes"` // 已上传分片的总字节数
+	Status        string          `gorm:"type:enum('uploading','completed','used','aborted','expired','deleting','deleted');not null;index:idx_upload_session_status_expires" json:"status"`
+	ExpiresAt     time.Time       `gorm:"type:datetime(3);not null;index:idx_upload_session_status_expires" json:"expiresAt"` // 上传中：超过该时间未完成则中断；已合并：超过该时间未使用则删除文件
+	CompletedAt   *time.Time      `gorm:"type:datetime(3)" json:"completedAt"`
+	CreatedAt     time.Time       `gorm:"autoCreateTime(3)" json:"createdAt"`
+	UpdatedAt     time.Time       `gorm:"autoUpdateTime(3)" json:"updatedAt"`
+}
+
+// 已上传分片
+type UploadedPart struct {
+	PartNumber int    `json:"partNumber"`
+	ETag       string `json:"etag"`
+	Size       int64  `json:"size"`
+}
+
+// 初始化分片上传请求体，partSize 为空时使用默认分片大小
+type InitMultipartUploadRequest struct {
+	FileName string `json:"fileName" binding:"required,max=255"`
+	FileSize int64  `json:"fileSize" binding:"required,min=1"`
+	PartSize int64  `json:"partSize" binding:"omitempty,min=1"`
+}
+
+// 续传查询结果：分片以 MinIO 记录为准，missingParts 为尚未上传的分片号
+type UploadSessionResponse struct {
+	*UploadSession
+	Parts        []UploadedPart `json:"parts"`
+	MissingParts []int          `json:"missingParts"`
+}
@@ -66,6 +66,27 @@ type AuthorStatsResponse struct {
 	TotalRevenue   float64 `json:"totalRevenue"`
 }
 
+// 权限
+type Permission struct {
+	Action     string                 `json:"action"`
+	Resource   string                 `json:"resource"`
+	Conditions map[string]interface{} `json:"conditions,omitempty"`
+}
+
+// 权限校验请求体
+type CheckPermissionRequest struct {
+	Action     string                 `json:"action" binding:"required"`
+	Resource   string                 `json:"resource" binding:"required"`
+	Conditions map[string]interface{} `json:"conditions"`
+}
+
+// 角色信息响应
+type RoleInfoResponse struct {
+	Role        string       `json:"role"`
+	DisplayName string       `json:"displayName"`
+	Permissions []Permission `json:"permissions"`
+}
+
 // 转换为响应格式
 func (u *User) ToResponse() UserResponse {
 	return UserResponse{
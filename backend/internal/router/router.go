@@ -4,10 +4,12 @@ import (
 	"backend/internal/config"
 	"backend/internal/controller"
 	"backend/internal/dao"
+	"backend/internal/dao/chain"
 	"backend/internal/dao/minio"
 	"backend/internal/dao/mongo"
 	"backend/internal/dao/mysql"
 	"backend/internal/dao/redis"
+	"backend/internal/dao/search"
 	"backend/internal/middleware"
 	"backend/internal/service"
 	"backend/internal/util"
@@ -17,7 +19,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func InitRouter(r *gin.Engine, repo *dao.Repository, cfg *config.Config) {
+// searchIndex 由 main 创建，与链上事件同步等后台任务共用
+func InitRouter(r *gin.Engine, repo *dao.Repository, cfg *config.Config, searchIndex search.SearchIndex) {
 
 	// 健康检查
 	r.GET("/health", func(c *gin.Context) {
@@ -32,19 +35,34 @@ func InitRouter(r *gin.Engine, repo *dao.Repository, cfg *config.Config) {
 	walletService := service.NewWalletService(mysql.NewUserDAO(repo.MySQL), redis.NewUserRedisDAO(repo.Redis), mysql.NewWalletChangeDAO(repo.MySQL))
 	walletController := controller.NewWalletController(walletService)
 
+	// 链上交易校验（未配置节点时为 nil）
+	var chainVerifier service.ChainVerifier
+	var purchaseChecker service.PurchaseChecker
+	if cfg.Chain.RPCURL != "" {
+		contractDAO := chain.NewContractDAO(chain.NewRPCClient(cfg.Chain.RPCURL), cfg.Chain.ContractAddress)
+		chainVerifier = contractDAO
+		// 付费下载时额外校验链上 hasPurchased（可选）
+		if cfg.Chain.VerifyPurchase {
+			purchaseChecker = contractDAO
+		}
+	}
+
 	// 数据集管理
 	datasetService := service.NewDatasetService(mysql.NewDatasetDAO(repo.MySQL), mysql.NewOutboxDAO(repo.MySQL), mongo.NewDatasetsPreviewDAO(repo.Mongo),
 		redis.NewDatasetRedisDAO(repo.Redis), redis.NewRankRedisDAO(repo.Redis), minio.NewDatasetMinioDAO(repo.MinIO, repo.MinIOCore, cfg.MinIO.Buckets[util.DATASET_BUCKET], cfg.MinIO.Buckets[util.DATASET_TEMP_BUCKET]),
-		mysql.NewUserStatsDAO(repo.MySQL), repo.MySQL)
-	datasetController := controller.NewDatasetController(datasetService)
+		mysql.NewUserStatsDAO(repo.MySQL), mysql.NewDownloadJobDAO(repo.MySQL), mysql.NewTagDAO(repo.MySQL), mysql.NewDatasetValidationDAO(repo.MySQL), mysql.NewUploadSessionDAO(repo.MySQL), purchaseChecker, searchIndex, repo.MySQL)
+	tagService := service.NewTagService(mysql.NewTagDAO(repo.MySQL), repo.MySQL)
+	datasetController := controller.NewDatasetController(datasetService, tagService)
 
 	// 交易记录管理
-	transactionService := service.NewTransactionService(mysql.NewTransactionDAO(repo.MySQL), mysql.NewUserStatsDAO(repo.MySQL), repo.MySQL)
+	transactionService := service.NewTransactionService(mysql.NewTransactionDAO(repo.MySQL), mysql.NewUserStatsDAO(repo.MySQL), chainVerifier, cfg.Chain.Indexer.Confirmations, repo.MySQL)
 	transactionController := controller.NewTransactionController(transactionService)
 
 	// 管理员
-	adminService := service.NewAdminService(mysql.NewAdminDAO(repo.MySQL), mysql.NewOutboxDAO(repo.MySQL), mongo.NewDatasetsPreviewDAO(repo.Mongo), minio.NewAdminMinioDAO(repo.MinIO, cfg.MinIO.Buckets[util.DATASET_BUCKET]), repo.MySQL)
-	adminController := controller.NewAdminController(adminService)
+	adminService := service.NewAdminService(mysql.NewAdminDAO(repo.MySQL), mysql.NewOutboxDAO(repo.MySQL), mongo.NewDatasetsPreviewDAO(repo.Mongo), minio.NewAdminMinioDAO(repo.MinIO, cfg.MinIO.Buckets[util.DATASET_BUCKET]), mysql.NewDetectJobDAO(repo.MySQL), searchIndex, repo.MySQL)
+	outboxService := service.NewOutboxService(mysql.NewOutboxDAO(repo.MySQL), mongo.NewDatasetsPreviewDAO(repo.Mongo), minio.NewAdminMinioDAO(repo.MinIO, cfg.MinIO.Buckets[util.DATASET_BUCKET]),
+		time.Duration(cfg.Outbox.Interval)*time.Second, cfg.Outbox.BatchSize, cfg.Outbox.MaxAttempts, time.Duration(cfg.Outbox.BaseBackoff)*time.Second)
+	adminController := controller.NewAdminController(adminService, outboxService)
 
 	// 首页公共路由（无需鉴权）
 	homeService := service.NewHomeService(mysql.NewHomeDAO(repo.MySQL), redis.NewRankRedisDAO(repo.Redis))
@@ -63,13 +81,13 @@ func InitRouter(r *gin.Engine, repo *dao.Repository, cfg *config.Config) {
 		SetupWalletRouter(api, walletController)
 
 		// 数据集管理
-		SetupDatasetRouter(api, datasetController)
+		SetupDatasetRouter(api, datasetController, authService, cfg.Download.Streaming)
 
 		// 交易记录管理
 		SetupTransactionRouter(api, transactionController)
 
 		// 管理员
-		SetupAdminRouter(api, adminController)
+		SetupAdminRouter(api, adminController, authService)
 	}
 
 	// 定时清理临时桶
@@ -136,8 +154,8 @@ func SetupAuthRouter(api *gin.RouterGroup, authController *controller.AuthContro
 	// 角色权限
 	roles := api.Group("/roles").Use(middleware.AuthMiddleware())
 	{
-		roles.POST("/check-permission")
-		roles.GET("/info")
+		roles.POST("/check-permission", authController.CheckPermission) // 校验当前用户权限
+		roles.GET("/info", authController.GetRoleInfo)                  // 获取当前用户角色信息
 	}
 }
 
@@ -151,26 +169,50 @@ func SetupWalletRouter(api *gin.RouterGroup, walletController *controller.Wallet
 	}
 }
 
-func SetupDatasetRouter(api *gin.RouterGroup, datasetController *controller.DatasetController) {
+func SetupDatasetRouter(api *gin.RouterGroup, datasetController *controller.DatasetController, roleGetter middleware.RoleGetter, streaming bool) {
 	dataset := api.Group("/dataset")
 	dataset.GET("/list", datasetController.ListDatasets)                    // 获取数据集列表
-	dataset.GET("/detail", datasetController.GetDatasetDetail)              // 获取数据集详情
+	dataset.GET("/search", datasetController.SearchDatasets)                // 全文搜索（相关度排序、分面统计、高亮）
+	dataset.GET("/tags/popular", datasetController.GetPopularTags)          // 热门标签
+	dataset.GET("/tags/autocomplete", datasetController.AutocompleteTags)   // 标签前缀补全
 	dataset.GET("/preview", datasetController.GetPreviewData)               // 获取预览数据
 	dataset.GET("/paid-by-author", datasetController.GetAuthorPaidDatasets) // 作者的付费数据集
+
+	// 获取数据集详情（公开接口，登录用户为作者本人时返回全部版本）
+	dataset.GET("/detail", middleware.OptionalAuthMiddleware(), datasetController.GetDatasetDetail)
+
 	// 数据集相关路由
 	datasetGroup := dataset.Group("").Use(middleware.AuthMiddleware())
 	{
-		datasetGroup.POST("/upload-preview", datasetController.UploadPreview)               // 上传预览数据
-		datasetGroup.POST("/init-multipart", datasetController.InitMultipartUpload)         // 初始化分片上传
-		datasetGroup.POST("/get-part-url", datasetController.GetPartUploadURL)              // 获取某个分片的上传 URL
-		datasetGroup.POST("/complete-multipart", datasetController.CompleteMultipartUpload) // 完成分片上传
-		datasetGroup.POST("/abort-multipart", datasetController.AbortMultipartUpload)       // 中断分片上传
-		datasetGroup.POST("/upload-dataset", datasetController.UploadDataset)               // 上传数据集
-		datasetGroup.POST("/delete-dataset", datasetController.DeleteDataset)               // 删除数据集和文件
-		datasetGroup.GET("/download-free", datasetController.DownloadFreeDataset)           // 下载免费数据集
-		datasetGroup.GET("/download-paid", datasetController.DownloadPaidDataset)           // 下载付费数据集
-		datasetGroup.GET("/download-status", datasetController.GetDownloadStatus)           // 下载状态
-		datasetGroup.GET("/datasets-by-author", datasetController.GetAuthorDatasets)        // 作者的数据集
+		datasetGroup.GET("/download-free", datasetController.DownloadFreeDataset)    // 下载免费数据集
+		datasetGroup.GET("/download-paid", datasetController.DownloadPaidDataset)    // 下载付费数据集
+		datasetGroup.GET("/download-status", datasetController.GetDownloadStatus)    // 下载状态
+		datasetGroup.POST("/download-cancel", datasetController.CancelDownloadJob)   // 取消下载任务
+		datasetGroup.GET("/datasets-by-author", datasetController.GetAuthorDatasets) // 作者的数据集
+		if streaming {
+			datasetGroup.GET("/download-paid-stream", datasetController.StreamPaidDataset) // 流式下载付费数据集（不经过临时桶）
+		}
+	}
+
+	// 上传相关路由（仅商家）
+	sellerGroup := dataset.Group("").Use(middleware.AuthMiddleware(), middleware.RequireRole(roleGetter, util.ROLE_SELLER))
+	{
+		sellerGroup.POST("/upload-preview", datasetController.UploadPreview)               // 上传预览数据
+		sellerGroup.POST("/init-multipart", datasetController.InitMultipartUpload)         // 初始化分片上传
+		sellerGroup.POST("/get-part-url", datasetController.GetPartUploadURL)              // 获取某个分片的上传 URL
+		sellerGroup.POST("/complete-multipart", datasetController.CompleteMultipartUpload) // 完成分片上传
+		sellerGroup.POST("/abort-multipart", datasetController.AbortMultipartUpload)       // 中断分片上传
+		sellerGroup.POST("/resume-multipart", datasetController.ResumeMultipartUpload)     // 续传：查询上传会话和已上传分片
+		sellerGroup.POST("/upload-dataset", datasetController.UploadDataset)               // 上传数据集
+		sellerGroup.POST("/publish-version", datasetController.PublishVersion)             // 发布数据集新版本
+		sellerGroup.PUT("/:id", datasetController.UpdateDataset)                           // 修改数据集元数据
+		sellerGroup.GET("/validation", datasetController.GetValidationReport)              // 查询版本文件校验报告
+	}
+
+	// 删除相关路由（商家、管理员）
+	deleteGroup := dataset.Group("").Use(middleware.AuthMiddleware(), middleware.RequireRole(roleGetter, util.ROLE_SELLER, util.ROLE_ADMIN))
+	{
+		deleteGroup.POST("/delete-dataset", datasetController.DeleteDataset) // 删除数据集和文件
 	}
 }
 
@@ -185,8 +227,8 @@ func SetupTransactionRouter(api *gin.RouterGroup, transactionController *control
 	}
 }
 
-func SetupAdminRouter(api *gin.RouterGroup, adminController *controller.AdminController) {
-	admin := api.Group("/admin").Use(middleware.AuthMiddleware())
+func SetupAdminRouter(api *gin.RouterGroup, adminController *controller.AdminController, roleGetter middleware.RoleGetter) {
+	admin := api.Group("/admin").Use(middleware.AuthMiddleware(), middleware.RequireRole(roleGetter, util.ROLE_ADMIN))
 	{
 		admin.GET("/stats", adminController.GetStats)                            // 获取统计数据
 		admin.GET("/dashboard-analytics", adminController.GetDashboardAnalytics) // 获取仪表盘数据
@@ -200,12 +242,22 @@ func SetupAdminRouter(api *gin.RouterGroup, adminController *controller.AdminCon
 		admin.DELETE("/datasets/:datasetId", adminController.DeleteDataset)       // 删除数据集
 		admin.PUT("/datasets/restore/:datasetId", adminController.RestoreDataset) // 恢复删除的数据集
 
-		admin.POST("/fingerprint/detect", adminController.FingerprintDetect) // 指纹检测
-		admin.GET("/fingerprint/records", adminController.GetDetectRecords)  // 获取指纹检测记录
+		admin.GET("/duplicates", adminController.GetDuplicates)                        // 获取重复文件记录
+		admin.POST("/duplicates/:duplicateId/review", adminController.ReviewDuplicate) // 审核重复文件（confirm/dismiss）
+
+		admin.POST("/fingerprint/detect", adminController.FingerprintDetect)                   // 指纹检测
+		admin.GET("/fingerprint/records", adminController.GetDetectRecords)                    // 获取指纹检测记录
+		admin.GET("/fingerprint/records/:recordId/report", adminController.ExportDetectReport) // 导出指纹匹配报告（json/html）
+		admin.POST("/fingerprint/detect-jobs", adminController.CreateDetectJob)                // 创建异步检测任务（上传文件或 MinIO 对象）
+		admin.GET("/fingerprint/detect-jobs/:jobId", adminController.GetDetectJob)             // 检测任务状态
+		admin.POST("/fingerprint/detect-jobs/:jobId/cancel", adminController.CancelDetectJob)  // 取消检测任务
 
-		admin.GET("/minio/buckets", adminController.GetMinioBuckets)      // 获取 MinIO 桶列表
-		admin.GET("/minio/objects", adminController.GetMinioObjects)      // 获取 MinIO 桶中的对象列表
-		admin.DELETE("/minio/object", adminController.DeleteMinioObject)  // 删除 MinIO 桶中的对象
-		admin.POST("/outbox/process", adminController.ProcessOutboxTasks) // 处理异步删除任务
+		admin.GET("/minio/buckets", adminController.GetMinioBuckets)         // 获取 MinIO 桶列表
+		admin.GET("/minio/objects", adminController.GetMinioObjects)         // 获取 MinIO 桶中的对象列表
+		admin.DELETE("/minio/object", adminController.DeleteMinioObject)     // 删除 MinIO 桶中的对象
+		admin.POST("/outbox/process", adminController.ProcessOutboxTasks)    // 处理异步删除任务
+		admin.GET("/outbox", adminController.GetOutboxTasks)                 // 获取异步任务列表
+		admin.POST("/outbox/:taskId/retry", adminController.RetryOutboxTask) // 重试失败任务
+		admin.DELETE("/outbox/:taskId", adminController.DiscardOutboxTask)   // 丢弃失败任务
 	}
 }
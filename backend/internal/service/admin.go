@@ -1,18 +1,25 @@
 package service
 
 import (
+	"backend/internal/config"
 	"backend/internal/dao/minio"
 	"backend/internal/dao/mongo"
 	"backend/internal/dao/mysql"
+	"backend/internal/dao/search"
 	"backend/internal/model"
 	"backend/internal/util"
+	"context"
 	"encoding/json"
 	"fmt"
 	"mime/multipart"
+	"path"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	minio2 "github.com/minio/minio-go/v7"
 	"gorm.io/gorm"
 )
 
@@ -21,15 +28,19 @@ type AdminService struct {
 	outboxDAO       *mysql.OutboxDAO
 	datasetMongoDAO *mongo.DatasetsPreviewDAO
 	adminMinioDAO   *minio.AdminMinioDAO
+	detectJobDAO    *mysql.DetectJobDAO
+	searchIndex     search.SearchIndex
 	db              *gorm.DB
 }
 
-func NewAdminService(adminDAO *mysql.AdminDAO, outboxDAO *mysql.OutboxDAO, datasetMongoDAO *mongo.DatasetsPreviewDAO, adminMinioDAO *minio.AdminMinioDAO, db *gorm.DB) *AdminService {
+func NewAdminService(adminDAO *mysql.AdminDAO, outboxDAO *mysql.OutboxDAO, datasetMongoDAO *mongo.DatasetsPreviewDAO, adminMinioDAO *minio.AdminMinioDAO, detectJobDAO *mysql.DetectJobDAO, searchIndex search.SearchIndex, db *gorm.DB) *AdminService {
 	return &AdminService{
 		adminDAO:        adminDAO,
 		outboxDAO:       outboxDAO,
 		datasetMongoDAO: datasetMongoDAO,
 		adminMinioDAO:   adminMinioDAO,
+		detectJobDAO:    detectJobDAO,
+		searchIndex:     searchIndex,
 		db:              db,
 	}
 }
@@ -113,14 +124,14 @@ func (s AdminService) AddUser(m *model.AdminAddUserRequest) error {
 }
 
 // 获取数据集列表
-func (s AdminService) GetDatasets(page int, limit int) ([]model.AdminDatasetListResponse, int64, int, error) {
-	if page < 1 {
-		page = 1
+func (s AdminService) GetDatasets(pq *model.PageQuery, q *model.DatasetQuery) ([]model.AdminDatasetListResponse, model.PageInfo, error) {
+	if err := normalizePageQuery(pq, 10); err != nil {
+		return nil, model.PageInfo{}, err
 	}
-	if limit < 1 {
-		limit = 10
+	if pq.Sort == model.SortRelevance {
+		return nil, model.PageInfo{}, ErrInvalidSort
 	}
-	return s.adminDAO.GetDatasets(page, limit)
+	return s.adminDAO.GetDatasets(pq, q)
 }
 
 // 删除数据集
@@ -148,23 +159,36 @@ func (s AdminService) DeleteDataset(datasetID int) error {
 		return err
 	}
 
-	task := &model.Outbox{
-		EventType: "delete_minio_object",
-		Payload:   fmt.Sprintf(`{"objectName": "%s"}`, objectName),
-		Status:    "pending",
-	}
-
-	// 插入删除数据集文件任务
-	if err := s.outboxDAO.InsertTask(tx, task); err != nil {
+	// 全部版本的文件一并延迟删除，保留期内可恢复
+	objectNames, err := s.adminDAO.GetVersionObjectNamesTX(tx, datasetID)
+	if err != nil {
 		tx.Rollback()
 		return err
 	}
+	if objectName != "" && !slices.Contains(objectNames, objectName) {
+		objectNames = append(objectNames, objectName)
+	}
+	nextRunAt := time.Now().Add(util.OUTBOX_DELETE_OBJECT_DELAY * time.Hour)
+	for _, name := range objectNames {
+		task, err := model.NewOutbox(model.OutboxDeleteMinioObject, model.ObjectNamePayload{ObjectName: name})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		task.NextRunAt = &nextRunAt
+
+		// 插入删除数据集文件任务
+		if err := s.outboxDAO.InsertTask(tx, task); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
 
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
 		return err
 	}
-
+	unindexDataset(s.searchIndex, uint(datasetID))
 	return nil
 }
 
@@ -186,52 +210,36 @@ func (s AdminService) RestoreDataset(datasetID int) error {
 		return err
 	}
 
-	// 查询数据集文件名
-	var objectName string
-	if err := tx.Model(&model.Dataset{}).Where("id = ?", datasetID).Pluck("object_name", &objectName).Error; err != nil {
+	// 查询数据集
+	var dataset model.Dataset
+	if err := tx.First(&dataset, datasetID).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
-	fmt.Println("objectName = ", objectName)
-	// 取消删除数据集文件任务
-	if err := s.outboxDAO.CancelDeleteMinioObjectTask(tx, objectName); err != nil {
+	// 取消全部版本的删除文件任务
+	objectNames, err := s.adminDAO.GetVersionObjectNamesTX(tx, datasetID)
+	if err != nil {
 		tx.Rollback()
 		return err
 	}
+	if !slices.Contains(objectNames, dataset.ObjectName) {
+		objectNames = append(objectNames, dataset.ObjectName)
+	}
+	for _, name := range objectNames {
+		if err := s.outboxDAO.CancelDeleteMinioObjectTask(tx, name); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
 
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
 		return err
 	}
+	indexDataset(s.searchIndex, &dataset)
 	return nil
 }
 
-// 异步处理删除任务
-func (s AdminService) ProcessOutboxTasks() {
-	tasks, _ := s.outboxDAO.GetPendingTasks()
-	var data struct {
-		ObjectName string `json:"objectName"`
-	}
-	for _, task := range tasks {
-		switch task.EventType {
-		case "delete_mongo_preview":
-			util.Info("删除预览数据")
-			_ = json.Unmarshal([]byte(task.Payload), &data)
-			if err := s.datasetMongoDAO.DeletePreviewData(data.ObjectName); err != nil {
-				continue
-			}
-		case "delete_minio_object":
-			util.Info("删除文件")
-			_ = json.Unmarshal([]byte(task.Payload), &data)
-			if err := s.adminMinioDAO.DeleteDatasetFile(data.ObjectName); err != nil {
-				continue
-			}
-		}
-
-		_ = s.outboxDAO.MarkTasksDone(task.ID)
-	}
-}
-
 // 清理临时桶
 func (s AdminService) CleanupTempBucket(bucket string, maxAgeHours int) error {
 	objects, err := s.adminMinioDAO.GetObjects(bucket)
@@ -262,42 +270,327 @@ func (s AdminService) CleanupTempBucket(bucket string, maxAgeHours int) error {
 }
 
 // 指纹检测
-func (s AdminService) FingerprintDetect(file *multipart.FileHeader) (*model.AdminFingerprintDetectResponse, error) {
+func (s AdminService) FingerprintDetect(file *multipart.FileHeader, format string) (*model.AdminFingerprintDetectResponse, error) {
+	record, err := detectFingerprint(s.adminDAO, file.Filename, file.Size, func() (*util.FingerprintResult, error) {
+		return util.VerifyFingerprint(file, format)
+	})
+	if err != nil {
+		return &model.AdminFingerprintDetectResponse{}, err
+	}
+	return detectRecordResponse(record), nil
+}
+
+// 执行检测并写入检测记录（同步接口与检测任务共用）
+func detectFingerprint(adminDAO *mysql.AdminDAO, filename string, fileSize int64, extract func() (*util.FingerprintResult, error)) (*model.DetectRecord, error) {
 	start := time.Now()
 	// 检测指纹
-	detectionResult, err := util.VerifyFingerprint(file)
+	result, err := extract()
 	if err != nil {
-		return &model.AdminFingerprintDetectResponse{}, err
+		return nil, err
+	}
+	record := model.DetectRecord{
+		Filename:        filename,
+		FileSize:        fileSize,
+		DetectionResult: "无指纹",
+		Status:          model.DetectStatusNoFingerprint,
+	}
+	if result != nil {
+		record.Version = result.Version
+		record.Confidence = result.Confidence
+		record.Copies = result.Votes
+		if err := matchFingerprint(adminDAO, result, &record); err != nil {
+			return nil, err
+		}
 	}
-	detectTime := time.Since(start).String()
-	res := model.AdminFingerprintDetectResponse{
-		MatchResult:   detectionResult,
-		DetectionTime: detectTime,
+	record.DetectionTime = time.Since(start).String()
+	// 添加指纹检测记录，检测时间与数据库精度（毫秒）一致，报告中的时间与记录相同
+	record.CreatedAt = time.Now().Truncate(time.Millisecond)
+	if err := adminDAO.CreateFingerprintDetectRecord(&record); err != nil {
+		return nil, err
 	}
-	// 添加指纹检测记录
-	if err := s.adminDAO.CreateFingerprintDetectRecord(file.Filename, file.Size, detectionResult, detectTime); err != nil || detectionResult == "无指纹" {
-		return &res, err
+	if err := sealDetectReport(adminDAO, &record); err != nil {
+		return nil, err
 	}
-	// 获取指纹地址
-	fingerprintAddress, err := s.adminDAO.GetFingerprintAddressByTimeStamp(detectionResult)
+	return &record, nil
+}
+
+// 生成检测记录的匹配报告并计算摘要，随记录保存作为证据；已保存过报告的记录不再重新生成
+func sealDetectReport(adminDAO *mysql.AdminDAO, record *model.DetectRecord) error {
+	report := record.ToReport()
+	if report.DatasetID != 0 {
+		var err error
+		if report.DatasetTitle, err = adminDAO.GetDatasetTitle(report.DatasetID); err != nil {
+			return err
+		}
+	}
+	reportJSON, digest, err := newDetectReportEvidence(&report)
 	if err != nil {
-		return &res, err
+		return err
 	}
+	saved, err := adminDAO.SaveDetectReport(record.ID, reportJSON, digest)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		// 并发导出时其他请求已保存，以已保存的报告为准
+		latest, err := adminDAO.GetDetectRecord(record.ID)
+		if err != nil {
+			return err
+		}
+		if latest == nil {
+			return ErrDetectRecordNotFound
+		}
+		reportJSON, digest = latest.ReportJSON, latest.ReportDigest
+	}
+	record.ReportJSON, record.ReportDigest = reportJSON, digest
+	return nil
+}
+
+// 检测记录转换为检测响应
+func detectRecordResponse(record *model.DetectRecord) *model.AdminFingerprintDetectResponse {
+	report := record.ToReport()
 	return &model.AdminFingerprintDetectResponse{
-		MatchResult:   fingerprintAddress,
-		DetectionTime: time.Since(start).String(),
-	}, nil
+		MatchResult:   record.DetectionResult,
+		DetectionTime: record.DetectionTime,
+		Report:        &report,
+	}
 }
 
-// 获取指纹检测记录
-func (s AdminService) GetDetectRecords(page int, limit int) ([]model.DetectRecordResponse, int64, int, error) {
-	if page < 1 {
-		page = 1
+// 根据指纹查找对应交易：v2 按购买 ID 精确匹配，v1 按时间戳匹配（同一秒内多笔购买时列出全部候选，标记为冲突）
+func matchFingerprint(adminDAO *mysql.AdminDAO, result *util.FingerprintResult, record *model.DetectRecord) error {
+	var txs []model.Transaction
+	if result.Version == util.FINGERPRINT_VERSION_V2 {
+		tx, err := adminDAO.GetCompletedTransactionByID(result.PurchaseID)
+		if err != nil {
+			return err
+		}
+		if tx != nil {
+			txs = append(txs, *tx)
+		}
+	} else {
+		record.Timestamp = &result.Timestamp
+		var err error
+		if txs, err = adminDAO.GetTransactionsByTimestamp(result.Timestamp); err != nil {
+			return err
+		}
 	}
-	if limit < 1 {
-		limit = 10
+	if len(txs) == 0 {
+		record.DetectionResult = "未知指纹"
+		record.Status = model.DetectStatusUnknown
+		return nil
+	}
+
+	candidates := make([]model.DetectCandidate, 0, len(txs))
+	wallets := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		userID, err := adminDAO.GetUserIDByWallet(tx.BuyerWalletAddress)
+		if err != nil {
+			return err
+		}
+		purchasedAt := tx.CreatedAt
+		if tx.BlockTimestamp > 0 {
+			purchasedAt = time.Unix(tx.BlockTimestamp, 0)
+		}
+		candidates = append(candidates, model.DetectCandidate{
+			TransactionID: tx.ID,
+			DatasetID:     tx.DatasetID,
+			BuyerUserID:   userID,
+			BuyerWallet:   tx.BuyerWalletAddress,
+			TxHash:        tx.TxHash,
+			PurchasedAt:   &purchasedAt,
+		})
+		wallets = append(wallets, tx.BuyerWalletAddress)
+	}
+	data, err := json.Marshal(candidates)
+	if err != nil {
+		return err
+	}
+	record.Candidates = string(data)
+
+	if len(candidates) > 1 {
+		record.Status = model.DetectStatusConflict
+		record.DetectionResult = strings.Join(wallets, ",")
+		if len(record.DetectionResult) > 255 {
+			record.DetectionResult = record.DetectionResult[:255]
+		}
+		return nil
+	}
+	c := candidates[0]
+	datasetID := int(c.DatasetID)
+	record.Status = model.DetectStatusMatched
+	record.DetectionResult = c.BuyerWallet
+	record.DatasetID = &datasetID
+	record.TransactionID = &c.TransactionID
+	record.BuyerWallet = c.BuyerWallet
+	record.TxHash = c.TxHash
+	record.PurchasedAt = c.PurchasedAt
+	if c.BuyerUserID != 0 {
+		record.BuyerUserID = &c.BuyerUserID
+	}
+	return nil
+}
+
+// 上传文件创建检测任务：文件先暂存到临时桶（按临时桶规则过期清理），再由 worker 异步检测
+func (s AdminService) CreateUploadDetectJob(adminID uint, file *multipart.FileHeader, format string) (uint, error) {
+	format, err := util.ResolveDatasetFormat(format, file.Filename)
+	if err != nil {
+		return 0, err
+	}
+	cfg := config.LoadConfig()
+	bucket := cfg.MinIO.Buckets[util.DATASET_TEMP_BUCKET]
+	// 第二段保持为时间戳，供临时桶清理判断过期
+	objectName := fmt.Sprintf("%d_%d_detect%s", adminID, time.Now().Unix(), filepath.Ext(file.Filename))
+
+	f, err := file.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if err := s.adminMinioDAO.PutObject(context.Background(), bucket, objectName, f, file.Size); err != nil {
+		return 0, err
+	}
+
+	job := &model.DetectJob{
+		AdminID:    adminID,
+		Source:     model.DetectSourceUpload,
+		Bucket:     bucket,
+		ObjectName: objectName,
+		Filename:   file.Filename,
+		Format:     format,
+		FileSize:   file.Size,
+	}
+	if err := s.detectJobDAO.CreateJob(job); err != nil {
+		return 0, err
+	}
+	return job.ID, nil
+}
+
+// 对 MinIO 中已有的对象创建检测任务（例如保存到桶中的泄露文件），只允许配置中的桶
+func (s AdminService) CreateObjectDetectJob(adminID uint, m *model.CreateDetectJobRequest) (uint, error) {
+	cfg := config.LoadConfig()
+	allowed := false
+	for _, bucket := range cfg.MinIO.Buckets {
+		if bucket == m.Bucket {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return 0, ErrBucketNotAllowed
+	}
+	format, err := util.ResolveDatasetFormat(m.Format, m.ObjectName)
+	if err != nil {
+		return 0, err
+	}
+	size, err := s.adminMinioDAO.StatObject(context.Background(), m.Bucket, m.ObjectName)
+	if err != nil {
+		if minio2.ToErrorResponse(err).Code == "NoSuchKey" {
+			return 0, ErrDetectObjectNotFound
+		}
+		return 0, err
+	}
+
+	job := &model.DetectJob{
+		AdminID:    adminID,
+		Source:     model.DetectSourceMinio,
+		Bucket:     m.Bucket,
+		ObjectName: m.ObjectName,
+		Filename:   path.Base(m.ObjectName),
+		Format:     format,
+		FileSize:   size,
+	}
+	if err := s.detectJobDAO.CreateJob(job); err != nil {
+		return 0, err
+	}
+	return job.ID, nil
+}
+
+// 获取检测任务状态，完成后附带检测结果
+func (s AdminService) GetDetectJob(jobID uint) (*model.DetectJobStatusResponse, error) {
+	job, err := s.detectJobDAO.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrDetectJobNotFound
+	}
+	res := &model.DetectJobStatusResponse{
+		JobID:    job.ID,
+		State:    job.Status,
+		Progress: job.Progress,
+		Filename: job.Filename,
+		Error:    job.Error,
+	}
+	if job.Status != model.DetectJobDone || job.RecordID == nil {
+		return res, nil
+	}
+	record, err := s.adminDAO.GetDetectRecord(*job.RecordID)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil {
+		res.Result = detectRecordResponse(record)
+	}
+	return res, nil
+}
+
+// 取消检测任务（排队中或执行中的任务）
+func (s AdminService) CancelDetectJob(jobID uint) error {
+	ok, err := s.detectJobDAO.MarkFailed(jobID, ErrDetectJobCanceled.Error())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrDetectJobNotFound
+	}
+	return nil
+}
+
+// 获取指纹检测记录，检测日期为 YYYY-MM-DD（本地时间），结束日期包含当天
+func (s AdminService) GetDetectRecords(q *model.DetectRecordQuery) ([]model.DetectRecordResponse, int64, int, error) {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.Limit < 1 {
+		q.Limit = 10
+	}
+	if q.StartDate != "" {
+		from, err := time.ParseInLocation("2006-01-02", q.StartDate, time.Local)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("%w: startDate 应为 YYYY-MM-DD", ErrInvalidDetectRecordQuery)
+		}
+		q.CreatedFrom = &from
+	}
+	if q.EndDate != "" {
+		to, err := time.ParseInLocation("2006-01-02", q.EndDate, time.Local)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("%w: endDate 应为 YYYY-MM-DD", ErrInvalidDetectRecordQuery)
+		}
+		// 包含当天
+		to = to.AddDate(0, 0, 1)
+		q.CreatedTo = &to
+	}
+	if q.CreatedFrom != nil && q.CreatedTo != nil && !q.CreatedFrom.Before(*q.CreatedTo) {
+		return nil, 0, 0, fmt.Errorf("%w: startDate 不能晚于 endDate", ErrInvalidDetectRecordQuery)
+	}
+	return s.adminDAO.GetDetectRecords(q)
+}
+
+// 导出检测记录的匹配报告；早于报告固化的记录在首次导出时生成并保存，之后导出内容不再变化
+func (s AdminService) ExportDetectReport(recordID int) (*DetectReportExport, error) {
+	record, err := s.adminDAO.GetDetectRecord(recordID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, ErrDetectRecordNotFound
+	}
+	if record.ReportDigest == "" {
+		if err := sealDetectReport(s.adminDAO, record); err != nil {
+			return nil, err
+		}
 	}
-	return s.adminDAO.GetDetectRecords(page, limit)
+	return NewDetectReportExport(record)
 }
 
 // 获取minio buckets
@@ -0,0 +1,541 @@
+package service
+
+import (
+	"backend/internal/dao/chain"
+	"backend/internal/dao/mysql"
+	"backend/internal/dao/redis"
+	"backend/internal/dao/search"
+	"backend/internal/model"
+	"backend/internal/util"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 同步进度名称
+const chainCheckpointName = "aiDatasets"
+
+// 链上事件来源（由 chain.ContractDAO 实现）
+type ChainEventSource interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	GetBlockHash(ctx context.Context, blockNumber uint64) (string, error)
+	GetLogs(ctx context.Context, fromBlock, toBlock uint64) ([]chain.Log, error)
+	GetPurchaseEvent(ctx context.Context, txHash string) (*chain.PurchaseEvent, error)
+}
+
+// 链上事件同步，将合约事件对账到 datasets、transactions 和 users.role
+// 只处理距最新区块超过 confirmations 个确认的区块，避免回滚导致脏数据
+type ChainIndexerService struct {
+	source        ChainEventSource
+	indexerDAO    *mysql.ChainIndexerDAO
+	userStats     *mysql.UserStatsDAO
+	userRedisDAO  *redis.UserRedisDAO
+	rankRedisDAO  *redis.RankRedisDAO
+	searchIndex   search.SearchIndex
+	startBlock    uint64
+	confirmations uint64
+	batchSize     uint64
+	interval      time.Duration
+}
+
+func NewChainIndexerService(source ChainEventSource, indexerDAO *mysql.ChainIndexerDAO, userStats *mysql.UserStatsDAO, userRedisDAO *redis.UserRedisDAO, rankRedisDAO *redis.RankRedisDAO,
+	searchIndex search.SearchIndex, startBlock, confirmations, batchSize uint64, interval time.Duration) *ChainIndexerService {
+	if batchSize == 0 {
+		batchSize = 1000
+	}
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	return &ChainIndexerService{
+		source:        source,
+		indexerDAO:    indexerDAO,
+		userStats:     userStats,
+		userRedisDAO:  userRedisDAO,
+		rankRedisDAO:  rankRedisDAO,
+		searchIndex:   searchIndex,
+		startBlock:    startBlock,
+		confirmations: confirmations,
+		batchSize:     batchSize,
+		interval:      interval,
+	}
+}
+
+// 持续同步，直到 ctx 结束
+func (s ChainIndexerService) Run(ctx context.Context) {
+	util.Info("链上事件同步已启动", zap.Uint64("startBlock", s.startBlock), zap.Uint64("confirmations", s.confirmations))
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Sync(ctx); err != nil {
+			util.Error("链上事件同步失败", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			util.Info("链上事件同步已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 同步一轮：从同步进度处理到最新的已确认区块
+func (s ChainIndexerService) Sync(ctx context.Context) error {
+	head, err := s.source.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	if head < s.confirmations {
+		return nil
+	}
+	safeBlock := head - s.confirmations
+
+	from, err := s.nextBlock(ctx)
+	if err != nil {
+		return err
+	}
+
+	for from <= safeBlock {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		to := from + s.batchSize - 1
+		if to > safeBlock {
+			to = safeBlock
+		}
+
+		logs, err := s.source.GetLogs(ctx, from, to)
+		if err != nil {
+			return err
+		}
+		for _, l := range logs {
+			if l.Removed {
+				continue
+			}
+			event, err := chain.DecodeEvent(l)
+			if errors.Is(err, chain.ErrUnknownEvent) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := s.applyEvent(ctx, event); err != nil {
+				return err
+			}
+		}
+
+		blockHash, err := s.source.GetBlockHash(ctx, to)
+		if err != nil {
+			return err
+		}
+		if err := s.indexerDAO.SaveCheckpointTX(s.indexerDAO.DB(), chainCheckpointName, to, blockHash); err != nil {
+			return err
+		}
+		util.Info("链上事件同步进度", zap.Uint64("from", from), zap.Uint64("to", to), zap.Int("logs", len(logs)))
+		from = to + 1
+	}
+	return nil
+}
+
+// 计算下一个待处理区块；同步进度所在区块被回滚时回退 confirmations 个区块，撤销其后已处理的事件后按主链重新处理
+func (s ChainIndexerService) nextBlock(ctx context.Context) (uint64, error) {
+	checkpoint, err := s.indexerDAO.GetCheckpoint(chainCheckpointName)
+	if err != nil {
+		return 0, err
+	}
+	if checkpoint == nil {
+		return s.startBlock, nil
+	}
+	if checkpoint.BlockHash != "" {
+		blockHash, err := s.source.GetBlockHash(ctx, checkpoint.BlockNumber)
+		if err != nil {
+			return 0, err
+		}
+		if blockHash != checkpoint.BlockHash {
+			rewind := s.startBlock
+			if checkpoint.BlockNumber > s.confirmations && checkpoint.BlockNumber-s.confirmations > rewind {
+				rewind = checkpoint.BlockNumber - s.confirmations
+			}
+			util.Warn("检测到区块回滚，回退同步进度",
+				zap.Uint64("checkpoint", checkpoint.BlockNumber), zap.String("savedHash", checkpoint.BlockHash),
+				zap.String("chainHash", blockHash), zap.Uint64("rewindTo", rewind))
+			if err := s.revertEvents(rewind); err != nil {
+				return 0, err
+			}
+			return rewind, nil
+		}
+	}
+	return checkpoint.BlockNumber + 1, nil
+}
+
+// 处理单个事件，事件记录与对账更新在同一个事务中，重复事件直接跳过
+func (s ChainIndexerService) applyEvent(ctx context.Context, e *chain.Event) error {
+	// 购买事件需要回执中的 gas、nonce 和区块时间，在事务外先查好
+	var purchase *chain.PurchaseEvent
+	if e.Name == chain.EventDatasetPurchase {
+		var err error
+		if purchase, err = s.source.GetPurchaseEvent(ctx, e.TxHash); err != nil {
+			return err
+		}
+	}
+
+	tx := s.indexerDAO.DB().Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	record := &model.ChainEvent{
+		TxHash:      e.TxHash,
+		LogIndex:    e.LogIndex,
+		BlockNumber: e.BlockNumber,
+		BlockHash:   e.BlockHash,
+		EventName:   e.Name,
+		DatasetID:   e.DatasetID,
+		Account:     e.Account,
+	}
+	inserted, err := s.indexerDAO.InsertEventTX(tx, record)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if !inserted {
+		tx.Rollback()
+		return nil
+	}
+	eventID := record.ID
+
+	var roleUserID uint
+	var changedDataset *model.Dataset
+	var undo model.ChainEventUndo
+	datasetID := uint(e.DatasetID)
+	switch e.Name {
+	case chain.EventDatasetCreated:
+		changedDataset, err = s.applyDatasetChange(tx, e, map[string]interface{}{
+			"price":   weiToAmount(e),
+			"is_free": false,
+		}, &undo)
+	case chain.EventDatasetUpdated:
+		changedDataset, err = s.applyDatasetChange(tx, e, map[string]interface{}{
+			"title":       e.Title,
+			"description": e.Description,
+			"price":       weiToAmount(e),
+			"is_free":     false,
+		}, &undo)
+	case chain.EventDatasetDeactivated:
+		undo.Restore, err = s.indexerDAO.DeactivateDatasetTX(tx, datasetID, e.Account)
+	case chain.EventDatasetForceRemoval:
+		undo.Restore, err = s.indexerDAO.DeactivateDatasetTX(tx, datasetID, "")
+	case chain.EventDatasetPurchase:
+		err = s.applyPurchase(tx, e, purchase, &undo)
+	case chain.EventRoleUpdated:
+		roleUserID, err = s.applyRole(tx, e, &undo)
+	}
+	if err == nil {
+		err = s.saveUndo(tx, eventID, &undo)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 同步缓存、搜索索引和排行榜快照
+	if roleUserID != 0 {
+		_ = s.userRedisDAO.DelRedisUserInfo(roleUserID)
+	}
+	if changedDataset != nil {
+		indexDataset(s.searchIndex, changedDataset)
+		updateRankSnapshot(s.rankRedisDAO, changedDataset)
+	}
+	if undo.Restore {
+		unindexDataset(s.searchIndex, datasetID)
+	}
+	util.Info("链上事件已同步", zap.String("event", e.Name), zap.String("txHash", e.TxHash),
+		zap.Uint64("datasetId", e.DatasetID), zap.String("account", e.Account))
+	return nil
+}
+
+// 按链上数据更新数据集（仅作者匹配的记录）并写入修改记录，同时确认或取代卖家待上链的价格修改
+// 返回更新后的数据集用于提交后同步搜索索引和排行榜，无变化或数据集不存在时返回 nil
+func (s ChainIndexerService) applyDatasetChange(tx *gorm.DB, e *chain.Event, updates map[string]interface{}, undo *model.ChainEventUndo) (*model.Dataset, error) {
+	datasetID := uint(e.DatasetID)
+	dataset, err := s.indexerDAO.GetDatasetTX(tx, datasetID, e.Account)
+	if err != nil || dataset == nil {
+		return nil, err
+	}
+	// 与 decimal(10,2) 列精度一致，便于和待确认价格比较
+	price := math.Round(updates["price"].(float64)*100) / 100
+	updates["price"] = price
+
+	if err := s.indexerDAO.ResolvePendingEditsTX(tx, datasetID, price, e.TxHash); err != nil {
+		return nil, err
+	}
+	changes := diffDataset(dataset, updates)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	if err := s.indexerDAO.UpdateDatasetTX(tx, datasetID, e.Account, updates); err != nil {
+		return nil, err
+	}
+	undo.Fields = changes
+	b, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.indexerDAO.CreateDatasetEditTX(tx, &model.DatasetEdit{
+		DatasetID: datasetID,
+		Source:    model.DatasetEditSourceChain,
+		Changes:   b,
+		Status:    model.DatasetEditConfirmed,
+		TxHash:    e.TxHash,
+	}); err != nil {
+		return nil, err
+	}
+	return s.indexerDAO.GetDatasetTX(tx, datasetID, e.Account)
+}
+
+// 对账购买记录：已有记录补全链上信息，待确认记录直接完成，都没有时补建一条已完成记录
+func (s ChainIndexerService) applyPurchase(tx *gorm.DB, e *chain.Event, p *chain.PurchaseEvent, undo *model.ChainEventUndo) error {
+	updates := map[string]interface{}{
+		"tx_hash":         p.TxHash,
+		"block_hash":      p.BlockHash,
+		"block_number":    p.BlockNumber,
+		"block_timestamp": p.BlockTimestamp,
+		"gas":             strconv.FormatUint(p.GasUsed, 10),
+		"nonce":           p.Nonce,
+	}
+
+	transaction, err := s.indexerDAO.GetTransactionByTxHashTX(tx, e.TxHash)
+	if err != nil {
+		return err
+	}
+	if transaction != nil {
+		// 已由确认接口完成的记录同样基于该区块，回滚时一并恢复为待确认
+		undo.TransactionID = transaction.ID
+		if transaction.Status == "completed" {
+			return nil
+		}
+		return s.indexerDAO.CompleteTransactionTX(tx, transaction.ID, updates)
+	}
+
+	transaction, err = s.indexerDAO.GetPendingTransactionTX(tx, e.Account, uint(e.DatasetID))
+	if err != nil {
+		return err
+	}
+	if transaction != nil {
+		undo.TransactionID = transaction.ID
+		return s.indexerDAO.CompleteTransactionTX(tx, transaction.ID, updates)
+	}
+
+	// 浏览器未调用创建交易接口，补建记录并计入买家花费
+	amount := weiToAmount(e)
+	created := &model.Transaction{
+		BuyerWalletAddress:  e.Account,
+		SellerWalletAddress: e.Seller,
+		DatasetID:           uint(e.DatasetID),
+		Type:                "purchase",
+		Amount:              amount,
+		Gas:                 strconv.FormatUint(p.GasUsed, 10),
+		TxHash:              p.TxHash,
+		BlockHash:           p.BlockHash,
+		Status:              "completed",
+		Nonce:               p.Nonce,
+		BlockNumber:         p.BlockNumber,
+		BlockTimestamp:      p.BlockTimestamp,
+	}
+	if err := s.indexerDAO.CreateTransactionTX(tx, created); err != nil {
+		return err
+	}
+	undo.TransactionID = created.ID
+	undo.Created = true
+	buyerID, err := s.indexerDAO.GetUserIDByWalletTX(tx, e.Account)
+	if err != nil || buyerID == 0 {
+		return err
+	}
+	undo.BuyerID = buyerID
+	undo.Amount = amount
+	return s.userStats.UpdateUserStatsTotalSpent(tx, buyerID, amount)
+}
+
+// 同步用户角色，返回需要清除缓存的用户ID（钱包未绑定用户时返回 0）
+func (s ChainIndexerService) applyRole(tx *gorm.DB, e *chain.Event, undo *model.ChainEventUndo) (uint, error) {
+	var role string
+	switch e.Role {
+	case chain.ChainRoleUser:
+		role = util.ROLE_USER
+	case chain.ChainRoleSeller:
+		role = util.ROLE_SELLER
+	case chain.ChainRoleAdmin:
+		role = util.ROLE_ADMIN
+	default:
+		util.Warn("未知的链上角色", zap.Uint64("role", e.Role), zap.String("account", e.Account))
+		return 0, nil
+	}
+
+	userID, err := s.indexerDAO.GetUserIDByWalletTX(tx, e.Account)
+	if err != nil || userID == 0 {
+		return 0, err
+	}
+	if undo.Role, err = s.indexerDAO.GetUserRoleTX(tx, userID); err != nil {
+		return 0, err
+	}
+	undo.UserID = userID
+	return userID, s.indexerDAO.UpdateUserRoleTX(tx, userID, role)
+}
+
+// 保存撤销事件所需的数据
+func (s ChainIndexerService) saveUndo(tx *gorm.DB, eventID uint, undo *model.ChainEventUndo) error {
+	b, err := json.Marshal(undo)
+	if err != nil {
+		return err
+	}
+	return s.indexerDAO.SaveEventUndoTX(tx, eventID, b)
+}
+
+// 撤销 fromBlock 及之后已处理的事件并删除事件记录，按处理顺序倒序进行
+// 这些区块可能已被回滚，撤销后由后续同步按主链重新处理，仍在主链上的事件会被重新应用
+func (s ChainIndexerService) revertEvents(fromBlock uint64) error {
+	events, err := s.indexerDAO.ListEventsFromBlock(fromBlock)
+	if err != nil {
+		return err
+	}
+	for i := range events {
+		if err := s.revertEvent(&events[i]); err != nil {
+			return err
+		}
+	}
+	if len(events) > 0 {
+		util.Warn("已撤销回滚区块中的链上事件", zap.Uint64("fromBlock", fromBlock), zap.Int("events", len(events)))
+	}
+	return nil
+}
+
+// 撤销单个事件的对账结果，撤销与删除事件记录在同一个事务中
+func (s ChainIndexerService) revertEvent(e *model.ChainEvent) error {
+	var undo model.ChainEventUndo
+	if len(e.Undo) > 0 {
+		if err := json.Unmarshal(e.Undo, &undo); err != nil {
+			return err
+		}
+	} else {
+		// 记录撤销数据之前处理的事件只能删除事件记录
+		util.Warn("链上事件缺少撤销数据，仅删除事件记录", zap.String("txHash", e.TxHash), zap.Uint64("logIndex", e.LogIndex))
+	}
+
+	tx := s.indexerDAO.DB().Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	var err error
+	var changedDataset *model.Dataset
+	datasetID := uint(e.DatasetID)
+	switch e.EventName {
+	case chain.EventDatasetCreated, chain.EventDatasetUpdated:
+		changedDataset, err = s.revertDatasetChange(tx, e, &undo)
+	case chain.EventDatasetDeactivated, chain.EventDatasetForceRemoval:
+		if !undo.Restore {
+			break
+		}
+		if err = s.indexerDAO.RestoreDatasetTX(tx, datasetID); err == nil {
+			changedDataset, err = s.indexerDAO.GetDatasetByIDTX(tx, datasetID)
+		}
+	case chain.EventDatasetPurchase:
+		if undo.TransactionID == 0 {
+			break
+		}
+		if !undo.Created {
+			err = s.indexerDAO.ReopenTransactionTX(tx, undo.TransactionID, e.BlockHash)
+			break
+		}
+		if err = s.indexerDAO.DeleteTransactionTX(tx, undo.TransactionID); err == nil && undo.BuyerID != 0 {
+			err = s.userStats.UpdateUserStatsTotalSpentRollback(tx, undo.BuyerID, undo.Amount)
+		}
+	case chain.EventRoleUpdated:
+		if undo.UserID != 0 && undo.Role != "" {
+			err = s.indexerDAO.UpdateUserRoleTX(tx, undo.UserID, undo.Role)
+		}
+	}
+	if err == nil {
+		err = s.indexerDAO.DeleteEventTX(tx, e.ID)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 同步缓存、搜索索引和排行榜快照
+	if undo.UserID != 0 {
+		_ = s.userRedisDAO.DelRedisUserInfo(undo.UserID)
+	}
+	if changedDataset != nil {
+		indexDataset(s.searchIndex, changedDataset)
+		updateRankSnapshot(s.rankRedisDAO, changedDataset)
+	}
+	util.Info("链上事件已撤销", zap.String("event", e.EventName), zap.String("txHash", e.TxHash), zap.Uint64("datasetId", e.DatasetID))
+	return nil
+}
+
+// 还原链上事件修改的数据集字段（字段已被之后的修改覆盖时保留当前值），返回还原后的数据集
+func (s ChainIndexerService) revertDatasetChange(tx *gorm.DB, e *model.ChainEvent, undo *model.ChainEventUndo) (*model.Dataset, error) {
+	datasetID := uint(e.DatasetID)
+	if err := s.indexerDAO.RevertDatasetEditsTX(tx, datasetID, e.TxHash); err != nil {
+		return nil, err
+	}
+	if len(undo.Fields) == 0 {
+		return nil, nil
+	}
+	dataset, err := s.indexerDAO.GetDatasetTX(tx, datasetID, e.Account)
+	if err != nil || dataset == nil {
+		return nil, err
+	}
+	applied := make(map[string]interface{}, len(undo.Fields))
+	for column, change := range undo.Fields {
+		applied[column] = change.New
+	}
+	overwritten := diffDataset(dataset, applied)
+	updates := make(map[string]interface{}, len(undo.Fields))
+	for column, change := range undo.Fields {
+		if _, ok := overwritten[column]; !ok {
+			updates[column] = change.Old
+		}
+	}
+	if len(updates) == 0 {
+		return nil, nil
+	}
+	if err := s.indexerDAO.UpdateDatasetTX(tx, datasetID, e.Account, updates); err != nil {
+		return nil, err
+	}
+	return s.indexerDAO.GetDatasetTX(tx, datasetID, e.Account)
+}
+
+// wei 转为数据库金额（decimal 8 位小数）
+func weiToAmount(e *chain.Event) float64 {
+	amount, _ := strconv.ParseFloat(chain.WeiToEther(e.Price, 8), 64)
+	return amount
+}
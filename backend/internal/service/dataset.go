@@ -6,22 +6,33 @@ import (
 	"backend/internal/dao/mongo"
 	"backend/internal/dao/mysql"
 	"backend/internal/dao/redis"
+	"backend/internal/dao/search"
 	"backend/internal/model"
 	"backend/internal/util"
-	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"strconv"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	minio2 "github.com/minio/minio-go/v7"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
 )
 
+// 链上购买记录查询（由 chain.ContractDAO 实现，未配置时为 nil）
+type PurchaseChecker interface {
+	HasPurchased(ctx context.Context, datasetID uint, buyer string) (bool, error)
+}
+
 type DatasetService struct {
 	datasetDAO      *mysql.DatasetDAO
 	outboxDAO       *mysql.OutboxDAO
@@ -30,10 +41,16 @@ type DatasetService struct {
 	rankRedisDAO    *redis.RankRedisDAO
 	datasetMinioDAO *minio.DatasetMinioDAO
 	userStatsDAO    *mysql.UserStatsDAO
+	downloadJobDAO  *mysql.DownloadJobDAO
+	tagDAO          *mysql.TagDAO
+	validationDAO   *mysql.DatasetValidationDAO
+	uploadDAO       *mysql.UploadSessionDAO
+	purchaseChecker PurchaseChecker
+	searchIndex     search.SearchIndex
 	db              *gorm.DB
 }
 
-func NewDatasetService(datasetDAO *mysql.DatasetDAO, outboxDAO *mysql.OutboxDAO, datasetMongoDAO *mongo.DatasetsPreviewDAO, datasetRedisDAO *redis.DatasetRedisDAO, rankRedisDAO *redis.RankRedisDAO, datasetMinioDAO *minio.DatasetMinioDAO, userStatsDAO *mysql.UserStatsDAO, db *gorm.DB) *DatasetService {
+func NewDatasetService(datasetDAO *mysql.DatasetDAO, outboxDAO *mysql.OutboxDAO, datasetMongoDAO *mongo.DatasetsPreviewDAO, datasetRedisDAO *redis.DatasetRedisDAO, rankRedisDAO *redis.RankRedisDAO, datasetMinioDAO *minio.DatasetMinioDAO, userStatsDAO *mysql.UserStatsDAO, downloadJobDAO *mysql.DownloadJobDAO, tagDAO *mysql.TagDAO, validationDAO *mysql.DatasetValidationDAO, uploadDAO *mysql.UploadSessionDAO, purchaseChecker PurchaseChecker, searchIndex search.SearchIndex, db *gorm.DB) *DatasetService {
 	return &DatasetService{
 		datasetDAO:      datasetDAO,
 		outboxDAO:       outboxDAO,
@@ -42,48 +59,181 @@ func NewDatasetService(datasetDAO *mysql.DatasetDAO, outboxDAO *mysql.OutboxDAO,
 		rankRedisDAO:    rankRedisDAO,
 		datasetMinioDAO: datasetMinioDAO,
 		userStatsDAO:    userStatsDAO,
+		downloadJobDAO:  downloadJobDAO,
+		tagDAO:          tagDAO,
+		validationDAO:   validationDAO,
+		uploadDAO:       uploadDAO,
+		purchaseChecker: purchaseChecker,
+		searchIndex:     searchIndex,
 		db:              db,
 	}
 }
 
 // 存入预览数据
-func (s DatasetService) SavePreviewData(req *model.UploadPreviewRequest) error {
+func (s DatasetService) SavePreviewData(req *model.UploadPreviewRequest, userID uint) error {
+	if req.UserID != userID {
+		return ErrNotObjectOwner
+	}
+	if err := checkObjectOwner(userID, req.ObjectName); err != nil {
+		return err
+	}
+	if _, err := util.ResolveDatasetFormat(req.Format, req.ObjectName); err != nil {
+		return err
+	}
+	walletAddress, err := s.getBoundWallet(userID)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(walletAddress, req.OwnerAddress) {
+		return ErrNotObjectOwner
+	}
 	return s.datasetMongoDAO.SavePreviewData(req)
 }
 
-// 获取上传数据集预签名 url
-func (s DatasetService) GetUploadPreviewURL(userID uint) (string, error) {
-	return s.datasetMinioDAO.GetUploadPreviewURL(userID)
+// 获取上传数据集预签名 url，对象扩展名与数据集格式一致（格式为空时按对象名扩展名推断）
+func (s DatasetService) GetUploadPreviewURL(userID uint, req *model.UploadPreviewRequest) (string, error) {
+	format, err := util.ResolveDatasetFormat(req.Format, req.ObjectName)
+	if err != nil {
+		return "", err
+	}
+	return s.datasetMinioDAO.GetUploadPreviewURL(userID, util.DatasetFormatExtension(format))
 }
 
 // 初始化分片上传 -- 1
-func (s DatasetService) InitiateMultipartUpload(objectName string) (string, string, error) {
+// 同时创建上传会话，记录文件大小和分片大小，供续传和清理使用
+func (s DatasetService) InitiateMultipartUpload(userID uint, req *model.InitMultipartUploadRequest) (*model.UploadSession, error) {
+	cfg := loadUploadConfig()
+	partSize := req.PartSize
+	if partSize == 0 {
+		partSize = cfg.partSize
+	}
+	if partSize < minUploadPartSize || partSize > maxUploadPartSize {
+		return nil, fmt.Errorf("%w: 分片大小应在 5MB 到 5GB 之间", ErrInvalidUploadPart)
+	}
+	totalParts := int((req.FileSize + partSize - 1) / partSize)
+	if totalParts > maxUploadParts {
+		return nil, fmt.Errorf("%w: 分片数不能超过 %d，请增大分片大小", ErrInvalidUploadPart, maxUploadParts)
+	}
+
+	// 对象名由服务端生成（<userID>_<时间戳><扩展名>），用于后续归属校验
+	objectName := s.datasetMinioDAO.GenerateDatasetObjectName(userID, req.FileName)
 	uploadId, err := s.datasetMinioDAO.InitiateMultipartUpload(objectName)
 	if err != nil {
-		return "", "", err
-	}
-	return uploadId, objectName, nil
+		return nil, err
+	}
+	session := &model.UploadSession{
+		UserID:        userID,
+		ObjectName:    objectName,
+		UploadID:      uploadId,
+		FileName:      filepath.Base(req.FileName),
+		ExpectedSize:  req.FileSize,
+		PartSize:      partSize,
+		TotalParts:    totalParts,
+		UploadedParts: json.RawMessage("[]"),
+		ExpiresAt:     time.Now().Add(cfg.sessionTTL),
+	}
+	if err = s.uploadDAO.CreateSession(session); err != nil {
+		_ = s.datasetMinioDAO.AbortMultipartUpload(objectName, uploadId)
+		return nil, err
+	}
+	return session, nil
 }
 
 // 获取某个分片的上传 URL -- 2
-func (s DatasetService) GetPresignedPartURL(objectName, uploadId string, partNumber int) (string, error) {
-	return s.datasetMinioDAO.GetPresignedPartURL(objectName, uploadId, partNumber)
+func (s DatasetService) GetPresignedPartURL(userID uint, objectName, uploadId string, partNumber int) (string, error) {
+	session, err := s.getUploadingSession(userID, objectName, uploadId)
+	if err != nil {
+		return "", err
+	}
+	if partNumber < 1 || partNumber > session.TotalParts {
+		return "", fmt.Errorf("%w: 分片号应在 1 到 %d 之间", ErrInvalidUploadPart, session.TotalParts)
+	}
+	url, err := s.datasetMinioDAO.GetPresignedPartURL(objectName, uploadId, partNumber)
+	if err != nil {
+		return "", err
+	}
+	if err = s.uploadDAO.TouchSession(session.ID, time.Now().Add(loadUploadConfig().sessionTTL)); err != nil {
+		util.Warn("上传会话续期失败", zap.String("objectName", objectName), zap.Error(err))
+	}
+	return url, nil
 }
 
 // 完成分片上传 -- 3
-func (s DatasetService) CompleteMultipartUpload(objectName, uploadId string, parts []minio2.CompletePart) (string, error) {
-	return s.datasetMinioDAO.CompleteMultipartUpload(objectName, uploadId, parts)
+// 分片列表以 MinIO 记录为准，全部分片上传完成且总大小与初始化时一致才合并；
+// 请求中的 parts（旧客户端仍会提交）须与 MinIO 记录一致
+func (s DatasetService) CompleteMultipartUpload(userID uint, objectName, uploadId string, parts []minio2.CompletePart) (string, error) {
+	session, err := s.getUploadingSession(userID, objectName, uploadId)
+	if err != nil {
+		return "", err
+	}
+	// 已被数据集使用的对象不允许覆盖
+	if s.datasetDAO.CheckObjectNameUsed(s.db, objectName) {
+		return "", ErrNotObjectOwner
+	}
+	listed, err := s.datasetMinioDAO.ListObjectParts(context.Background(), objectName, uploadId)
+	if err != nil {
+		return "", err
+	}
+	uploaded, uploadedBytes := uploadedParts(listed)
+	if len(uploaded) != session.TotalParts || uploadedBytes != session.ExpectedSize {
+		return "", fmt.Errorf("%w: 已上传 %d/%d 个分片，%d/%d 字节", ErrUploadIncomplete, len(uploaded), session.TotalParts, uploadedBytes, session.ExpectedSize)
+	}
+	etags := make(map[int]string, len(uploaded))
+	completeParts := make([]minio2.CompletePart, 0, len(uploaded))
+	for _, p := range uploaded {
+		etags[p.PartNumber] = p.ETag
+		completeParts = append(completeParts, minio2.CompletePart{PartNumber: p.PartNumber, ETag: p.ETag})
+	}
+	for _, p := range parts {
+		if etags[p.PartNumber] != strings.Trim(p.ETag, `"`) {
+			return "", fmt.Errorf("%w: 分片 %d 的 ETag 与服务端记录不一致", ErrUploadIncomplete, p.PartNumber)
+		}
+	}
+
+	key, err := s.datasetMinioDAO.CompleteMultipartUpload(objectName, uploadId, completeParts)
+	if err != nil {
+		return "", err
+	}
+	b, _ := json.Marshal(uploaded)
+	if err = s.uploadDAO.MarkCompleted(session.ID, b, uploadedBytes, time.Now().Add(loadUploadConfig().orphanTTL)); err != nil {
+		util.Warn("更新上传会话失败", zap.String("objectName", objectName), zap.Error(err))
+	}
+	return key, nil
 }
 
 // 取消分片上传
-func (s DatasetService) AbortMultipartUpload(objectName, uploadId string) error {
-	return s.datasetMinioDAO.AbortMultipartUpload(objectName, uploadId)
+func (s DatasetService) AbortMultipartUpload(userID uint, objectName, uploadId string) error {
+	session, err := s.getUploadingSession(userID, objectName, uploadId)
+	if err != nil {
+		return err
+	}
+	if err = s.datasetMinioDAO.AbortMultipartUpload(objectName, uploadId); err != nil {
+		return err
+	}
+	_, err = s.uploadDAO.UpdateStatus(session.ID, model.UploadSessionUploading, model.UploadSessionAborted)
+	return err
 }
 
 // 上传数据集
 func (s DatasetService) UploadDataset(m *model.UploadDatasetRequest, userID uint) (uint, error) {
 	cfg := config.LoadConfig()
 	bucket := cfg.MinIO.Buckets["datasets"]
+
+	// 校验作者地址与对象名归属
+	walletAddress, err := s.getBoundWallet(userID)
+	if err != nil {
+		return 0, err
+	}
+	if !strings.EqualFold(walletAddress, m.AuthorWalletAddress) {
+		return 0, ErrNotDatasetOwner
+	}
+	if err = checkObjectOwner(userID, m.ObjectName); err != nil {
+		return 0, err
+	}
+	if m.Format, err = util.ResolveDatasetFormat(m.Format, m.ObjectName); err != nil {
+		return 0, err
+	}
+
 	tx := s.db.Begin()
 	if tx.Error != nil {
 		return 0, tx.Error
@@ -94,11 +244,49 @@ func (s DatasetService) UploadDataset(m *model.UploadDatasetRequest, userID uint
 			panic(p)
 		}
 	}()
+	// 一个对象只能对应一个数据集
+	if s.datasetDAO.CheckObjectNameUsed(tx, m.ObjectName) {
+		tx.Rollback()
+		return 0, ErrNotObjectOwner
+	}
 	datasetID, err := s.datasetDAO.UploadDataset(tx, m, bucket)
 	if err != nil {
 		tx.Rollback()
 		return 0, err
 	}
+	if err = s.tagDAO.SetDatasetTagsTX(tx, datasetID, util.ParseTags(m.Tags)); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	// 初始版本，文件校验通过前数据集不对外展示
+	version := &model.DatasetVersion{
+		DatasetID:  datasetID,
+		Version:    1,
+		ObjectName: m.ObjectName,
+		FileSize:   m.FileSize,
+		Format:     m.Format,
+		Checksum:   strings.ToLower(m.Checksum),
+		Changelog:  model.InitialVersionChangelog,
+		Status:     model.DatasetStatusValidating,
+	}
+	if err = s.datasetDAO.CreateVersionTX(tx, version); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if err = s.createValidationJobTX(tx, version); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	// 上传会话的文件已被使用，不再由清理任务删除；会话已过期或文件已被清理时拒绝
+	usable, err := s.uploadDAO.MarkUsedTX(tx, version.ObjectName)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if !usable {
+		tx.Rollback()
+		return 0, ErrUploadSessionClosed
+	}
 
 	if err = s.userStatsDAO.UpdateUserStatsTotalUploads(tx, userID); err != nil {
 		tx.Rollback()
@@ -109,20 +297,23 @@ func (s DatasetService) UploadDataset(m *model.UploadDatasetRequest, userID uint
 		tx.Rollback()
 		return 0, err
 	}
-	// 1. 上传成功后写入最近上传排行榜（score=当前时间戳）
-	// 2. 仅存最小字段，保持容量上限
-	_ = s.rankRedisDAO.AppendLatest(map[string]interface{}{
-		"id":       datasetID,
-		"title":    m.Title,
-		"category": m.Category,
-		"isFree":   m.IsFree,
-		"price":    m.Price,
-	}, time.Now().Unix(), util.RANK_DEFAULT_LIMIT)
+	// 最近上传排行榜在文件校验通过后写入
+
+	if dataset, err := s.datasetDAO.GetDataset(datasetID); err != nil {
+		util.Warn("查询数据集失败，未写入搜索索引", zap.Uint("datasetID", datasetID), zap.Error(err))
+	} else {
+		indexDataset(s.searchIndex, dataset)
+	}
 	return datasetID, nil
 }
 
 // 删除数据集和文件(minio、mongodb异步worker删除)
-func (s DatasetService) DeleteDataset(m *model.DeleteDatasetRequest) error {
+func (s DatasetService) DeleteDataset(m *model.DeleteDatasetRequest, userID uint) error {
+	walletAddress, role, err := s.datasetDAO.GetUserWalletAndRole(userID)
+	if err != nil {
+		return err
+	}
+
 	tx := s.datasetDAO.DB().Begin()
 	if tx.Error != nil {
 		return tx.Error
@@ -133,39 +324,63 @@ func (s DatasetService) DeleteDataset(m *model.DeleteDatasetRequest) error {
 			panic(p)
 		}
 	}()
+
+	// 校验数据集归属，对象名以数据库记录为准（管理员可删除任意数据集）
+	authorWalletAddress, objectName, err := s.datasetDAO.GetDatasetOwnerTX(tx, m.DatasetID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if role != util.ROLE_ADMIN && (walletAddress == "" || !strings.EqualFold(walletAddress, authorWalletAddress)) {
+		tx.Rollback()
+		return ErrNotDatasetOwner
+	}
+	if objectName != m.ObjectName {
+		tx.Rollback()
+		return ErrNotObjectOwner
+	}
 	// 删除数据集
 	if err := s.datasetDAO.DeleteDataset(tx, m.DatasetID); err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	// 删除预览数据
-	if err := s.datasetMongoDAO.DeletePreviewData(m.ObjectName); err != nil {
-		task := model.Outbox{
-			EventType: "delete_mongo_preview",
-			Payload:   fmt.Sprintf(`{"objectName": %s}`, m.ObjectName),
-			Status:    "pending",
-			CreatedAt: time.Now(),
-		}
-
-		if err = s.outboxDAO.InsertTask(tx, &task); err != nil {
-			tx.Rollback()
-			return err
-		}
+	// 全部版本的文件和预览数据一并删除
+	objectNames, err := s.datasetDAO.GetVersionObjectNamesTX(tx, m.DatasetID)
+	if err != nil {
+		tx.Rollback()
+		return err
 	}
+	if !slices.Contains(objectNames, objectName) {
+		objectNames = append(objectNames, objectName)
+	}
+	for _, name := range objectNames {
+		// 删除预览数据
+		if err := s.datasetMongoDAO.DeletePreviewData(name); err != nil {
+			task, err := model.NewOutbox(model.OutboxDeleteMongoPreview, model.ObjectNamePayload{ObjectName: name})
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
 
-	// 删除文件
-	if err := s.datasetMinioDAO.DeleteObject(m.ObjectName); err != nil {
-		task := model.Outbox{
-			EventType: "delete_minio_object",
-			Payload:   fmt.Sprintf(`{"objectName": "%s"}`, m.ObjectName),
-			Status:    "pending",
-			CreatedAt: time.Now(),
+			if err = s.outboxDAO.InsertTask(tx, task); err != nil {
+				tx.Rollback()
+				return err
+			}
 		}
 
-		if err = s.outboxDAO.InsertTask(tx, &task); err != nil {
-			tx.Rollback()
-			return err
+		// 删除文件
+		if err := s.datasetMinioDAO.DeleteObject(name); err != nil {
+			task, err := model.NewOutbox(model.OutboxDeleteMinioObject, model.ObjectNamePayload{ObjectName: name})
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			if err = s.outboxDAO.InsertTask(tx, task); err != nil {
+				tx.Rollback()
+				return err
+			}
 		}
 	}
 
@@ -173,65 +388,142 @@ func (s DatasetService) DeleteDataset(m *model.DeleteDatasetRequest) error {
 		tx.Rollback()
 		return err
 	}
+	unindexDataset(s.searchIndex, m.DatasetID)
 	return nil
 }
 
-// 获取数据集列表，支持分页、免费/付费、分类、搜索、文件大小等筛选
-func (s *DatasetService) ListDatasets(page, limit, isFree int, category, search, fileSizeRange, priceRange string) ([]model.DatasetListResponse, int64, int, error) {
-	filters := make(map[string]interface{})
-	if isFree == 1 {
-		filters["is_free"] = true
-	} else if isFree == 0 {
-		filters["is_free"] = false
+// 获取数据集列表，支持排序、游标/页码分页和筛选
+// 有关键词时默认按相关度排序（走搜索索引），也可指定其他排序方式，此时按全文检索条件筛选后排序
+func (s *DatasetService) ListDatasets(pq *model.PageQuery, q *model.DatasetQuery) ([]model.DatasetListResponse, model.PageInfo, error) {
+	// 公开列表只展示已通过文件校验的数据集
+	q.Status = model.DatasetStatusReady
+	if pq.Sort == "" && q.Text != "" {
+		pq.Sort = model.SortRelevance
 	}
-	if category != "" && category != "全部分类" {
-		filters["category"] = category
+	if err := normalizePageQuery(pq, 10); err != nil {
+		return nil, model.PageInfo{}, err
 	}
-	if search != "" {
-		filters["search"] = search
+	if pq.Sort == model.SortRelevance {
+		if q.Text == "" {
+			return nil, model.PageInfo{}, ErrInvalidSort
+		}
+		return s.listByRelevance(pq, q)
 	}
-	if fileSizeRange != "" && fileSizeRange != "all" {
-		filters["fileSizeRange"] = fileSizeRange
+	return s.datasetDAO.ListDatasets(pq, q)
+}
+
+// 按相关度分页：得分由搜索索引计算，无法按键定位，游标中记录页码，翻页实际按 OFFSET 进行，
+// 越往后越慢，且翻页期间数据变化时可能重复或遗漏；因此只提供前 maxRelevanceOffset 条结果，
+// 需要完整遍历时应改用其他排序方式（走键集分页）
+func (s *DatasetService) listByRelevance(pq *model.PageQuery, q *model.DatasetQuery) ([]model.DatasetListResponse, model.PageInfo, error) {
+	page := max(pq.Page, 1)
+	if pq.Cursor != nil {
+		if pq.Cursor.Page < 1 {
+			return nil, model.PageInfo{}, util.ErrInvalidCursor
+		}
+		page = pq.Cursor.Page
 	}
-	if priceRange != "" && priceRange != "all" {
-		filters["priceRange"] = priceRange
+	if (page-1)*pq.Limit >= maxRelevanceOffset {
+		return nil, model.PageInfo{}, ErrRelevanceTooDeep
 	}
-
-	if page < 1 {
-		page = 1
+	items, total, _, _, err := s.SearchDatasets(NewDatasetSearchQuery(q, page, pq.Limit))
+	if err != nil {
+		return nil, model.PageInfo{}, err
 	}
-	if limit < 1 {
-		limit = 10
+	datasets := make([]model.DatasetListResponse, 0, len(items))
+	for _, item := range items {
+		datasets = append(datasets, item.DatasetListResponse)
 	}
-	return s.datasetDAO.ListDatasets(page, limit, filters)
-}
 
-// 获取数据集详情
-func (s DatasetService) GetDatasetDetail(datasetId uint) (model.DatasetListResponse, error) {
-	return s.datasetDAO.GetDatasetDetail(datasetId)
+	info := model.PageInfo{HasMore: int64(page*pq.Limit) < total && page*pq.Limit < maxRelevanceOffset}
+	if info.HasMore {
+		info.NextCursor = util.EncodeCursor(model.PageCursor{Sort: model.SortRelevance, Page: page + 1})
+	}
+	if pq.WithTotal {
+		info.Total = &total
+	}
+	return datasets, info, nil
 }
 
 // 作者的付费数据集
-func (s DatasetService) GetAuthorPaidDatasets(authorWalletAddress string, page, limit int) ([]model.DatasetListResponse, int64, int, error) {
-	if page < 1 {
-		page = 1
+func (s DatasetService) GetAuthorPaidDatasets(authorWalletAddress string, q *model.PageQuery) ([]model.DatasetListResponse, model.PageInfo, error) {
+	if err := normalizePageQuery(q, 3); err != nil {
+		return nil, model.PageInfo{}, err
 	}
-	if limit < 1 {
-		limit = 3
+	if q.Sort == model.SortRelevance {
+		return nil, model.PageInfo{}, ErrInvalidSort
 	}
-	return s.datasetDAO.GetAuthorPaidDatasets(authorWalletAddress, page, limit)
+	return s.datasetDAO.GetAuthorPaidDatasets(authorWalletAddress, q)
 }
 
-// 获取预览数据
-func (s DatasetService) GetPreviewData(name string) (model.PreviewResponse, error) {
-	return s.datasetMongoDAO.GetPreviewData(name)
+var ErrPreviewNotFound = errors.New("预览数据不存在")
+
+// 预览数据每页条数
+const (
+	defaultPreviewLimit = 20
+	maxPreviewLimit     = 100
+)
+
+// 分页获取预览数据：优先返回文件校验时服务端生成的抽样记录；
+// 校验功能上线前的历史数据集没有抽样记录，返回卖家上传的预览
+func (s DatasetService) GetPreviewData(name string, page, limit int) (*model.PreviewPage, error) {
+	page = max(page, 1)
+	if limit <= 0 {
+		limit = defaultPreviewLimit
+	}
+	limit = min(limit, maxPreviewLimit)
+	skip := (page - 1) * limit
+
+	preview, err := s.datasetMongoDAO.GetPreviewData(name, skip, limit)
+	if errors.Is(err, mongo2.ErrNoDocuments) {
+		return nil, ErrPreviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := &model.PreviewPage{Source: model.PreviewSourceServer, Page: page, Limit: limit, Fields: []model.PreviewField{}}
+	if !preview.Generated {
+		res.Source = model.PreviewSourceSeller
+		res.Total = len(preview.PreviewData)
+		res.Items = preview.PreviewData[min(skip, res.Total):min(skip+limit, res.Total)]
+	} else {
+		res.Items = preview.Sample
+		res.Total = preview.SampleTotal
+		res.SampleHead = preview.SampleHead
+		res.Records = preview.Records
+		res.GeneratedAt = &preview.GeneratedAt
+		if preview.Fields != nil {
+			res.Fields = preview.Fields
+		}
+	}
+	if res.Items == nil {
+		res.Items = []string{}
+	}
+	res.TotalPages = (res.Total + limit - 1) / limit
+	return res, nil
 }
 
-// 下载免费数据集
-func (s DatasetService) GetDownloadURL(userID, datasetID uint) (string, error) {
+// 下载免费数据集，version 为 0 时下载最新版本；返回下载链接和文件校验值，签发链接前核对对象 ETag
+func (s DatasetService) GetDownloadURL(userID, datasetID uint, version int) (*model.DownloadURLResponse, error) {
+	// 付费数据集不能走免费下载
+	isFree, err := s.datasetDAO.GetDatasetIsFree(datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if !isFree {
+		return nil, ErrPurchaseRequired
+	}
+	file, err := s.getDownloadVersion(datasetID, version)
+	if err != nil {
+		return nil, err
+	}
+	if err = verifyObjectETag(context.Background(), s.datasetMinioDAO, file); err != nil {
+		return nil, err
+	}
+
 	tx := s.datasetDAO.DB().Begin()
 	if tx.Error != nil {
-		return "", tx.Error
+		return nil, tx.Error
 	}
 	defer func() {
 		if p := recover(); p != nil {
@@ -239,49 +531,101 @@ func (s DatasetService) GetDownloadURL(userID, datasetID uint) (string, error) {
 			panic(p)
 		}
 	}()
-	// 获取对象名
-	objectName, err := s.datasetDAO.GetObjectNameByDatasetIDTX(tx, datasetID)
-	if err != nil {
-		tx.Rollback()
-		return "", err
-	}
 	// 添加下载记录
-	if err = s.datasetDAO.AddDownloadRecord(tx, userID, datasetID); err != nil {
+	if _, err = s.datasetDAO.AddDownloadRecord(tx, userID, datasetID); err != nil {
 		tx.Rollback()
-		return "", err
+		return nil, err
 	}
 
 	// 更新下载次数
 	if err = s.datasetDAO.UpdateDownloadCount(tx, datasetID); err != nil {
 		tx.Rollback()
-		return "", err
+		return nil, err
 	}
 
 	// 更新用户统计数据
 	if err = s.userStatsDAO.UpdateUserStatsTotalDownloads(tx, userID); err != nil {
 		tx.Rollback()
-		return "", err
+		return nil, err
 	}
 
 	// 获取下载链接
-	url, err := s.datasetMinioDAO.GetDownloadFreeDatasetURL(objectName)
+	url, err := s.datasetMinioDAO.GetDownloadFreeDatasetURL(file.ObjectName)
 	if err != nil {
 		tx.Rollback()
-		return "", err
+		return nil, err
 	}
 	if err = tx.Commit().Error; err != nil {
 		tx.Rollback()
-		return "", err
+		return nil, err
+	}
+	return &model.DownloadURLResponse{
+		URL:      url,
+		Version:  file.Version,
+		FileSize: file.FileSize,
+		Checksum: file.Checksum,
+		MD5:      file.MD5,
+	}, nil
+}
+
+// 校验用户是否已购买数据集（数据库已完成交易 + 可选的链上校验）
+func (s DatasetService) VerifyPurchase(ctx context.Context, userId, datasetId uint) error {
+	walletAddress, err := s.getBoundWallet(userId)
+	if err != nil {
+		return err
 	}
-	return url, nil
+	purchased, err := s.datasetDAO.HasCompletedTransaction(walletAddress, datasetId)
+	if err != nil {
+		return err
+	}
+	if !purchased {
+		return ErrPurchaseRequired
+	}
+	if s.purchaseChecker == nil {
+		return nil
+	}
+	onChain, err := s.purchaseChecker.HasPurchased(ctx, datasetId, walletAddress)
+	if err != nil {
+		return err
+	}
+	if !onChain {
+		return ErrPurchaseNotOnChain
+	}
+	return nil
 }
 
-// 下载付费数据集
-func (s DatasetService) DownloadPaidDataset(userId, datasetId uint) (string, error) {
+// 下载付费数据集，version 为 0 时下载最新版本
+func (s DatasetService) DownloadPaidDataset(userId, datasetId uint, version int) (string, uint, error) {
 	ctx := context.Background()
+	if err := s.VerifyPurchase(ctx, userId, datasetId); err != nil {
+		return "", 0, err
+	}
+	// 获取源对象名和文件大小
+	file, err := s.getDownloadVersion(datasetId, version)
+	if err != nil {
+		util.Error("获取数据集版本失败", zap.Error(err))
+		return "", 0, err
+	}
+
+	// 同一用户同一数据集版本同时只有一个进行中的任务，重复请求直接复用
+	activeKey := fmt.Sprintf("%s:%d-%d-v%d", util.PAID_DOWNLOAD_TASK_ID, userId, datasetId, file.Version)
+	if job, err := s.downloadJobDAO.GetActiveJob(activeKey); err != nil {
+		return "", 0, err
+	} else if job != nil {
+		util.Info("复用进行中的下载任务", zap.Uint("jobId", job.ID), zap.Uint("datasetId", datasetId))
+		return job.SourceObject, job.ID, nil
+	}
+
+	// 指纹携带购买对应的交易记录 ID
+	transactionID, err := s.datasetDAO.GetCompletedTransactionID(userId, datasetId)
+	if err != nil {
+		util.Error("获取交易记录失败", zap.Error(err))
+		return "", 0, err
+	}
+
 	tx := s.datasetDAO.DB().Begin()
 	if tx.Error != nil {
-		return "", tx.Error
+		return "", 0, tx.Error
 	}
 	defer func() {
 		if p := recover(); p != nil {
@@ -289,202 +633,287 @@ func (s DatasetService) DownloadPaidDataset(userId, datasetId uint) (string, err
 			panic(p)
 		}
 	}()
-	// 添加下载记录
-	if err := s.datasetDAO.AddDownloadRecord(tx, userId, datasetId); err != nil {
+
+	// 创建下载任务，由 DownloadJobService 的 worker 异步处理
+	// active_key 唯一，并发请求只有一个能插入成功，其余复用已有任务
+	job := &model.DownloadJob{
+		UserID:       userId,
+		DatasetID:    datasetId,
+		Version:      file.Version,
+		ActiveKey:    &activeKey,
+		SourceObject: file.ObjectName,
+		SourceETag:   file.ETag,
+		SourceSHA256: file.Checksum,
+		FileSize:     file.FileSize,
+		Format:       file.Format,
+		Fingerprint:  util.NewFingerprintV2(uint64(transactionID)),
+	}
+	if err := s.downloadJobDAO.CreateJobTX(tx, job); err != nil {
 		tx.Rollback()
-		return "", err
+		if active, aerr := s.downloadJobDAO.GetActiveJob(activeKey); aerr == nil && active != nil {
+			util.Info("复用进行中的下载任务", zap.Uint("jobId", active.ID), zap.Uint("datasetId", datasetId))
+			return active.SourceObject, active.ID, nil
+		}
+		util.Error("创建下载任务失败", zap.Error(err))
+		return "", 0, err
 	}
-
-	// 更新下载次数
-	if err := s.datasetDAO.UpdateDownloadCount(tx, datasetId); err != nil {
+	job.TempObject = s.datasetMinioDAO.GenerateTempObjectName(userId, job.ID, file.ObjectName)
+	if err := s.downloadJobDAO.SetTempObjectTX(tx, job.ID, job.TempObject); err != nil {
 		tx.Rollback()
-		return "", err
+		return "", 0, err
 	}
 
-	// 更新用户统计数据
-	if err := s.userStatsDAO.UpdateUserStatsTotalDownloads(tx, userId); err != nil {
+	if err := s.recordPaidDownloadTX(tx, userId, datasetId); err != nil {
 		tx.Rollback()
-		return "", err
+		return "", 0, err
 	}
 
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
-		return "", err
+		return "", 0, err
 	}
 
-	urlKey := fmt.Sprintf("%s:%d-%d:url", util.PAID_DOWNLOAD_TASK_ID, userId, datasetId)
-	if url, err := s.datasetRedisDAO.GetDownloadURL(ctx, urlKey); err == nil {
-		util.Info("已缓存下载链接", zap.String("url", url))
-		return url, nil
-	}
+	util.Info("已创建下载任务", zap.Uint("jobId", job.ID), zap.Uint("datasetId", datasetId), zap.String("objectName", file.ObjectName))
+	return file.ObjectName, job.ID, nil
+}
 
-	// 获取源对象名和文件大小
-	objectName, fileSize, err := s.datasetDAO.GetObjectNameAndFileSizeByDatasetID(datasetId)
-	if err != nil {
-		util.Error("获取对象名和文件大小失败", zap.Error(err))
-		return "", err
+// 添加下载记录 TX，首次下载该数据集时才计入数据集下载次数和用户下载统计
+func (s DatasetService) recordPaidDownloadTX(tx *gorm.DB, userId, datasetId uint) error {
+	firstDownload, err := s.datasetDAO.AddDownloadRecord(tx, userId, datasetId)
+	if err != nil || !firstDownload {
+		return err
 	}
-
-	// 获取交易时间戳
-	timestamp, err := s.datasetDAO.GetTransactionTimestamp(userId, datasetId)
-	if err != nil {
-		util.Error("获取交易时间戳失败", zap.Error(err))
-		return "", err
+	// 更新下载次数
+	if err := s.datasetDAO.UpdateDownloadCount(tx, datasetId); err != nil {
+		return err
 	}
+	// 更新用户统计数据
+	return s.userStatsDAO.UpdateUserStatsTotalDownloads(tx, userId)
+}
 
-	// 获取源文件 Reader
-	srcReader, err := s.datasetMinioDAO.GetDatesetReader(ctx, objectName)
+// 流式下载信息
+type PaidStreamInfo struct {
+	UserID      uint
+	DatasetID   uint
+	ObjectName  string
+	Format      string
+	FileName    string
+	Fingerprint string
+	Size        int64  // 带指纹后的字节数，未缓存时为 -1，需完整下载一次后才可知
+	Checksum    string // 带指纹文件的 SHA-256，买家可据此核对下载结果；未缓存时在完整下载后得到
+	ETag        string // 同一买家同一版本的输出不变，可用于断点续传校验
+
+	sourceETag   string // 源文件校验时的 ETag 和 SHA-256，读取时核对，历史版本为空
+	sourceSHA256 string
+	sizeKey      string // 大小和 SHA-256 的缓存 key
+	checksumKey  string
+}
+
+// 准备流式下载：校验购买并读取缓存的带指纹文件大小和 SHA-256，version 为 0 时下载最新版本
+// 指纹插入位置取决于每行内容，大小只能完整生成一遍得到；未缓存时不在请求中预先计算，由首次完整下载顺带计算并缓存
+func (s DatasetService) PreparePaidStream(ctx context.Context, userId, datasetId uint, version int) (*PaidStreamInfo, error) {
+	if err := s.VerifyPurchase(ctx, userId, datasetId); err != nil {
+		return nil, err
+	}
+	file, err := s.getDownloadVersion(datasetId, version)
 	if err != nil {
-		util.Error("获取源文件失败", zap.Error(err))
-		return "", err
+		util.Error("获取数据集版本失败", zap.Error(err))
+		return nil, err
 	}
-	key := fmt.Sprintf("%s:%d-%d", util.PAID_DOWNLOAD_TASK_ID, userId, datasetId)
-	// 初始化进度
-	if err = s.datasetRedisDAO.RecordDownloadTask(ctx, key, 0); err != nil {
-		util.Error("初始化下载任务进度失败", zap.Error(err))
-		return "", err
+	// 指纹携带购买对应的交易记录 ID
+	transactionID, err := s.datasetDAO.GetCompletedTransactionID(userId, datasetId)
+	if err != nil {
+		util.Error("获取交易记录失败", zap.Error(err))
+		return nil, err
+	}
+
+	info := &PaidStreamInfo{
+		UserID:       userId,
+		DatasetID:    datasetId,
+		ObjectName:   file.ObjectName,
+		Format:       file.Format,
+		FileName:     fmt.Sprintf("dataset_%d_v%d%s", datasetId, file.Version, filepath.Ext(file.ObjectName)),
+		Fingerprint:  util.NewFingerprintV2(uint64(transactionID)),
+		sourceETag:   file.ETag,
+		sourceSHA256: file.Checksum,
+	}
+	// 指纹携带交易记录 ID，ETag 中只放其哈希，避免在响应头中暴露
+	fpHash := sha256.Sum256([]byte(info.Fingerprint))
+	info.ETag = fmt.Sprintf(`"%d-v%d-%s-%d"`, datasetId, file.Version, hex.EncodeToString(fpHash[:8]), file.FileSize)
+
+	info.sizeKey = fmt.Sprintf("%s:%s:%s", util.PAID_STREAM_SIZE, file.ObjectName, info.Fingerprint)
+	info.checksumKey = fmt.Sprintf("%s:%s:%s", util.PAID_STREAM_CHECKSUM, file.ObjectName, info.Fingerprint)
+	info.Size = -1
+	if size, err := s.datasetRedisDAO.GetStreamSize(ctx, info.sizeKey); err == nil {
+		if checksum, err := s.datasetRedisDAO.GetStreamChecksum(ctx, info.checksumKey); err == nil {
+			info.Size, info.Checksum = size, checksum
+		}
 	}
-	cr := &util.CountingReader{R: srcReader}
-
-	// pipe 用于流式处理：producer -> pw 写入，uploader 从 pr 读取上传
-	pr, pw := io.Pipe()
+	return info, nil
+}
 
-	// 计算每 1% 更新步长
-	var updateStep int64
-	if fileSize <= 0 {
-		updateStep = 1
-	} else {
-		updateStep = fileSize / 100
-		if updateStep == 0 {
-			updateStep = 1
+// 将带指纹文件的 [start, end] 区间写入 w，从头开始的请求记为一次下载
+// 大小未知时（info.Size < 0）忽略区间写入完整文件，完成后填充并缓存 info.Size 和 info.Checksum
+func (s DatasetService) StreamPaidDataset(ctx context.Context, info *PaidStreamInfo, w io.Writer, start, end int64) error {
+	if start == 0 {
+		tx := s.datasetDAO.DB().Begin()
+		if tx.Error != nil {
+			return tx.Error
+		}
+		if err := s.recordPaidDownloadTX(tx, info.UserID, info.DatasetID); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit().Error; err != nil {
+			tx.Rollback()
+			return err
 		}
 	}
 
-	// Producer：边读边插指纹，写入 pipe
-	go func() {
-		defer func() {
-			_ = pw.Close()
-			if closer, ok := srcReader.(io.Closer); ok {
-				_ = closer.Close()
-			}
-		}()
-
-		br := bufio.NewReader(cr)
-		lineIndex := 0
-		lastBytes := int64(0)
-		var localProgress uint = 0 // 0-99
-
-		for {
-			line, err := br.ReadString('\n')
-			if len(line) > 0 {
-				// 插入指纹
-				pos := lineIndex % util.FINGERPRINT_GROUP_SIZE
-				processedLine, ierr := util.InsertFingerprint(line, strconv.FormatInt(timestamp, 10), pos)
-				if ierr != nil {
-					_ = pw.CloseWithError(ierr)
-					_ = s.datasetRedisDAO.RecordDownloadTask(ctx, key, 0)
-					util.Error("插入指纹失败，终止处理", zap.Error(ierr))
-					return
-				}
-
-				// 写入 pipe
-				if _, ierr = pw.Write([]byte(processedLine)); ierr != nil {
-					_ = pw.CloseWithError(ierr)
-					_ = s.datasetRedisDAO.RecordDownloadTask(ctx, key, 0)
-					util.Error("写 pipe 失败，终止处理", zap.Error(ierr))
-					return
-				}
-				lineIndex++
-
-				// 按字节更新进度
-				if cr.Count-lastBytes >= updateStep {
-					lastBytes = cr.Count
-					if localProgress < 99 { // 保留 100 给上传完成标识
-						localProgress++
-						if rerr := s.datasetRedisDAO.RecordDownloadTask(ctx, key, localProgress); rerr != nil {
-							_ = pw.CloseWithError(rerr)
-							util.Error("记录下载任务进度失败", zap.Error(rerr))
-							return
-						}
-					}
-				}
-			}
-
-			if err == io.EOF {
-				return
-			}
-			if err != nil {
-				_ = pw.CloseWithError(err)
-				_ = s.datasetRedisDAO.RecordDownloadTask(ctx, key, 0)
-				util.Error("读取源文件失败，终止处理", zap.Error(err))
-				return
-			}
-		}
-	}()
-	// 上传到 minio 临时桶
-	go func() {
-		err := s.datasetMinioDAO.UploadDatasetToTempBucket(ctx, pr, objectName)
+	if info.Size < 0 {
+		output := sha256.New()
+		n, err := s.writePaidStream(ctx, info, io.MultiWriter(w, output))
 		if err != nil {
-			util.Error("上传到 minio 临时桶失败", zap.Error(err))
-			_ = s.datasetRedisDAO.RecordDownloadTask(ctx, key, 0)
-			return
+			return err
 		}
-
-		util.Info("数据集上传到临时桶完成", zap.String("objectName", objectName))
-		if rerr := s.datasetRedisDAO.RecordDownloadTask(ctx, key, 100); rerr != nil {
-			util.Error("记录下载任务进度(100)失败", zap.Error(rerr))
-			return
+		info.Size, info.Checksum = n, hex.EncodeToString(output.Sum(nil))
+		if err := s.datasetRedisDAO.SetStreamSize(ctx, info.sizeKey, info.Size); err != nil {
+			util.Warn("缓存流式下载大小失败", zap.Error(err))
 		}
+		if err := s.datasetRedisDAO.SetStreamChecksum(ctx, info.checksumKey, info.Checksum); err != nil {
+			util.Warn("缓存流式下载 SHA-256 失败", zap.Error(err))
+		}
+		return nil
+	}
 
-		util.Info("数据集上传到临时桶完成", zap.String("objectName", objectName))
-	}()
-
-	// 1. 热门排行榜由定时任务维护，不做实时更新
-	// 2. 其余处理流程保持不变
-	util.Info("已异步开始处理下载任务", zap.String("datasetId", fmt.Sprintf("%d", datasetId)), zap.String("objectName", objectName))
-	return objectName, nil
+	_, err := s.writePaidStream(ctx, info, &util.RangeWriter{W: w, Start: start, End: end})
+	if errors.Is(err, util.ErrRangeDone) {
+		return nil
+	}
+	return err
 }
 
-// 获取下载进度
-func (s DatasetService) GetDownloadStatus(userId, datasetId uint, objectName string) (string, string, error) {
-	ctx := context.Background()
-	key := fmt.Sprintf("%s:%d-%d", util.PAID_DOWNLOAD_TASK_ID, userId, datasetId)
-	fmt.Println("获取去下载进度：GetDownloadStatus = ", key)
-	progress, err := s.datasetRedisDAO.GetDownloadTask(ctx, key)
-	fmt.Println("service progress = ", progress)
+// 读取源文件，插入指纹后写入 w；完整读取时核对源文件的 SHA-256
+func (s DatasetService) writePaidStream(ctx context.Context, info *PaidStreamInfo, w io.Writer) (int64, error) {
+	srcReader, err := s.datasetMinioDAO.GetDatesetReader(ctx, info.ObjectName, info.sourceETag)
 	if err != nil {
-		return "", "", err
+		return 0, err
 	}
-
-	if progress == "" {
-		return "", "", errors.New("progress 不存在")
+	if closer, ok := srcReader.(io.Closer); ok {
+		defer closer.Close()
 	}
-
-	if progress != "100" {
-		return "", progress, nil
+	verifier := newSourceVerifier(srcReader, info.sourceSHA256)
+	n, err := util.WriteWithFingerprint(w, verifier, info.Format, info.Fingerprint, func(int64) error {
+		return ctx.Err()
+	})
+	if err == nil {
+		err = verifier.Verify()
 	}
+	return n, integrityError(err)
+}
 
-	url, err := s.datasetMinioDAO.GetTempBucketPresignedURL(ctx, objectName)
+// 获取下载任务状态，jobID 为 0 时查询该数据集最近一次任务
+func (s DatasetService) GetDownloadStatus(userId, datasetId, jobID uint) (*model.DownloadStatusResponse, error) {
+	var job *model.DownloadJob
+	var err error
+	if jobID != 0 {
+		job, err = s.downloadJobDAO.GetJob(jobID)
+	} else {
+		job, err = s.downloadJobDAO.GetLatestJob(userId, datasetId)
+	}
 	if err != nil {
-		return "", progress, err
+		return nil, err
+	}
+	if job == nil || job.UserID != userId || job.DatasetID != datasetId {
+		return nil, ErrDownloadJobNotFound
 	}
 
+	res := &model.DownloadStatusResponse{
+		JobID:    job.ID,
+		State:    job.Status,
+		Progress: job.Progress,
+		Checksum: job.Checksum,
+		Error:    job.Error,
+	}
+	if job.Status != model.DownloadJobDone {
+		return res, nil
+	}
+	res.Status = 1
+
 	// 缓存url
-	urlKey := fmt.Sprintf("%s:%d-%d:url", util.PAID_DOWNLOAD_TASK_ID, userId, datasetId)
+	ctx := context.Background()
+	urlKey := fmt.Sprintf("%s:%d:url", util.PAID_DOWNLOAD_TASK_ID, job.ID)
+	if url, err := s.datasetRedisDAO.GetDownloadURL(ctx, urlKey); err == nil {
+		res.URL = url
+		return res, nil
+	}
+	url, err := s.datasetMinioDAO.GetTempBucketPresignedURL(ctx, job.TempObject)
+	if err != nil {
+		return nil, err
+	}
 	if err = s.datasetRedisDAO.SetDownloadURL(ctx, urlKey, url); err != nil {
-		return "", progress, err
+		util.Warn("缓存下载链接失败", zap.Error(err))
 	}
+	res.URL = url
+	return res, nil
+}
 
-	return url, progress, nil
+// 取消下载任务（排队中或执行中的任务）
+func (s DatasetService) CancelDownloadJob(userId, jobID uint) error {
+	job, err := s.downloadJobDAO.GetJob(jobID)
+	if err != nil {
+		return err
+	}
+	if job == nil || job.UserID != userId {
+		return ErrDownloadJobNotFound
+	}
+	ok, err := s.downloadJobDAO.MarkFailed(jobID, ErrDownloadJobCanceled.Error())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrDownloadJobNotFound
+	}
+	return nil
+}
+
+// 获取作者的数据集（仅本人或管理员可查看全部数据集）
+func (s DatasetService) GetAuthorDatasets(userID uint, address string, q *model.PageQuery) ([]model.DatasetListResponse, model.PageInfo, error) {
+	walletAddress, role, err := s.datasetDAO.GetUserWalletAndRole(userID)
+	if err != nil {
+		return nil, model.PageInfo{}, err
+	}
+	if address == "" {
+		address = walletAddress
+	}
+	if role != util.ROLE_ADMIN && (walletAddress == "" || !strings.EqualFold(walletAddress, address)) {
+		return nil, model.PageInfo{}, ErrNotDatasetOwner
+	}
+	if err := normalizePageQuery(q, 5); err != nil {
+		return nil, model.PageInfo{}, err
+	}
+	if q.Sort == model.SortRelevance {
+		return nil, model.PageInfo{}, ErrInvalidSort
+	}
+	return s.datasetDAO.GetAuthorDatasets(address, q)
 }
 
-// 获取作者的数据集
-func (s DatasetService) GetAuthorDatasets(address string, page int, limit int) ([]model.DatasetListResponse, int64, int, error) {
-	if page < 1 {
-		page = 1
+// 获取用户已绑定的钱包地址
+func (s DatasetService) getBoundWallet(userID uint) (string, error) {
+	walletAddress, _, err := s.datasetDAO.GetUserWalletAndRole(userID)
+	if err != nil {
+		return "", err
 	}
-	if limit < 1 {
-		limit = 5
+	if walletAddress == "" {
+		return "", ErrWalletNotBound
 	}
-	return s.datasetDAO.GetAuthorDatasets(address, page, limit)
+	return walletAddress, nil
+}
+
+// 校验对象名归属（对象名格式：<userID>_<时间戳><扩展名>）
+func checkObjectOwner(userID uint, objectName string) error {
+	if strings.Contains(objectName, "/") || !strings.HasPrefix(objectName, fmt.Sprintf("%d_", userID)) {
+		return ErrNotObjectOwner
+	}
+	return nil
 }
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/internal/util"
+	"errors"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDuplicateNotFound = errors.New("重复文件记录不存在")
+	ErrDuplicateReviewed = errors.New("重复文件记录已审核")
+)
+
+// 获取重复文件记录（其他卖家已上传过相同文件的版本）
+func (s AdminService) GetDuplicates(q *model.DuplicateQuery) ([]model.DuplicateResponse, int64, int, error) {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.Limit < 1 {
+		q.Limit = 10
+	}
+	return s.adminDAO.GetDuplicates(q)
+}
+
+// 审核重复文件记录：confirm 确认为重复上传，数据集下架（status 置为 rejected，已购买用户仍可下载）；dismiss 标记为误报
+// 只能审核待审核的记录
+func (s AdminService) ReviewDuplicate(adminID, id uint, req *model.ReviewDuplicateRequest) (*model.DatasetDuplicate, error) {
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	dup, err := s.adminDAO.LockDuplicateTX(tx, id)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDuplicateNotFound
+		}
+		return nil, err
+	}
+	if dup.Status != model.DuplicatePending {
+		tx.Rollback()
+		return nil, ErrDuplicateReviewed
+	}
+
+	status := model.DuplicateDismissed
+	if req.Action == "confirm" {
+		status = model.DuplicateConfirmed
+		if err = s.adminDAO.UpdateDatasetStatusTX(tx, dup.DatasetID, model.DatasetStatusRejected); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err = s.adminDAO.ReviewDuplicateTX(tx, id, status, adminID, req.Note); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	util.Info("重复文件审核完成", zap.Uint("id", id), zap.Uint("datasetId", dup.DatasetID), zap.String("status", status), zap.Uint("adminId", adminID))
+	if status == model.DuplicateConfirmed {
+		// 下架后从搜索结果中移除（索引按状态过滤）
+		var dataset model.Dataset
+		if err := s.db.First(&dataset, dup.DatasetID).Error; err == nil {
+			indexDataset(s.searchIndex, &dataset)
+		} else {
+			util.Warn("查询数据集失败，未更新搜索索引", zap.Uint("datasetID", dup.DatasetID), zap.Error(err))
+		}
+	}
+
+	var reviewed model.DatasetDuplicate
+	if err := s.db.First(&reviewed, id).Error; err != nil {
+		return nil, err
+	}
+	return &reviewed, nil
+}
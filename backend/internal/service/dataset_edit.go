@@ -0,0 +1,238 @@
+package service
+
+import (
+	"backend/internal/dao/redis"
+	"backend/internal/model"
+	"backend/internal/util"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 修改数据集参数错误，controller 层映射为 400
+var (
+	ErrInvalidDatasetEdit = errors.New("数据集修改参数错误")
+	ErrNoDatasetChanges   = errors.New("没有需要修改的字段")
+)
+
+// 修改数据集元数据（仅作者本人）
+// 标题、描述、分类、标签、许可证立即生效；付费数据集的价格以合约为准，修改价格或免费改付费时
+// 先记为待上链，由卖家调用合约后经链上事件同步生效。已上链的付费数据集不能改为免费（合约不支持）
+func (s DatasetService) UpdateDataset(datasetID uint, req *model.UpdateDatasetRequest, userID uint) (*model.UpdateDatasetResponse, error) {
+	walletAddress, err := s.getBoundWallet(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	ds, err := s.datasetDAO.LockDatasetTX(tx, datasetID)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDatasetNotFound
+		}
+		return nil, err
+	}
+	if !strings.EqualFold(ds.AuthorWalletAddress, walletAddress) {
+		tx.Rollback()
+		return nil, ErrNotDatasetOwner
+	}
+
+	applied, pending, err := planDatasetEdit(ds, req)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	appliedChanges := diffDataset(ds, applied)
+	pendingChanges := diffDataset(ds, pending)
+	if len(appliedChanges) == 0 && len(pendingChanges) == 0 {
+		tx.Rollback()
+		return nil, ErrNoDatasetChanges
+	}
+
+	// 付费数据集链上保存标题、描述和价格，任一变化都需要卖家调用合约同步
+	var chainAction string
+	switch {
+	case ds.IsFree && len(pendingChanges) > 0:
+		chainAction = model.ChainActionCreateDataset
+	case !ds.IsFree && (len(pendingChanges) > 0 || appliedChanges["title"] != nil || appliedChanges["description"] != nil):
+		chainAction = model.ChainActionUpdateDataset
+	}
+
+	if len(appliedChanges) > 0 {
+		updates := make(map[string]interface{}, len(appliedChanges))
+		for column, change := range appliedChanges {
+			updates[column] = change.New
+		}
+		if err = s.datasetDAO.UpdateDatasetFieldsTX(tx, datasetID, updates); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if change, ok := appliedChanges["tags"]; ok {
+		if err = s.tagDAO.SetDatasetTagsTX(tx, datasetID, util.ParseTags(change.New.(string))); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	edit := &model.DatasetEdit{
+		DatasetID: datasetID,
+		UserID:    &userID,
+		Source:    model.DatasetEditSourceUser,
+		Status:    model.DatasetEditApplied,
+	}
+	if len(pendingChanges) > 0 {
+		if err = s.datasetDAO.SupersedePendingEditsTX(tx, datasetID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		price := pending["price"].(float64)
+		edit.PendingPrice = &price
+		edit.Status = model.DatasetEditPendingChain
+		for column, change := range pendingChanges {
+			appliedChanges[column] = change
+		}
+	}
+	if edit.Changes, err = json.Marshal(appliedChanges); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err = s.datasetDAO.CreateEditTX(tx, edit); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// 同步搜索索引和排行榜快照
+	if dataset, err := s.datasetDAO.GetDataset(datasetID); err != nil {
+		util.Warn("查询数据集失败，未更新搜索索引和排行榜", zap.Uint("datasetID", datasetID), zap.Error(err))
+	} else {
+		indexDataset(s.searchIndex, dataset)
+		updateRankSnapshot(s.rankRedisDAO, dataset)
+	}
+
+	detail, err := s.datasetDAO.GetDatasetDetail(datasetID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.UpdateDatasetResponse{Dataset: detail, Edit: edit, ChainAction: chainAction}, nil
+}
+
+// 校验修改请求，拆分为立即生效的字段和等待链上确认的字段（price、is_free）
+func planDatasetEdit(ds *model.Dataset, req *model.UpdateDatasetRequest) (applied, pending map[string]interface{}, err error) {
+	applied = make(map[string]interface{})
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			return nil, nil, fmt.Errorf("%w: 标题不能为空", ErrInvalidDatasetEdit)
+		}
+		applied["title"] = title
+	}
+	if req.Description != nil {
+		applied["description"] = strings.TrimSpace(*req.Description)
+	}
+	if req.Category != nil {
+		category := strings.TrimSpace(*req.Category)
+		if category == "" {
+			return nil, nil, fmt.Errorf("%w: 分类不能为空", ErrInvalidDatasetEdit)
+		}
+		applied["category"] = category
+	}
+	if req.Tags != nil {
+		applied["tags"] = strings.Join(util.ParseTags(*req.Tags), ",")
+	}
+	if req.License != nil {
+		license := strings.TrimSpace(*req.License)
+		if license == "" {
+			return nil, nil, fmt.Errorf("%w: 许可证不能为空", ErrInvalidDatasetEdit)
+		}
+		applied["license"] = license
+	}
+
+	isFree := ds.IsFree
+	if req.IsFree != nil {
+		isFree = *req.IsFree
+	}
+	price := ds.Price
+	if req.Price != nil {
+		// 与 decimal(10,2) 列精度一致
+		price = math.Round(*req.Price*100) / 100
+	}
+	switch {
+	case isFree && !ds.IsFree:
+		return nil, nil, fmt.Errorf("%w: 已上链的付费数据集不能改为免费", ErrInvalidDatasetEdit)
+	case isFree:
+		if req.Price != nil && price > 0 {
+			return nil, nil, fmt.Errorf("%w: 免费数据集不能设置价格", ErrInvalidDatasetEdit)
+		}
+	case price <= 0:
+		return nil, nil, fmt.Errorf("%w: 付费数据集价格必须大于 0", ErrInvalidDatasetEdit)
+	case ds.IsFree:
+		pending = map[string]interface{}{"price": price, "is_free": false}
+	default:
+		pending = map[string]interface{}{"price": price}
+	}
+	return applied, pending, nil
+}
+
+// 对比数据集当前值，返回实际变化的字段
+func diffDataset(ds *model.Dataset, updates map[string]interface{}) map[string]*model.FieldChange {
+	changes := make(map[string]*model.FieldChange, len(updates))
+	for column, value := range updates {
+		var old interface{}
+		switch column {
+		case "title":
+			old = ds.Title
+		case "description":
+			old = ds.Description
+		case "category":
+			old = ds.Category
+		case "tags":
+			old = ds.Tags
+		case "price":
+			old = ds.Price
+		case "is_free":
+			old = ds.IsFree
+		case "license":
+			old = ds.License
+		default:
+			continue
+		}
+		if old != value {
+			changes[column] = &model.FieldChange{Old: old, New: value}
+		}
+	}
+	return changes
+}
+
+// 更新排行榜中的数据集快照（与 HomeService 刷新榜单时写入的字段一致）
+func updateRankSnapshot(rank *redis.RankRedisDAO, dataset *model.Dataset) {
+	err := rank.UpdateDataset(dataset.ID, map[string]interface{}{
+		"title":    dataset.Title,
+		"category": dataset.Category,
+		"isFree":   dataset.IsFree,
+		"price":    dataset.Price,
+	})
+	if err != nil {
+		util.Warn("更新排行榜失败", zap.Uint("datasetID", dataset.ID), zap.Error(err))
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"backend/internal/dao/minio"
+	"backend/internal/model"
+	"backend/internal/util"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"strings"
+
+	minio2 "github.com/minio/minio-go/v7"
+	"go.uber.org/zap"
+)
+
+// 数据集文件与校验时记录的 ETag 或 SHA-256 不一致（对象被替换或损坏），controller 层映射为 500
+var ErrFileIntegrity = errors.New("数据集文件完整性校验失败，请联系管理员")
+
+// 核对 MinIO 对象的 ETag 与版本校验时记录的是否一致，历史版本没有记录时跳过
+func verifyObjectETag(ctx context.Context, dao *minio.DatasetMinioDAO, file *model.DatasetVersion) error {
+	if file.ETag == "" {
+		return nil
+	}
+	etag, err := dao.GetObjectETag(ctx, file.ObjectName)
+	if err != nil {
+		return err
+	}
+	if strings.Trim(etag, `"`) != strings.Trim(file.ETag, `"`) {
+		util.Error("数据集文件 ETag 与校验记录不一致", zap.Uint("datasetId", file.DatasetID), zap.Int("version", file.Version),
+			zap.String("etag", etag), zap.String("expected", file.ETag))
+		return ErrFileIntegrity
+	}
+	return nil
+}
+
+// 读取源文件时的 ETag 前置条件不满足说明对象已被替换
+func integrityError(err error) error {
+	if err != nil && minio2.ToErrorResponse(err).Code == "PreconditionFailed" {
+		return ErrFileIntegrity
+	}
+	return err
+}
+
+// 源文件校验：读取时计算 SHA-256，读完后与版本记录的值核对，历史版本没有记录时跳过
+type sourceVerifier struct {
+	r    io.Reader
+	hash hash.Hash
+	want string
+}
+
+func newSourceVerifier(r io.Reader, checksum string) *sourceVerifier {
+	h := sha256.New()
+	return &sourceVerifier{r: io.TeeReader(r, h), hash: h, want: checksum}
+}
+
+func (v *sourceVerifier) Read(p []byte) (int, error) {
+	return v.r.Read(p)
+}
+
+// 读完剩余内容（指纹写入可能在文件末尾之前结束）并核对 SHA-256
+func (v *sourceVerifier) Verify() error {
+	if v.want == "" {
+		return nil
+	}
+	if _, err := io.Copy(io.Discard, v.r); err != nil {
+		return integrityError(err)
+	}
+	if got := hex.EncodeToString(v.hash.Sum(nil)); !strings.EqualFold(got, v.want) {
+		util.Error("数据集源文件 SHA-256 与校验记录不一致", zap.String("sha256", got), zap.String("expected", v.want))
+		return ErrFileIntegrity
+	}
+	return nil
+}
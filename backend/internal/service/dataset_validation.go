@@ -0,0 +1,421 @@
+package service
+
+import (
+	"backend/internal/dao/minio"
+	"backend/internal/dao/mongo"
+	"backend/internal/dao/mysql"
+	"backend/internal/dao/redis"
+	"backend/internal/dao/search"
+	"backend/internal/model"
+	"backend/internal/util"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	minio2 "github.com/minio/minio-go/v7"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrValidationNotFound     = errors.New("校验记录不存在")
+	ErrDatasetVersionNotReady = errors.New("数据集版本尚未通过文件校验")
+	errValidationCanceled     = errors.New("校验任务已结束")
+)
+
+// 执行租约，任务执行期间（含写入结论）由心跳每 lease/3 续约一次；读取失败的任务不主动释放，租约过期后重新认领
+const validationJobLease = 2 * time.Minute
+
+// 单个任务最多执行次数（含崩溃后重新认领），超过后版本判定为未通过
+const validationJobMaxAttempts = 3
+
+// 校验报告：文件概况 + 与上传声明、预览数据的核对结果 + 未通过原因
+type validationReport struct {
+	*util.DatasetProfile
+	ETag             string           `json:"etag"` // 读取时 MinIO 对象的 ETag
+	DeclaredSize     int64            `json:"declaredSize"`
+	DeclaredChecksum string           `json:"declaredChecksum,omitempty"`
+	ErrorRate        float64          `json:"errorRate"`                // 格式错误或编码问题的记录占比
+	PreviewMatched   *bool            `json:"previewMatched,omitempty"` // 卖家上传的预览数据是否与文件开头一致，没有预览时为空
+	DuplicateOf      *duplicateSource `json:"duplicateOf,omitempty"`    // 其他卖家已上传相同文件时为最早的版本，等待管理员审核
+	Reasons          []string         `json:"reasons"`                  // 未通过原因，通过时为空
+}
+
+// 重复文件的原始版本
+type duplicateSource struct {
+	DatasetID uint `json:"datasetId"`
+	VersionID uint `json:"versionId"`
+	Version   int  `json:"version"`
+}
+
+// 数据集文件校验 worker 池：认领 dataset_validations 中的任务，流式读取 MinIO 对象生成校验报告，
+// 并将版本和数据集标记为 ready 或 rejected
+type DatasetValidationService struct {
+	validationDAO   *mysql.DatasetValidationDAO
+	datasetDAO      *mysql.DatasetDAO
+	datasetMongoDAO *mongo.DatasetsPreviewDAO
+	adminMinioDAO   *minio.AdminMinioDAO
+	rankRedisDAO    *redis.RankRedisDAO
+	searchIndex     search.SearchIndex
+	bucket          string
+	workers         int
+	jobTimeout      time.Duration
+	pollInterval    time.Duration
+	maxErrorRate    float64
+}
+
+func NewDatasetValidationService(validationDAO *mysql.DatasetValidationDAO, datasetDAO *mysql.DatasetDAO, datasetMongoDAO *mongo.DatasetsPreviewDAO,
+	adminMinioDAO *minio.AdminMinioDAO, rankRedisDAO *redis.RankRedisDAO, searchIndex search.SearchIndex, bucket string,
+	workers int, jobTimeout, pollInterval time.Duration, maxErrorRate float64) *DatasetValidationService {
+	if workers <= 0 {
+		workers = 2
+	}
+	if jobTimeout <= 0 {
+		jobTimeout = 60 * time.Minute
+	}
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	if maxErrorRate <= 0 {
+		maxErrorRate = 0.01
+	}
+	return &DatasetValidationService{
+		validationDAO:   validationDAO,
+		datasetDAO:      datasetDAO,
+		datasetMongoDAO: datasetMongoDAO,
+		adminMinioDAO:   adminMinioDAO,
+		rankRedisDAO:    rankRedisDAO,
+		searchIndex:     searchIndex,
+		bucket:          bucket,
+		workers:         workers,
+		jobTimeout:      jobTimeout,
+		pollInterval:    pollInterval,
+		maxErrorRate:    maxErrorRate,
+	}
+}
+
+// 启动 worker 池，直到 ctx 结束；结束时等待执行中的任务退出并重新排队
+func (s DatasetValidationService) Run(ctx context.Context) {
+	leaseWorkerPool[*model.DatasetValidation]{
+		name:         "数据集校验任务",
+		workers:      s.workers,
+		jobTimeout:   s.jobTimeout,
+		pollInterval: s.pollInterval,
+		lease:        validationJobLease,
+		claim:        s.validationDAO.ClaimJobs,
+		run:          s.runJob,
+	}.Run(ctx)
+}
+
+// 执行单个任务并记录结果
+func (s DatasetValidationService) runJob(ctx context.Context, job *model.DatasetValidation) {
+	if job.Attempts > validationJobMaxAttempts {
+		s.reject(job, "校验任务多次中断，已停止重试")
+		return
+	}
+
+	util.Info("开始数据集校验任务", zap.Uint("jobId", job.ID), zap.Uint("datasetId", job.DatasetID), zap.Int("version", job.Version))
+	// 心跳覆盖读取文件和写入结论的全过程，写入结论前租约不会过期
+	jobCtx, stop := withLeaseHeartbeat(ctx, validationJobLease, func(lease time.Duration) (bool, error) {
+		return s.validationDAO.RenewLease(job.ID, lease)
+	}, errValidationCanceled)
+	defer stop()
+
+	report, err := s.process(jobCtx, job)
+	switch {
+	case err == nil:
+		if err := s.finish(job, report); err != nil {
+			util.Error("写入校验结果失败", zap.Uint("jobId", job.ID), zap.Error(err))
+		}
+	case leaseJobInterrupted(ctx, err, errValidationCanceled, "校验任务", job.ID, s.validationDAO.RequeueJob):
+	case minio2.ToErrorResponse(err).Code == "NoSuchKey":
+		s.reject(job, "数据集文件不存在")
+	default:
+		// 读取失败多为暂时性错误，保留执行中状态，租约过期后由 worker 重新认领
+		util.Error("校验任务失败，等待重试", zap.Uint("jobId", job.ID), zap.Int("attempts", job.Attempts), zap.Error(err))
+	}
+}
+
+// 流式读取对象并生成校验报告，每处理 1% 的字节更新一次进度
+func (s DatasetValidationService) process(parent context.Context, job *model.DatasetValidation) (*validationReport, error) {
+	ctx, cancel := context.WithTimeout(parent, s.jobTimeout)
+	defer cancel()
+
+	reader, etag, err := s.adminMinioDAO.GetObjectReaderWithETag(ctx, s.bucket, job.ObjectName)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	updateStep := max(job.DeclaredSize/100, 1)
+	lastBytes := int64(0)
+	profile, err := util.ProfileDataset(reader, job.Format, func(read int64) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if read-lastBytes < updateStep {
+			return nil
+		}
+		lastBytes = read
+		running, err := s.validationDAO.UpdateProgress(job.ID, min(uint(read*100/max(job.DeclaredSize, 1)), 99), validationJobLease)
+		if err != nil {
+			return err
+		}
+		if !running {
+			return errValidationCanceled
+		}
+		return nil
+	})
+	if err != nil {
+		// 超时、取消或服务退出时，读取错误只是表象，以 ctx 的原因为准
+		if ctx.Err() != nil && !errors.Is(err, errValidationCanceled) {
+			return nil, context.Cause(ctx)
+		}
+		return nil, err
+	}
+	report := s.evaluate(job, profile)
+	report.ETag = etag
+	return report, nil
+}
+
+// 根据文件概况给出结论：无有效记录、大小或 SHA-256 与声明不一致、有记录超过长度上限、错误记录占比超过上限、预览数据与文件不符时不通过
+func (s DatasetValidationService) evaluate(job *model.DatasetValidation, profile *util.DatasetProfile) *validationReport {
+	report := checkProfile(job, profile, s.maxErrorRate)
+
+	// 预览数据由卖家浏览器读取文件开头若干行后上传，核对是否与实际文件一致
+	if preview, err := s.datasetMongoDAO.GetPreviewData(job.ObjectName, 0, 0); err == nil && len(preview.PreviewData) > 0 {
+		matched := len(preview.PreviewData) <= len(profile.Head)
+		for i := 0; matched && i < len(preview.PreviewData); i++ {
+			matched = strings.TrimSpace(preview.PreviewData[i]) == strings.TrimSpace(profile.Head[i])
+		}
+		report.PreviewMatched = &matched
+		if !matched {
+			report.Reasons = append(report.Reasons, "预览数据与文件内容不一致")
+		}
+	}
+	return report
+}
+
+// 按文件概况核对上传声明和错误记录占比（不含预览数据核对）
+func checkProfile(job *model.DatasetValidation, profile *util.DatasetProfile, maxErrorRate float64) *validationReport {
+	report := &validationReport{
+		DatasetProfile:   profile,
+		DeclaredSize:     job.DeclaredSize,
+		DeclaredChecksum: job.DeclaredChecksum,
+		Reasons:          []string{},
+	}
+	if profile.Records > 0 {
+		report.ErrorRate = float64(profile.MalformedRecords+profile.EncodingErrors) / float64(profile.Records)
+	}
+
+	if profile.Records == profile.MalformedRecords {
+		report.Reasons = append(report.Reasons, "文件中没有有效记录")
+	}
+	if profile.Bytes != job.DeclaredSize {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("文件大小 %d 字节与声明的 %d 字节不一致", profile.Bytes, job.DeclaredSize))
+	}
+	if job.DeclaredChecksum != "" && !strings.EqualFold(job.DeclaredChecksum, profile.SHA256) {
+		report.Reasons = append(report.Reasons, "文件 SHA-256 与声明不一致")
+	}
+	if profile.OversizedRecords > 0 {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("有 %d 条记录超过长度上限", profile.OversizedRecords))
+	}
+	if report.ErrorRate > maxErrorRate {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("格式错误或编码问题的记录占比 %.2f%% 超过上限 %.2f%%", report.ErrorRate*100, maxErrorRate*100))
+	}
+	return report
+}
+
+// 写入校验结论：通过的版本若为最新的已通过版本，数据集文件信息切换到该版本
+// 通过的版本记录文件的 SHA-256、MD5 和 ETag；其他卖家已有相同文件时记录重复文件，等待管理员审核
+func (s DatasetValidationService) finish(job *model.DatasetValidation, report *validationReport) error {
+	result := model.DatasetStatusReady
+	if len(report.Reasons) > 0 {
+		result = model.DatasetStatusRejected
+	}
+	// 预览以服务端从文件抽样的记录为准，未通过的版本也保留，便于卖家排查
+	if err := s.datasetMongoDAO.SaveGeneratedPreview(job.ObjectName, report.Bytes, generatedPreview(report.DatasetProfile)); err != nil {
+		return err
+	}
+
+	tx := s.validationDAO.DB().Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	// 查重与写入结论在同一事务中，并锁定 SHA-256 相同的版本，避免两个卖家同时上传相同文件时都未被发现
+	if result == model.DatasetStatusReady {
+		if err := s.datasetDAO.LockVersionsByChecksumTX(tx, report.SHA256); err != nil {
+			tx.Rollback()
+			return err
+		}
+		original, err := s.datasetDAO.FindOriginalVersionTX(tx, report.SHA256, job.DatasetID)
+		switch {
+		case err == nil:
+			report.DuplicateOf = &duplicateSource{DatasetID: original.DatasetID, VersionID: original.ID, Version: original.Version}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			tx.Rollback()
+			return err
+		}
+	}
+	b, err := json.Marshal(report)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	done, err := s.validationDAO.MarkDoneTX(tx, job.ID, result, b)
+	if err != nil || !done {
+		tx.Rollback()
+		return err
+	}
+	var integrity *model.FileIntegrity
+	if result == model.DatasetStatusReady {
+		integrity = &model.FileIntegrity{Checksum: report.SHA256, MD5: report.MD5, ETag: report.ETag}
+	}
+	if dup := report.DuplicateOf; dup != nil {
+		err = s.datasetDAO.CreateDuplicateTX(tx, &model.DatasetDuplicate{
+			DatasetID:         job.DatasetID,
+			VersionID:         job.VersionID,
+			Version:           job.Version,
+			Checksum:          report.SHA256,
+			OriginalDatasetID: dup.DatasetID,
+			OriginalVersionID: dup.VersionID,
+			OriginalVersion:   dup.Version,
+			Status:            model.DuplicatePending,
+		})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	published, err := s.applyResultTX(tx, job, result, integrity)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	util.Info("数据集校验任务完成", zap.Uint("jobId", job.ID), zap.Uint("datasetId", job.DatasetID),
+		zap.Int("version", job.Version), zap.String("result", result), zap.Strings("reasons", report.Reasons))
+	if dup := report.DuplicateOf; dup != nil {
+		util.Warn("数据集文件与其他卖家的数据集重复，等待管理员审核", zap.Uint("datasetId", job.DatasetID), zap.Int("version", job.Version),
+			zap.Uint("originalDatasetId", dup.DatasetID), zap.Int("originalVersion", dup.Version))
+	}
+	s.afterResult(job, published)
+	return nil
+}
+
+// 由文件概况生成预览文档的服务端字段
+func generatedPreview(profile *util.DatasetProfile) *model.GeneratedPreview {
+	fields := make([]model.PreviewField, 0, len(profile.Fields))
+	for _, f := range profile.Fields {
+		fields = append(fields, model.PreviewField{
+			Name:              f.Name,
+			Types:             f.Types,
+			NullRate:          f.NullRate,
+			Min:               f.Min,
+			Max:               f.Max,
+			Mean:              f.Mean,
+			MinLength:         f.MinLength,
+			MaxLength:         f.MaxLength,
+			Distinct:          f.Distinct,
+			DistinctTruncated: f.DistinctTruncated,
+			Examples:          f.Examples,
+		})
+	}
+	return &model.GeneratedPreview{
+		Generated:   true,
+		Sample:      profile.Sample,
+		SampleHead:  profile.SampleHead,
+		SampleTotal: len(profile.Sample),
+		Records:     profile.Records,
+		Fields:      fields,
+		GeneratedAt: time.Now(),
+	}
+}
+
+// 判定版本未通过（任务无法完成时）
+func (s DatasetValidationService) reject(job *model.DatasetValidation, reason string) {
+	err := s.validationDAO.DB().Transaction(func(tx *gorm.DB) error {
+		failed, err := s.validationDAO.MarkFailedTX(tx, job.ID, model.DatasetStatusRejected, reason)
+		if err != nil || !failed {
+			return err
+		}
+		_, err = s.applyResultTX(tx, job, model.DatasetStatusRejected, nil)
+		return err
+	})
+	if err != nil {
+		util.Error("更新校验任务状态失败", zap.Uint("jobId", job.ID), zap.Error(err))
+		return
+	}
+	util.Warn("数据集校验未完成，版本判定为未通过", zap.Uint("jobId", job.ID), zap.Uint("datasetId", job.DatasetID), zap.String("reason", reason))
+	s.afterResult(job, false)
+}
+
+// 更新版本和数据集状态 TX，返回数据集文件是否切换到了该版本
+// 数据集有任一已通过版本即为 ready；首个版本未通过且没有已通过版本时为 rejected；已确认为重复上传的数据集保持 rejected
+func (s DatasetValidationService) applyResultTX(tx *gorm.DB, job *model.DatasetValidation, result string, integrity *model.FileIntegrity) (bool, error) {
+	if err := s.datasetDAO.UpdateVersionStatusTX(tx, job.VersionID, result, integrity); err != nil {
+		return false, err
+	}
+	latestReady, err := s.datasetDAO.GetLatestReadyVersionNumberTX(tx, job.DatasetID)
+	if err != nil {
+		return false, err
+	}
+	if latestReady == 0 {
+		return false, s.datasetDAO.UpdateDatasetStatusTX(tx, job.DatasetID, model.DatasetStatusRejected)
+	}
+	published := false
+	if result == model.DatasetStatusReady && latestReady == job.Version {
+		version, err := s.datasetDAO.GetVersionByIDTX(tx, job.VersionID)
+		if err != nil {
+			return false, err
+		}
+		if err := s.datasetDAO.UpdateDatasetFileTX(tx, version); err != nil {
+			return false, err
+		}
+		published = true
+	}
+	confirmed, err := s.datasetDAO.HasConfirmedDuplicateTX(tx, job.DatasetID)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case confirmed:
+		return published, s.datasetDAO.UpdateDatasetStatusTX(tx, job.DatasetID, model.DatasetStatusRejected)
+	case published:
+		return true, s.datasetDAO.UpdateDatasetStatusTX(tx, job.DatasetID, model.DatasetStatusReady)
+	}
+	return false, nil
+}
+
+// 状态变化后同步搜索索引；首个版本通过时写入最近上传排行榜
+func (s DatasetValidationService) afterResult(job *model.DatasetValidation, published bool) {
+	dataset, err := s.datasetDAO.GetDataset(job.DatasetID)
+	if err != nil {
+		util.Warn("查询数据集失败，未更新搜索索引", zap.Uint("datasetID", job.DatasetID), zap.Error(err))
+		return
+	}
+	indexDataset(s.searchIndex, dataset)
+	if published && job.Version == 1 && dataset.Status == model.DatasetStatusReady {
+		_ = s.rankRedisDAO.AppendLatest(map[string]interface{}{
+			"id":       dataset.ID,
+			"title":    dataset.Title,
+			"category": dataset.Category,
+			"isFree":   dataset.IsFree,
+			"price":    dataset.Price,
+		}, dataset.CreatedAt.Unix(), util.RANK_DEFAULT_LIMIT)
+	}
+}
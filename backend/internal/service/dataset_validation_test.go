@@ -0,0 +1,68 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/internal/util"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestCheckProfile(t *testing.T) {
+	const (
+		jsonl = "{\"a\": 1}\n{\"a\": 2}\n{\"a\": 3}\nnot json\n"
+		csv   = "id,name\n1,alice\n2,bob\n"
+		tsv   = "id\tname\n1\talice\n2\tbob\n"
+		text  = "hello\n\xff\n"
+		json  = `[{"a": 1}, {"a": 2}]`
+	)
+	checksum := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	cases := []struct {
+		name         string
+		format       string
+		src          string
+		declaredSize int64 // 0 表示与文件一致
+		checksum     string
+		maxErrorRate float64
+		reasons      []string // 期望的未通过原因（子串），为空表示通过
+	}{
+		{name: "jsonl under threshold", format: util.FORMAT_JSONL, src: jsonl, checksum: checksum(jsonl), maxErrorRate: 0.3},
+		{name: "jsonl over threshold", format: util.FORMAT_JSONL, src: jsonl, maxErrorRate: 0.2, reasons: []string{"占比 25.00% 超过上限 20.00%"}},
+		{name: "csv", format: util.FORMAT_CSV, src: csv, checksum: strings.ToUpper(checksum(csv)), maxErrorRate: 0.01},
+		{name: "csv size mismatch", format: util.FORMAT_CSV, src: csv, declaredSize: 1, maxErrorRate: 0.01, reasons: []string{"与声明的 1 字节不一致"}},
+		{name: "tsv checksum mismatch", format: util.FORMAT_TSV, src: tsv, checksum: checksum(csv), maxErrorRate: 0.01, reasons: []string{"SHA-256 与声明不一致"}},
+		{name: "text encoding errors", format: util.FORMAT_TEXT, src: text, maxErrorRate: 0.01, reasons: []string{"占比 50.00%"}},
+		{name: "json", format: util.FORMAT_JSON, src: json, maxErrorRate: 0.01},
+		{name: "json not array", format: util.FORMAT_JSON, src: `{"a": 1}`, maxErrorRate: 0.01, reasons: []string{"没有有效记录", "占比 100.00%"}},
+		{name: "empty", format: util.FORMAT_JSONL, src: "", maxErrorRate: 0.01, reasons: []string{"没有有效记录"}},
+		{
+			name: "size and checksum mismatch", format: util.FORMAT_JSONL, src: jsonl, declaredSize: 2, checksum: checksum(csv), maxErrorRate: 0.3,
+			reasons: []string{"字节不一致", "SHA-256 与声明不一致"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			profile, err := util.ProfileDataset(strings.NewReader(c.src), c.format, nil)
+			if err != nil {
+				t.Fatalf("ProfileDataset: %v", err)
+			}
+			job := &model.DatasetValidation{DeclaredSize: int64(len(c.src)), DeclaredChecksum: c.checksum}
+			if c.declaredSize != 0 {
+				job.DeclaredSize = c.declaredSize
+			}
+			report := checkProfile(job, profile, c.maxErrorRate)
+			if len(report.Reasons) != len(c.reasons) {
+				t.Fatalf("reasons = %q, want %d matching %q", report.Reasons, len(c.reasons), c.reasons)
+			}
+			for i, want := range c.reasons {
+				if !strings.Contains(report.Reasons[i], want) {
+					t.Errorf("reasons[%d] = %q, want it to contain %q", i, report.Reasons[i], want)
+				}
+			}
+		})
+	}
+}
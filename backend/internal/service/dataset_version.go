@@ -0,0 +1,198 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/internal/util"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 数据集或版本不存在，controller 层映射为 404
+var (
+	ErrDatasetNotFound        = errors.New("数据集不存在")
+	ErrDatasetVersionNotFound = errors.New("数据集版本不存在")
+)
+
+// 发布数据集新版本：文件须为本人上传且未被任何数据集或版本使用，
+// 版本号在数据集行锁内递增，旧版本保留供已购买用户下载；新版本通过文件校验后数据集的文件信息才切换到该版本
+func (s DatasetService) PublishVersion(req *model.PublishDatasetVersionRequest, userID uint) (*model.DatasetVersion, error) {
+	walletAddress, err := s.getBoundWallet(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkObjectOwner(userID, req.ObjectName); err != nil {
+		return nil, err
+	}
+	format, err := util.ResolveDatasetFormat(req.Format, req.ObjectName)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	ds, err := s.datasetDAO.LockDatasetTX(tx, req.DatasetID)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDatasetNotFound
+		}
+		return nil, err
+	}
+	if !strings.EqualFold(ds.AuthorWalletAddress, walletAddress) {
+		tx.Rollback()
+		return nil, ErrNotDatasetOwner
+	}
+	if s.datasetDAO.CheckObjectNameUsed(tx, req.ObjectName) {
+		tx.Rollback()
+		return nil, ErrNotObjectOwner
+	}
+	latest, err := s.datasetDAO.GetLatestVersionNumberTX(tx, req.DatasetID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	version := &model.DatasetVersion{
+		DatasetID:  req.DatasetID,
+		Version:    latest + 1,
+		ObjectName: req.ObjectName,
+		FileSize:   req.FileSize,
+		Format:     format,
+		Checksum:   strings.ToLower(req.Checksum),
+		Changelog:  strings.TrimSpace(req.Changelog),
+		Status:     model.DatasetStatusValidating,
+	}
+	if err = s.datasetDAO.CreateVersionTX(tx, version); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err = s.createValidationJobTX(tx, version); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	// 上传会话的文件已被使用，不再由清理任务删除；会话已过期或文件已被清理时拒绝
+	usable, err := s.uploadDAO.MarkUsedTX(tx, version.ObjectName)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if !usable {
+		tx.Rollback()
+		return nil, ErrUploadSessionClosed
+	}
+	// 还没有通过校验的版本（首个版本未通过）时重新进入校验中
+	if ds.Status != model.DatasetStatusReady {
+		if err = s.datasetDAO.UpdateDatasetStatusTX(tx, ds.ID, model.DatasetStatusValidating); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err = tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return version, nil
+}
+
+// 创建版本文件校验任务 TX，上传时声明的大小和 SHA-256 由 worker 与实际文件核对
+func (s DatasetService) createValidationJobTX(tx *gorm.DB, version *model.DatasetVersion) error {
+	return s.validationDAO.CreateJobTX(tx, &model.DatasetValidation{
+		DatasetID:        version.DatasetID,
+		VersionID:        version.ID,
+		Version:          version.Version,
+		ObjectName:       version.ObjectName,
+		Format:           version.Format,
+		DeclaredSize:     version.FileSize,
+		DeclaredChecksum: version.Checksum,
+	})
+}
+
+// 查询版本文件校验报告（仅作者本人），version 为 0 时取最新版本
+func (s DatasetService) GetValidationReport(datasetID uint, version int, userID uint) (*model.DatasetValidation, error) {
+	walletAddress, err := s.getBoundWallet(userID)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := s.datasetDAO.GetDataset(datasetID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDatasetNotFound
+		}
+		return nil, err
+	}
+	if !strings.EqualFold(ds.AuthorWalletAddress, walletAddress) {
+		return nil, ErrNotDatasetOwner
+	}
+	if version <= 0 {
+		if version, err = s.datasetDAO.GetLatestVersionNumberTX(s.db, datasetID); err != nil {
+			return nil, err
+		}
+	}
+	v, err := s.datasetDAO.GetVersion(datasetID, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDatasetVersionNotFound
+		}
+		return nil, err
+	}
+	job, err := s.validationDAO.GetLatestJob(v.ID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrValidationNotFound
+	}
+	return job, nil
+}
+
+// 获取数据集详情及版本：作者本人可看到全部版本（含校验中和未通过的），其他用户只能看到已通过校验的版本
+// viewerID 为 0 表示未登录
+func (s DatasetService) GetDatasetDetail(datasetId, viewerID uint) (*model.DatasetDetailResponse, error) {
+	dataset, err := s.datasetDAO.GetDatasetDetail(datasetId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDatasetNotFound
+		}
+		return nil, err
+	}
+	owner := false
+	if viewerID != 0 {
+		walletAddress, err := s.getBoundWallet(viewerID)
+		if err != nil && !errors.Is(err, ErrWalletNotBound) {
+			return nil, err
+		}
+		owner = walletAddress != "" && strings.EqualFold(walletAddress, dataset.AuthorWalletAddress)
+	}
+	versions, err := s.datasetDAO.ListVersions(datasetId, !owner)
+	if err != nil {
+		return nil, err
+	}
+	if versions == nil {
+		versions = []model.DatasetVersion{}
+	}
+	return &model.DatasetDetailResponse{DatasetListResponse: dataset, Versions: versions}, nil
+}
+
+// 查询下载的目标版本，version 为 0 时取最新的已通过校验版本
+// 购买针对整个数据集（合约中每个买家只能购买一次），已购买用户可下载全部已通过校验的版本
+func (s DatasetService) getDownloadVersion(datasetID uint, version int) (*model.DatasetVersion, error) {
+	v, err := s.datasetDAO.GetVersion(datasetID, version)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDatasetVersionNotFound
+	}
+	if err == nil && v.Status != model.DatasetStatusReady {
+		return nil, ErrDatasetVersionNotReady
+	}
+	return v, err
+}
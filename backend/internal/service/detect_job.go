@@ -0,0 +1,144 @@
+package service
+
+import (
+	"backend/internal/dao/minio"
+	"backend/internal/dao/mysql"
+	"backend/internal/model"
+	"backend/internal/util"
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrDetectJobNotFound    = errors.New("检测任务不存在")
+	ErrDetectJobCanceled    = errors.New("检测任务已取消")
+	ErrBucketNotAllowed     = errors.New("不允许访问该存储桶")
+	ErrDetectObjectNotFound = errors.New("对象不存在")
+)
+
+// 执行租约，任务执行期间由心跳定期续约
+const detectJobLease = 2 * time.Minute
+
+// 单个任务最多执行次数（含崩溃后重新认领）
+const detectJobMaxAttempts = 3
+
+// 指纹检测任务 worker 池：认领 detect_jobs 中的任务，流式扫描 MinIO 对象并写入检测记录
+type DetectJobService struct {
+	detectJobDAO  *mysql.DetectJobDAO
+	adminDAO      *mysql.AdminDAO
+	adminMinioDAO *minio.AdminMinioDAO
+	workers       int
+	jobTimeout    time.Duration
+	pollInterval  time.Duration
+}
+
+func NewDetectJobService(detectJobDAO *mysql.DetectJobDAO, adminDAO *mysql.AdminDAO, adminMinioDAO *minio.AdminMinioDAO, workers int, jobTimeout, pollInterval time.Duration) *DetectJobService {
+	if workers <= 0 {
+		workers = 2
+	}
+	if jobTimeout <= 0 {
+		jobTimeout = 60 * time.Minute
+	}
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	return &DetectJobService{
+		detectJobDAO:  detectJobDAO,
+		adminDAO:      adminDAO,
+		adminMinioDAO: adminMinioDAO,
+		workers:       workers,
+		jobTimeout:    jobTimeout,
+		pollInterval:  pollInterval,
+	}
+}
+
+// 启动 worker 池，直到 ctx 结束；结束时等待执行中的任务退出并重新排队
+func (s DetectJobService) Run(ctx context.Context) {
+	leaseWorkerPool[*model.DetectJob]{
+		name:         "指纹检测任务",
+		workers:      s.workers,
+		jobTimeout:   s.jobTimeout,
+		pollInterval: s.pollInterval,
+		lease:        detectJobLease,
+		claim:        s.detectJobDAO.ClaimJobs,
+		run:          s.runJob,
+	}.Run(ctx)
+}
+
+// 执行单个任务并记录结果
+func (s DetectJobService) runJob(ctx context.Context, job *model.DetectJob) {
+	if job.Attempts > detectJobMaxAttempts {
+		_, _ = s.detectJobDAO.MarkFailed(job.ID, "任务多次中断，已停止重试")
+		util.Error("检测任务多次中断", zap.Uint("jobId", job.ID), zap.Int("attempts", job.Attempts))
+		return
+	}
+
+	util.Info("开始指纹检测任务", zap.Uint("jobId", job.ID), zap.String("bucket", job.Bucket), zap.String("objectName", job.ObjectName))
+	record, err := s.process(ctx, job)
+	switch {
+	case err == nil:
+		if err := s.detectJobDAO.MarkDone(job.ID, record.ID); err != nil {
+			util.Error("更新检测任务状态失败", zap.Uint("jobId", job.ID), zap.Error(err))
+			return
+		}
+		util.Info("指纹检测任务完成", zap.Uint("jobId", job.ID), zap.String("result", record.DetectionResult))
+	case leaseJobInterrupted(ctx, err, ErrDetectJobCanceled, "检测任务", job.ID, s.detectJobDAO.RequeueJob):
+	default:
+		failLeaseJob("检测任务", job.ID, err, s.detectJobDAO.MarkFailed)
+	}
+}
+
+// 流式读取对象并检测，每处理 1% 的字节更新一次进度（保留 100 给写入记录）
+func (s DetectJobService) process(parent context.Context, job *model.DetectJob) (*model.DetectRecord, error) {
+	ctx, cancel := context.WithTimeout(parent, s.jobTimeout)
+	defer cancel()
+	ctx, stop := withLeaseHeartbeat(ctx, detectJobLease, func(lease time.Duration) (bool, error) {
+		return s.detectJobDAO.RenewLease(job.ID, lease)
+	}, ErrDetectJobCanceled)
+	defer stop()
+
+	fp, err := util.GetFingerprinter(job.Format)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := s.adminMinioDAO.GetObjectReader(ctx, job.Bucket, job.ObjectName)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	updateStep := job.FileSize / 100
+	if updateStep <= 0 {
+		updateStep = 1
+	}
+	lastBytes := int64(0)
+	var progress uint = 0
+	record, err := detectFingerprint(s.adminDAO, job.Filename, job.FileSize, func() (*util.FingerprintResult, error) {
+		return fp.Extract(reader, func(read int64) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if read-lastBytes < updateStep || progress >= 99 {
+				return nil
+			}
+			progress = min(uint(read*100/max(job.FileSize, 1)), 99)
+			lastBytes = read
+			running, err := s.detectJobDAO.UpdateProgress(job.ID, progress, detectJobLease)
+			if err != nil {
+				return err
+			}
+			if !running {
+				return ErrDetectJobCanceled
+			}
+			return nil
+		})
+	})
+	// 超时、取消或服务退出时，读取错误只是表象，以 ctx 的原因为准
+	if err != nil && ctx.Err() != nil && !errors.Is(err, ErrDetectJobCanceled) {
+		return nil, context.Cause(ctx)
+	}
+	return record, err
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"backend/internal/model"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+var ErrDetectRecordNotFound = errors.New("检测记录不存在")
+
+// 检测记录筛选参数错误，controller 层映射为 400
+var ErrInvalidDetectRecordQuery = errors.New("筛选参数错误")
+
+// 匹配报告的导出内容：报告 JSON 及其 SHA-256 摘要，摘要用于核对证据未被篡改
+type DetectReportExport struct {
+	Report      *model.DetectReport
+	JSON        []byte
+	Digest      string
+	GeneratedAt time.Time
+}
+
+// 生成匹配报告的证据数据：报告 JSON 及其 SHA-256 摘要
+func newDetectReportEvidence(report *model.DetectReport) (string, string, error) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(data)
+	return string(data), hex.EncodeToString(sum[:]), nil
+}
+
+// 由检测记录生成导出内容：报告 JSON 和摘要是检测时保存的内容，原样输出，不随数据集标题等后续修改变化
+func NewDetectReportExport(record *model.DetectRecord) (*DetectReportExport, error) {
+	var report model.DetectReport
+	if err := json.Unmarshal([]byte(record.ReportJSON), &report); err != nil {
+		return nil, err
+	}
+	return &DetectReportExport{
+		Report:      &report,
+		JSON:        []byte(record.ReportJSON),
+		Digest:      record.ReportDigest,
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
+var detectReportStatusText = map[string]string{
+	model.DetectStatusNoFingerprint: "未检测到指纹",
+	model.DetectStatusUnknown:       "未知指纹（无对应交易）",
+	model.DetectStatusMatched:       "已匹配",
+	model.DetectStatusConflict:      "多笔候选交易",
+}
+
+var detectReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"status": func(s string) string {
+		if text, ok := detectReportStatusText[s]; ok {
+			return text
+		}
+		return s
+	},
+	"time": func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.Format(time.RFC3339)
+	},
+	"percent": func(f float64) string {
+		return fmt.Sprintf("%.2f%%", f*100)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>指纹匹配报告 #{{.Report.RecordID}}</title>
+<style>
+body{font-family:sans-serif;margin:2em;color:#222}
+table{border-collapse:collapse;margin-bottom:1.5em}
+th,td{border:1px solid #ccc;padding:6px 10px;text-align:left;font-size:14px}
+th{background:#f5f5f5}
+pre{background:#f5f5f5;padding:1em;overflow:auto;font-size:12px}
+</style>
+</head>
+<body>
+<h1>指纹匹配报告 #{{.Report.RecordID}}</h1>
+<h2>检测文件</h2>
+<table>
+<tr><th>文件名</th><td>{{.Report.Filename}}</td></tr>
+<tr><th>文件大小</th><td>{{.Report.FileSize}} 字节</td></tr>
+<tr><th>检测时间</th><td>{{.Report.DetectedAt.Format "2006-01-02T15:04:05Z07:00"}}</td></tr>
+<tr><th>检测耗时</th><td>{{.Report.DetectionTime}}</td></tr>
+</table>
+<h2>匹配结果</h2>
+<table>
+<tr><th>状态</th><td>{{status .Report.Status}}</td></tr>
+<tr><th>指纹版本</th><td>{{if .Report.Version}}v{{.Report.Version}}{{else}}-{{end}}</td></tr>
+{{- if .Report.Timestamp}}
+<tr><th>指纹时间戳</th><td>{{.Report.Timestamp}}</td></tr>
+{{- end}}
+<tr><th>数据集</th><td>{{if .Report.DatasetID}}#{{.Report.DatasetID}} {{.Report.DatasetTitle}}{{else}}-{{end}}</td></tr>
+<tr><th>买家用户</th><td>{{if .Report.BuyerUserID}}#{{.Report.BuyerUserID}}{{else}}-{{end}}</td></tr>
+<tr><th>买家钱包</th><td>{{or .Report.BuyerWallet "-"}}</td></tr>
+<tr><th>交易记录</th><td>{{if .Report.TransactionID}}#{{.Report.TransactionID}}{{else}}-{{end}}</td></tr>
+<tr><th>交易哈希</th><td>{{or .Report.TxHash "-"}}</td></tr>
+<tr><th>购买时间</th><td>{{time .Report.PurchasedAt}}</td></tr>
+<tr><th>置信度</th><td>{{percent .Report.Confidence}}</td></tr>
+<tr><th>指纹份数</th><td>{{.Report.Copies}}</td></tr>
+</table>
+{{- if .Report.Candidates}}
+<h2>候选交易</h2>
+<table>
+<tr><th>交易记录</th><th>数据集</th><th>买家用户</th><th>买家钱包</th><th>交易哈希</th><th>购买时间</th></tr>
+{{- range .Report.Candidates}}
+<tr><td>#{{.TransactionID}}</td><td>#{{.DatasetID}}</td><td>{{if .BuyerUserID}}#{{.BuyerUserID}}{{else}}-{{end}}</td><td>{{.BuyerWallet}}</td><td>{{or .TxHash "-"}}</td><td>{{time .PurchasedAt}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<h2>报告数据</h2>
+<p>生成时间：{{.GeneratedAt.Format "2006-01-02T15:04:05Z07:00"}}</p>
+<p>SHA-256：<code>{{.Digest}}</code></p>
+<pre>{{printf "%s" .JSON}}</pre>
+</body>
+</html>
+`))
+
+// 渲染 HTML 证据报告，报告数据中的文件名等内容均经过转义
+func (e *DetectReportExport) WriteHTML(w io.Writer) error {
+	return detectReportTemplate.Execute(w, e)
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"backend/internal/dao/minio"
+	"backend/internal/dao/mysql"
+	"backend/internal/model"
+	"backend/internal/util"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrDownloadJobNotFound = errors.New("下载任务不存在")
+	ErrDownloadJobCanceled = errors.New("下载任务已取消")
+)
+
+// 执行租约，任务执行期间由心跳定期续约，超时未续约的任务会被其他 worker 重新认领
+const downloadJobLease = 2 * time.Minute
+
+// 单个任务最多执行次数（含崩溃后重新认领）
+const downloadJobMaxAttempts = 3
+
+// 付费下载任务 worker 池：认领 download_jobs 中的任务，插入指纹后写入临时桶
+type DownloadJobService struct {
+	jobDAO          *mysql.DownloadJobDAO
+	datasetMinioDAO *minio.DatasetMinioDAO
+	workers         int
+	jobTimeout      time.Duration
+	pollInterval    time.Duration
+}
+
+func NewDownloadJobService(jobDAO *mysql.DownloadJobDAO, datasetMinioDAO *minio.DatasetMinioDAO, workers int, jobTimeout, pollInterval time.Duration) *DownloadJobService {
+	if workers <= 0 {
+		workers = 4
+	}
+	if jobTimeout <= 0 {
+		jobTimeout = 30 * time.Minute
+	}
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	return &DownloadJobService{
+		jobDAO:          jobDAO,
+		datasetMinioDAO: datasetMinioDAO,
+		workers:         workers,
+		jobTimeout:      jobTimeout,
+		pollInterval:    pollInterval,
+	}
+}
+
+// 启动 worker 池，直到 ctx 结束；结束时等待执行中的任务退出并重新排队
+func (s DownloadJobService) Run(ctx context.Context) {
+	leaseWorkerPool[*model.DownloadJob]{
+		name:         "付费下载任务",
+		workers:      s.workers,
+		jobTimeout:   s.jobTimeout,
+		pollInterval: s.pollInterval,
+		lease:        downloadJobLease,
+		claim:        s.jobDAO.ClaimJobs,
+		run:          s.runJob,
+	}.Run(ctx)
+}
+
+// 执行单个任务并记录结果
+func (s DownloadJobService) runJob(ctx context.Context, job *model.DownloadJob) {
+	if job.Attempts > downloadJobMaxAttempts {
+		_, _ = s.jobDAO.MarkFailed(job.ID, "任务多次中断，已停止重试")
+		util.Error("下载任务多次中断", zap.Uint("jobId", job.ID), zap.Int("attempts", job.Attempts))
+		return
+	}
+
+	util.Info("开始处理下载任务", zap.Uint("jobId", job.ID), zap.Uint("datasetId", job.DatasetID), zap.Int("attempts", job.Attempts))
+	checksum, err := s.process(ctx, job)
+	switch {
+	case err == nil:
+		if err := s.jobDAO.MarkDone(job.ID, checksum); err != nil {
+			util.Error("更新下载任务状态失败", zap.Uint("jobId", job.ID), zap.Error(err))
+			return
+		}
+		util.Info("数据集上传到临时桶完成", zap.Uint("jobId", job.ID), zap.String("objectName", job.TempObject))
+	case leaseJobInterrupted(ctx, err, ErrDownloadJobCanceled, "下载任务", job.ID, s.jobDAO.RequeueJob):
+	default:
+		failLeaseJob("下载任务", job.ID, err, s.jobDAO.MarkFailed)
+	}
+}
+
+// 流式处理：边读源文件边插入指纹，通过 pipe 上传到临时桶，返回带指纹文件的 SHA-256
+// 源文件与校验时记录的 ETag 或 SHA-256 不一致时中止上传，任务失败
+func (s DownloadJobService) process(parent context.Context, job *model.DownloadJob) (string, error) {
+	ctx, cancel := context.WithTimeout(parent, s.jobTimeout)
+	defer cancel()
+	ctx, stop := withLeaseHeartbeat(ctx, downloadJobLease, func(lease time.Duration) (bool, error) {
+		return s.jobDAO.RenewLease(job.ID, lease)
+	}, ErrDownloadJobCanceled)
+	defer stop()
+
+	srcReader, err := s.datasetMinioDAO.GetDatesetReader(ctx, job.SourceObject, job.SourceETag)
+	if err != nil {
+		return "", err
+	}
+	if closer, ok := srcReader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	verifier := newSourceVerifier(srcReader, job.SourceSHA256)
+	output := sha256.New()
+
+	pr, pw := io.Pipe()
+	uploadErr := make(chan error, 1)
+	go func() {
+		err := s.datasetMinioDAO.UploadDatasetToTempBucket(ctx, pr, job.TempObject)
+		// 上传提前失败时让写端立即返回
+		_ = pr.CloseWithError(err)
+		uploadErr <- err
+	}()
+
+	err = s.insertFingerprint(ctx, job, verifier, io.MultiWriter(pw, output))
+	if err == nil {
+		// 在关闭写端之前核对，不一致时上传以错误结束，临时桶中不会留下文件
+		err = verifier.Verify()
+	}
+	_ = pw.CloseWithError(err)
+	upErr := <-uploadErr
+
+	// 超时、取消或服务退出时，读写错误只是表象，以 ctx 的原因为准
+	if ctx.Err() != nil && !errors.Is(err, ErrDownloadJobCanceled) {
+		return "", context.Cause(ctx)
+	}
+	if err != nil {
+		return "", integrityError(err)
+	}
+	if upErr != nil {
+		return "", upErr
+	}
+	return hex.EncodeToString(output.Sum(nil)), nil
+}
+
+// 逐行插入指纹写入 pipe，每处理 1% 的字节更新一次进度（保留 100 给上传完成）
+func (s DownloadJobService) insertFingerprint(ctx context.Context, job *model.DownloadJob, src io.Reader, w io.Writer) error {
+	updateStep := job.FileSize / 100
+	if updateStep <= 0 {
+		updateStep = 1
+	}
+
+	lastBytes := int64(0)
+	var progress uint = 0
+	_, err := util.WriteWithFingerprint(w, src, job.Format, job.Fingerprint, func(read int64) error {
+		if read-lastBytes < updateStep || progress >= 99 {
+			return nil
+		}
+		lastBytes = read
+		progress++
+		running, err := s.jobDAO.UpdateProgress(job.ID, progress, downloadJobLease)
+		if err != nil {
+			return err
+		}
+		if !running {
+			return ErrDownloadJobCanceled
+		}
+		return ctx.Err()
+	})
+	return err
+}
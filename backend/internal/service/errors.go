@@ -0,0 +1,54 @@
+package service
+
+import (
+	"backend/internal/dao/chain"
+	"errors"
+)
+
+// 权限相关错误，controller 层统一映射为 403
+var (
+	ErrWalletNotBound  = errors.New("请先绑定钱包")
+	ErrNotDatasetOwner = errors.New("无权操作他人的数据集")
+	ErrNotObjectOwner  = errors.New("无权操作他人的文件")
+
+	ErrNotTransactionOwner = errors.New("无权操作他人的交易记录")
+)
+
+// 购买相关错误，ErrPurchaseRequired 映射为 402，ErrPurchaseNotOnChain 映射为 403
+var (
+	ErrPurchaseRequired   = errors.New("请先购买该数据集")
+	ErrPurchaseNotOnChain = errors.New("链上未查询到购买记录")
+)
+
+// 交易确认相关错误，controller 层映射为 400
+var (
+	ErrTransactionNotPending = errors.New("交易记录不是待确认状态")
+	ErrTransactionMismatch   = errors.New("链上交易与交易记录不一致")
+	ErrTxHashUsed            = errors.New("该交易哈希已被其他交易记录使用")
+	ErrTxNotConfirmed        = errors.New("链上交易确认数不足，请稍后再试")
+)
+
+// 未配置链上节点时无法确认交易，controller 层映射为 503
+var ErrChainVerifierUnavailable = errors.New("未配置链上校验节点，暂时无法确认交易")
+
+// 是否为权限错误
+func IsForbiddenError(err error) bool {
+	return errors.Is(err, ErrWalletNotBound) || errors.Is(err, ErrNotDatasetOwner) || errors.Is(err, ErrNotObjectOwner) ||
+		errors.Is(err, ErrPurchaseNotOnChain) || errors.Is(err, ErrNotTransactionOwner)
+}
+
+// 是否为交易校验失败错误
+func IsTransactionVerifyError(err error) bool {
+	return errors.Is(err, ErrTransactionNotPending) || errors.Is(err, ErrTransactionMismatch) || errors.Is(err, ErrTxHashUsed) ||
+		errors.Is(err, ErrTxNotConfirmed) || errors.Is(err, chain.ErrReceiptNotFound) || errors.Is(err, chain.ErrTxReverted) || errors.Is(err, chain.ErrEventNotFound)
+}
+
+// 是否为服务不可用错误
+func IsUnavailableError(err error) bool {
+	return errors.Is(err, ErrChainVerifierUnavailable)
+}
+
+// 是否为未付费错误
+func IsPaymentRequiredError(err error) bool {
+	return errors.Is(err, ErrPurchaseRequired)
+}
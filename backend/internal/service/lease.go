@@ -0,0 +1,133 @@
+package service
+
+import (
+	"backend/internal/util"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 续约函数，任务已不在执行中时返回 false
+type leaseRenewer func(lease time.Duration) (bool, error)
+
+// 任务执行期间的租约心跳：独立于进度更新，每 lease/3 续约一次，避免读源、上传或校验较慢时租约过期被其他 worker 重新认领
+// 任务已不在执行中（被取消）时以 canceled 取消返回的 ctx；stop 停止心跳并等待其退出
+func withLeaseHeartbeat(parent context.Context, lease time.Duration, renew leaseRenewer, canceled error) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(parent)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			running, err := renew(lease)
+			if err != nil {
+				// 下次心跳重试，租约为间隔的 3 倍
+				util.Warn("任务续约失败", zap.Error(err))
+				continue
+			}
+			if !running {
+				cancel(canceled)
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+			cancel(context.Canceled)
+		})
+	}
+}
+
+// 基于租约的任务 worker 池（付费下载、指纹检测、数据集校验共用）：
+// 每个轮询周期按空闲 worker 数认领任务，每个任务一个 goroutine 执行；ctx 结束时等待执行中的任务退出
+type leaseWorkerPool[J any] struct {
+	name         string // 日志中的任务名称
+	workers      int
+	jobTimeout   time.Duration
+	pollInterval time.Duration
+	lease        time.Duration
+	claim        func(limit int, lease time.Duration) ([]J, error)
+	run          func(ctx context.Context, job J)
+}
+
+// 启动 worker 池，直到 ctx 结束；结束时等待执行中的任务退出并重新排队
+func (p leaseWorkerPool[J]) Run(ctx context.Context) {
+	util.Info(p.name+" worker 已启动", zap.Int("workers", p.workers), zap.Duration("jobTimeout", p.jobTimeout))
+	sem := make(chan struct{}, p.workers)
+	var wg sync.WaitGroup
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if free := p.workers - len(sem); free > 0 {
+			jobs, err := p.claim(free, p.lease)
+			if err != nil {
+				util.Error("认领"+p.name+"失败", zap.Error(err))
+			}
+			for _, job := range jobs {
+				sem <- struct{}{}
+				wg.Add(1)
+				go func(job J) {
+					defer func() {
+						<-sem
+						wg.Done()
+					}()
+					p.run(ctx, job)
+				}(job)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			util.Info(p.name + " worker 已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 任务执行出错时的公共处理：服务退出时重新排队，交给下次启动或其他实例重新执行；任务已被取消时只记录日志
+// 返回 false 表示 err 需要由调用方处理
+func leaseJobInterrupted(ctx context.Context, err, canceled error, name string, jobID uint, requeue func(id uint) error) bool {
+	switch {
+	case ctx.Err() != nil:
+		if err := requeue(jobID); err != nil {
+			util.Error(name+"重新排队失败", zap.Uint("jobId", jobID), zap.Error(err))
+		}
+		return true
+	case errors.Is(err, canceled):
+		util.Info(name+"已取消", zap.Uint("jobId", jobID))
+		return true
+	}
+	return false
+}
+
+// 标记任务失败，处理超时时记录为"处理超时"
+func failLeaseJob(name string, jobID uint, err error, markFailed func(id uint, reason string) (bool, error)) {
+	reason := err.Error()
+	if errors.Is(err, context.DeadlineExceeded) {
+		reason = "处理超时"
+	}
+	util.Error(name+"失败", zap.Uint("jobId", jobID), zap.Error(err))
+	if _, err := markFailed(jobID, reason); err != nil {
+		util.Error("更新"+name+"状态失败", zap.Uint("jobId", jobID), zap.Error(err))
+	}
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLeaseHeartbeatRenews(t *testing.T) {
+	var renewals atomic.Int32
+	ctx, stop := withLeaseHeartbeat(context.Background(), 30*time.Millisecond, func(time.Duration) (bool, error) {
+		renewals.Add(1)
+		return true, nil
+	}, ErrDownloadJobCanceled)
+
+	time.Sleep(75 * time.Millisecond)
+	if ctx.Err() != nil {
+		t.Fatalf("ctx canceled while job is running: %v", context.Cause(ctx))
+	}
+	stop()
+	n := renewals.Load()
+	if n < 3 {
+		t.Fatalf("renewals = %d, want at least 3", n)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if renewals.Load() != n {
+		t.Fatal("heartbeat kept renewing after stop")
+	}
+}
+
+func TestLeaseHeartbeatCancelsWhenNotRunning(t *testing.T) {
+	ctx, stop := withLeaseHeartbeat(context.Background(), 15*time.Millisecond, func(time.Duration) (bool, error) {
+		return false, nil
+	}, ErrDownloadJobCanceled)
+	defer stop()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("ctx not canceled")
+	}
+	if !errors.Is(context.Cause(ctx), ErrDownloadJobCanceled) {
+		t.Fatalf("cause = %v, want ErrDownloadJobCanceled", context.Cause(ctx))
+	}
+}
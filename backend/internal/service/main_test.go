@@ -0,0 +1,14 @@
+package service
+
+import (
+	"backend/internal/util"
+	"os"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	util.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"backend/internal/dao/minio"
+	"backend/internal/dao/mongo"
+	"backend/internal/dao/mysql"
+	"backend/internal/model"
+	"backend/internal/util"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var ErrOutboxTaskNotFailed = errors.New("任务不存在或不是失败状态")
+
+// 异步任务处理器：NewPayload 返回该任务类型的载荷结构体指针，Handle 接收解析后的载荷
+type OutboxHandler struct {
+	NewPayload func() model.OutboxPayload
+	Handle     func(payload model.OutboxPayload) error
+}
+
+// 异步任务调度：认领到期任务执行，失败按指数退避重试，超过最大次数进入 failed
+type OutboxService struct {
+	outboxDAO       *mysql.OutboxDAO
+	datasetMongoDAO *mongo.DatasetsPreviewDAO
+	adminMinioDAO   *minio.AdminMinioDAO
+	handlers        map[string]OutboxHandler
+	interval        time.Duration
+	batchSize       int
+	maxAttempts     int
+	baseBackoff     time.Duration
+}
+
+func NewOutboxService(outboxDAO *mysql.OutboxDAO, datasetMongoDAO *mongo.DatasetsPreviewDAO, adminMinioDAO *minio.AdminMinioDAO,
+	interval time.Duration, batchSize, maxAttempts int, baseBackoff time.Duration) *OutboxService {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	if batchSize <= 0 {
+		batchSize = 20
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = 8
+	}
+	if baseBackoff <= 0 {
+		baseBackoff = 30 * time.Second
+	}
+	s := &OutboxService{
+		outboxDAO:       outboxDAO,
+		datasetMongoDAO: datasetMongoDAO,
+		adminMinioDAO:   adminMinioDAO,
+		handlers:        make(map[string]OutboxHandler),
+		interval:        interval,
+		batchSize:       batchSize,
+		maxAttempts:     maxAttempts,
+		baseBackoff:     baseBackoff,
+	}
+
+	s.Register(model.OutboxDeleteMongoPreview, OutboxHandler{
+		NewPayload: func() model.OutboxPayload { return &model.ObjectNamePayload{} },
+		Handle: func(payload model.OutboxPayload) error {
+			p := payload.(*model.ObjectNamePayload)
+			util.Info("删除预览数据", zap.String("objectName", p.ObjectName))
+			return s.datasetMongoDAO.DeletePreviewData(p.ObjectName)
+		},
+	})
+	s.Register(model.OutboxDeleteMinioObject, OutboxHandler{
+		NewPayload: func() model.OutboxPayload { return &model.ObjectNamePayload{} },
+		Handle: func(payload model.OutboxPayload) error {
+			p := payload.(*model.ObjectNamePayload)
+			util.Info("删除文件", zap.String("objectName", p.ObjectName))
+			return s.adminMinioDAO.DeleteDatasetFile(p.ObjectName)
+		},
+	})
+	return s
+}
+
+// 注册任务处理器，需在 Run 之前调用
+func (s OutboxService) Register(eventType string, handler OutboxHandler) {
+	if _, ok := s.handlers[eventType]; ok {
+		panic("重复注册的异步任务类型: " + eventType)
+	}
+	s.handlers[eventType] = handler
+}
+
+// 认领后的执行租约，超过该时间未完成的任务会被重新认领
+const outboxLease = 5 * time.Minute
+
+// 最大重试间隔
+const outboxMaxBackoff = time.Hour
+
+// 持续调度，直到 ctx 结束
+func (s OutboxService) Run(ctx context.Context) {
+	util.Info("异步任务调度已启动", zap.Duration("interval", s.interval), zap.Int("maxAttempts", s.maxAttempts))
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		// 一批处理满时说明还有积压，继续处理
+		for {
+			n, err := s.Dispatch()
+			if err != nil {
+				util.Error("异步任务调度失败", zap.Error(err))
+			}
+			if err != nil || n < s.batchSize || ctx.Err() != nil {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			util.Info("异步任务调度已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 处理一批到期任务，返回本批认领的任务数
+func (s OutboxService) Dispatch() (int, error) {
+	tasks, err := s.outboxDAO.ClaimDueTasks(s.batchSize, outboxLease)
+	if err != nil {
+		return 0, err
+	}
+	for _, task := range tasks {
+		if err := s.execute(task); err != nil {
+			s.fail(task, err)
+			continue
+		}
+		if err := s.outboxDAO.MarkTasksDone(task.ID); err != nil {
+			util.Error("更新任务状态失败", zap.Uint("taskId", task.ID), zap.Error(err))
+		}
+	}
+	return len(tasks), nil
+}
+
+// 执行单个任务：按任务类型找到处理器，解析并校验载荷后交给处理器
+func (s OutboxService) execute(task *model.Outbox) error {
+	handler, ok := s.handlers[task.EventType]
+	if !ok {
+		return fmt.Errorf("未知的任务类型: %s", task.EventType)
+	}
+	payload := handler.NewPayload()
+	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+		return fmt.Errorf("payload 解析失败: %w", err)
+	}
+	if err := payload.Validate(); err != nil {
+		return fmt.Errorf("payload 校验失败: %w", err)
+	}
+	return handler.Handle(payload)
+}
+
+// 记录失败并计算下次执行时间
+func (s OutboxService) fail(task *model.Outbox, err error) {
+	attempts := task.Attempts + 1
+	failed := attempts >= s.maxAttempts
+	backoff := s.baseBackoff << (attempts - 1)
+	if backoff <= 0 || backoff > outboxMaxBackoff {
+		backoff = outboxMaxBackoff
+	}
+	util.Error("异步任务执行失败", zap.Uint("taskId", task.ID), zap.String("eventType", task.EventType),
+		zap.Int("attempts", attempts), zap.Bool("failed", failed), zap.Error(err))
+	if err := s.outboxDAO.MarkTaskAttemptFailed(task.ID, attempts, time.Now().Add(backoff), err.Error(), failed); err != nil {
+		util.Error("更新任务状态失败", zap.Uint("taskId", task.ID), zap.Error(err))
+	}
+}
+
+// 获取任务列表
+func (s OutboxService) ListTasks(status string, page, limit int) ([]model.Outbox, int64, int, error) {
+	return s.outboxDAO.ListTasks(status, page, limit)
+}
+
+// 重试失败任务
+func (s OutboxService) RetryTask(id uint) error {
+	ok, err := s.outboxDAO.RetryFailedTask(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrOutboxTaskNotFailed
+	}
+	return nil
+}
+
+// 丢弃失败任务
+func (s OutboxService) DiscardTask(id uint) error {
+	ok, err := s.outboxDAO.DiscardFailedTask(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrOutboxTaskNotFailed
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"backend/internal/dao/mysql"
+	"backend/internal/model"
+	"backend/internal/util"
+	"errors"
+)
+
+// 排序参数错误，controller 层映射为 400
+var ErrInvalidSort = errors.New("不支持的排序方式")
+
+// 相关度排序超出可翻页深度，controller 层映射为 400
+var ErrRelevanceTooDeep = errors.New("相关度排序最多只能翻到前 10000 条结果，请缩小搜索范围或改用其他排序方式")
+
+// 每页最大条数
+const maxPageLimit = 1000
+
+// 相关度排序按 OFFSET 翻页，限制可翻页深度
+const maxRelevanceOffset = 10000
+
+// 校验并补全数据集列表的分页参数：默认按创建时间倒序，游标必须与当前排序方式一致
+func normalizePageQuery(q *model.PageQuery, defaultLimit int) error {
+	if q.Sort == "" {
+		q.Sort = model.SortNewest
+	}
+	if q.Sort != model.SortRelevance && !mysql.IsDatasetSort(q.Sort) {
+		return ErrInvalidSort
+	}
+	if q.Limit < 1 {
+		q.Limit = defaultLimit
+	}
+	q.Limit = min(q.Limit, maxPageLimit)
+	if q.Page < 0 {
+		q.Page = 0
+	}
+	if q.Cursor != nil && q.Cursor.Sort != q.Sort {
+		return util.ErrInvalidCursor
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"backend/internal/dao/mysql"
+	"backend/internal/dao/search"
+	"backend/internal/model"
+	"backend/internal/util"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 筛选参数错误，controller 层映射为 400
+var ErrInvalidDatasetQuery = errors.New("筛选参数错误")
+
+// 校验列表/搜索接口的查询参数并转换为筛选条件
+// category 为“全部分类”、范围为 all 时不筛选；范围可省略一端，文件大小支持 KB/MB/GB/TB 单位
+func NewDatasetQuery(p *model.DatasetQueryParams) (*model.DatasetQuery, error) {
+	q := &model.DatasetQuery{
+		Text:         strings.TrimSpace(p.Search),
+		License:      strings.TrimSpace(p.License),
+		AuthorWallet: p.AuthorWallet,
+		IsFree:       p.IsFree,
+		Tags:         util.ParseTags(p.Tags),
+		MatchAllTags: p.TagMode != "or",
+	}
+	if q.Text == "" {
+		q.Text = strings.TrimSpace(p.Q)
+	}
+	if category := strings.TrimSpace(p.Category); category != "全部分类" {
+		q.Category = category
+	}
+
+	var err error
+	if q.MinSize, q.MaxSize, err = parseRange(p.FileSizeRange, util.ParseSize); err != nil {
+		return nil, fmt.Errorf("%w: fileSizeRange 应为 min-max，如 10MB-1GB、100-、-50MB", ErrInvalidDatasetQuery)
+	}
+	if q.MinPrice, q.MaxPrice, err = parseRange(p.PriceRange, parsePrice); err != nil {
+		return nil, fmt.Errorf("%w: priceRange 应为 min-max，如 0-9.9、100-、-50", ErrInvalidDatasetQuery)
+	}
+
+	if p.CreatedFrom != "" {
+		from, err := time.ParseInLocation("2006-01-02", p.CreatedFrom, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: createdFrom 应为 YYYY-MM-DD", ErrInvalidDatasetQuery)
+		}
+		q.CreatedFrom = &from
+	}
+	if p.CreatedTo != "" {
+		to, err := time.ParseInLocation("2006-01-02", p.CreatedTo, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: createdTo 应为 YYYY-MM-DD", ErrInvalidDatasetQuery)
+		}
+		// 包含当天
+		to = to.AddDate(0, 0, 1)
+		q.CreatedTo = &to
+	}
+	if q.CreatedFrom != nil && q.CreatedTo != nil && !q.CreatedFrom.Before(*q.CreatedTo) {
+		return nil, fmt.Errorf("%w: createdFrom 不能晚于 createdTo", ErrInvalidDatasetQuery)
+	}
+	return q, nil
+}
+
+// 由筛选条件和页码构造搜索条件
+func NewDatasetSearchQuery(q *model.DatasetQuery, page, limit int) *model.DatasetSearchQuery {
+	return &model.DatasetSearchQuery{
+		DatasetQuery: *q,
+		Page:         max(page, 1),
+		Limit:        min(max(limit, 1), maxPageLimit),
+	}
+}
+
+func parsePrice(s string) (float64, error) {
+	price, err := strconv.ParseFloat(s, 64)
+	if err != nil || price < 0 || math.IsInf(price, 0) || math.IsNaN(price) {
+		return 0, ErrInvalidDatasetQuery
+	}
+	return price, nil
+}
+
+// 解析 min-max 范围，可省略一端；空字符串或 all 表示不限，返回 nil
+func parseRange[T int64 | float64](s string, parse func(string) (T, error)) (*T, *T, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "all" {
+		return nil, nil, nil
+	}
+	lo, hi, ok := strings.Cut(s, "-")
+	lo, hi = strings.TrimSpace(lo), strings.TrimSpace(hi)
+	if !ok || (lo == "" && hi == "") {
+		return nil, nil, ErrInvalidDatasetQuery
+	}
+	var from, to *T
+	if lo != "" {
+		v, err := parse(lo)
+		if err != nil {
+			return nil, nil, err
+		}
+		from = &v
+	}
+	if hi != "" {
+		v, err := parse(hi)
+		if err != nil {
+			return nil, nil, err
+		}
+		to = &v
+	}
+	if from != nil && to != nil && *to < *from {
+		return nil, nil, ErrInvalidDatasetQuery
+	}
+	return from, to, nil
+}
+
+// 全文搜索数据集：按相关度排序，返回当前页、总数、总页数和分面统计
+func (s DatasetService) SearchDatasets(q *model.DatasetSearchQuery) ([]model.DatasetSearchItem, int64, int, model.SearchFacets, error) {
+	// 只搜索已通过文件校验的数据集
+	q.Status = model.DatasetStatusReady
+	result, err := s.searchIndex.Search(q)
+	if err != nil {
+		return nil, 0, 0, model.SearchFacets{}, err
+	}
+	ids := make([]uint, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.ID)
+	}
+	datasets, err := s.datasetDAO.GetDatasetsByIDs(ids)
+	if err != nil {
+		return nil, 0, 0, model.SearchFacets{}, err
+	}
+	byID := make(map[uint]model.DatasetListResponse, len(datasets))
+	for _, ds := range datasets {
+		byID[ds.ID] = ds
+	}
+
+	items := make([]model.DatasetSearchItem, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		ds, ok := byID[hit.ID]
+		if !ok {
+			// 索引中残留的已删除数据集
+			continue
+		}
+		items = append(items, model.DatasetSearchItem{DatasetListResponse: ds, Score: hit.Score, Highlights: hit.Highlights})
+	}
+	totalPages := int((result.Total + int64(q.Limit) - 1) / int64(q.Limit))
+	return items, result.Total, totalPages, result.Facets, nil
+}
+
+// 创建搜索索引：memory 为进程内倒排索引，启动时从数据库全量加载；默认使用 MySQL FULLTEXT ngram 索引
+// 进程内只创建一个，路由中的各服务与链上事件同步、文件校验 worker 共用
+func NewSearchIndex(db *gorm.DB, engine string) search.SearchIndex {
+	if engine != util.SEARCH_ENGINE_MEMORY {
+		return search.NewMySQLSearchIndex(db)
+	}
+	index := search.NewMemorySearchIndex()
+	datasets, err := mysql.NewDatasetDAO(db).GetAllDatasets()
+	if err != nil {
+		util.Error("加载搜索索引失败", zap.Error(err))
+		return index
+	}
+	index.Rebuild(datasets)
+	util.Info("搜索索引加载成功", zap.Int("数据集数量", len(datasets)))
+	return index
+}
+
+// 写入搜索索引，失败只记录日志（数据库为准，内存索引在重启时全量重建）
+func indexDataset(index search.SearchIndex, dataset *model.Dataset) {
+	if err := index.Index(dataset); err != nil {
+		util.Warn("更新搜索索引失败", zap.Uint("datasetID", dataset.ID), zap.Error(err))
+	}
+}
+
+// 从搜索索引中移除
+func unindexDataset(index search.SearchIndex, datasetID uint) {
+	if err := index.Delete(datasetID); err != nil {
+		util.Warn("移除搜索索引失败", zap.Uint("datasetID", datasetID), zap.Error(err))
+	}
+}
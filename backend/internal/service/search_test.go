@@ -0,0 +1,89 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/internal/util"
+	"errors"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	ptr := func(v int64) *int64 { return &v }
+	cases := []struct {
+		in       string
+		from, to *int64
+		err      bool
+	}{
+		{"", nil, nil, false},
+		{"all", nil, nil, false},
+		{"100-", ptr(100), nil, false},
+		{"-50", nil, ptr(50), false},
+		{"10MB-1GB", ptr(10 << 20), ptr(1 << 30), false},
+		{" 10 - 20 ", ptr(10), ptr(20), false},
+		{"5-5", ptr(5), ptr(5), false},
+		{"-", nil, nil, true},
+		{"abc", nil, nil, true},
+		{"abc-", nil, nil, true},
+		{"10-abc", nil, nil, true},
+		{"1GB-10MB", nil, nil, true},
+		{"100-50", nil, nil, true},
+	}
+	eq := func(a, b *int64) bool { return (a == nil && b == nil) || (a != nil && b != nil && *a == *b) }
+	for _, c := range cases {
+		from, to, err := parseRange(c.in, util.ParseSize)
+		if c.err {
+			if err == nil {
+				t.Errorf("parseRange(%q) err = nil, want error", c.in)
+			}
+			continue
+		}
+		if err != nil || !eq(from, c.from) || !eq(to, c.to) {
+			t.Errorf("parseRange(%q) = %v, %v, %v, want %v, %v", c.in, from, to, err, c.from, c.to)
+		}
+	}
+}
+
+func TestNewDatasetQuery(t *testing.T) {
+	cases := []struct {
+		name string
+		p    model.DatasetQueryParams
+		err  bool
+	}{
+		{"empty", model.DatasetQueryParams{}, false},
+		{"size open max", model.DatasetQueryParams{FileSizeRange: "100-"}, false},
+		{"size open min", model.DatasetQueryParams{FileSizeRange: "-50"}, false},
+		{"size units", model.DatasetQueryParams{FileSizeRange: "10MB-1GB"}, false},
+		{"size dash only", model.DatasetQueryParams{FileSizeRange: "-"}, true},
+		{"size not a number", model.DatasetQueryParams{FileSizeRange: "abc"}, true},
+		{"size reversed", model.DatasetQueryParams{FileSizeRange: "1GB-10MB"}, true},
+		{"price", model.DatasetQueryParams{PriceRange: "0-9.9"}, false},
+		{"price open max", model.DatasetQueryParams{PriceRange: "100-"}, false},
+		{"price dash only", model.DatasetQueryParams{PriceRange: "-"}, true},
+		{"price not a number", model.DatasetQueryParams{PriceRange: "abc"}, true},
+		{"price reversed", model.DatasetQueryParams{PriceRange: "50-10"}, true},
+		{"dates", model.DatasetQueryParams{CreatedFrom: "2024-01-01", CreatedTo: "2024-01-31"}, false},
+		{"same day", model.DatasetQueryParams{CreatedFrom: "2024-01-01", CreatedTo: "2024-01-01"}, false},
+		{"bad date", model.DatasetQueryParams{CreatedFrom: "2024/01/01"}, true},
+		{"dates reversed", model.DatasetQueryParams{CreatedFrom: "2024-02-01", CreatedTo: "2024-01-01"}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			q, err := NewDatasetQuery(&c.p)
+			if c.err {
+				if !errors.Is(err, ErrInvalidDatasetQuery) {
+					t.Fatalf("err = %v, want ErrInvalidDatasetQuery", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewDatasetQuery: %v", err)
+			}
+			if c.p.FileSizeRange == "10MB-1GB" && (q.MinSize == nil || *q.MinSize != 10<<20 || q.MaxSize == nil || *q.MaxSize != 1<<30) {
+				t.Errorf("size range = %v-%v, want 10MB-1GB in bytes", q.MinSize, q.MaxSize)
+			}
+			if c.p.FileSizeRange == "100-" && (q.MinSize == nil || *q.MinSize != 100 || q.MaxSize != nil) {
+				t.Errorf("size range = %v-%v, want 100-", q.MinSize, q.MaxSize)
+			}
+		})
+	}
+}
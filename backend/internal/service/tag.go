@@ -0,0 +1,77 @@
+package service
+
+import (
+	"backend/internal/dao/mysql"
+	"backend/internal/model"
+	"backend/internal/util"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 迁移旧标签时每批处理的数据集数
+const tagMigrateBatchSize = 500
+
+type TagService struct {
+	tagDAO *mysql.TagDAO
+	db     *gorm.DB
+}
+
+func NewTagService(tagDAO *mysql.TagDAO, db *gorm.DB) *TagService {
+	return &TagService{
+		tagDAO: tagDAO,
+		db:     db,
+	}
+}
+
+// 热门标签
+func (s TagService) GetPopularTags(limit int) ([]model.TagCount, error) {
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return s.tagDAO.GetPopularTags(limit)
+}
+
+// 标签前缀补全，前缀按标签规则规范化（小写、合并空白）
+func (s TagService) AutocompleteTags(prefix string, limit int) ([]model.TagCount, error) {
+	prefix = strings.ToLower(strings.Join(strings.Fields(prefix), " "))
+	if prefix == "" {
+		return []model.TagCount{}, nil
+	}
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+	return s.tagDAO.AutocompleteTags(prefix, limit)
+}
+
+// 将旧数据集逗号分隔的 tags 字段迁移到 tags / dataset_tags 表，已迁移的数据集跳过，可重复执行
+func (s TagService) MigrateLegacyTags() (int, error) {
+	migrated := 0
+	var afterID uint
+	for {
+		datasets, err := s.tagDAO.GetUntaggedDatasets(afterID, tagMigrateBatchSize)
+		if err != nil {
+			return migrated, err
+		}
+		if len(datasets) == 0 {
+			return migrated, nil
+		}
+
+		tx := s.db.Begin()
+		if tx.Error != nil {
+			return migrated, tx.Error
+		}
+		for _, ds := range datasets {
+			if err := s.tagDAO.SetDatasetTagsTX(tx, ds.ID, util.ParseTags(ds.Tags)); err != nil {
+				tx.Rollback()
+				return migrated, err
+			}
+		}
+		if err := tx.Commit().Error; err != nil {
+			tx.Rollback()
+			return migrated, err
+		}
+		migrated += len(datasets)
+		afterID = datasets[len(datasets)-1].ID
+	}
+}
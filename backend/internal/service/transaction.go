@@ -1,21 +1,42 @@
 package service
 
 import (
+	"backend/internal/dao/chain"
 	"backend/internal/dao/mysql"
 	"backend/internal/model"
+	"backend/internal/util"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// 链上交易校验（由 chain.ContractDAO 实现，未配置节点时为 nil）
+type ChainVerifier interface {
+	GetPurchaseEvent(ctx context.Context, txHash string) (*chain.PurchaseEvent, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// 确认交易时要求购买所在区块已有 confirmations 个确认（与链上事件同步的确认深度一致）
+// 确认接口完成的记录只在链上事件同步处理该区块后才会随区块回滚恢复，确认前等待足够的区块可避免回滚后仍保持已完成
 type TransactionService struct {
 	transactionDAO *mysql.TransactionDAO
 	userStats      *mysql.UserStatsDAO
+	chainVerifier  ChainVerifier
+	confirmations  uint64
 	db             *gorm.DB
 }
 
-func NewTransactionService(transactionDAO *mysql.TransactionDAO, userStats *mysql.UserStatsDAO, db *gorm.DB) *TransactionService {
+func NewTransactionService(transactionDAO *mysql.TransactionDAO, userStats *mysql.UserStatsDAO, chainVerifier ChainVerifier, confirmations uint64, db *gorm.DB) *TransactionService {
 	return &TransactionService{
 		transactionDAO: transactionDAO,
 		userStats:      userStats,
+		chainVerifier:  chainVerifier,
+		confirmations:  confirmations,
 		db:             db,
 	}
 }
@@ -49,12 +70,111 @@ func (s TransactionService) CreateTransaction(userID uint, req *model.CreateTran
 	return transactionId, nil
 }
 
-// 确认交易记录
-func (s TransactionService) ConfirmTransaction(m *model.TransactionConfirmRequest) error {
-	if err := s.transactionDAO.GetTransactionById(m.ID); err != nil {
+// 确认交易记录（以链上回执为准，未配置节点时拒绝确认）
+func (s TransactionService) ConfirmTransaction(userID uint, m *model.TransactionConfirmRequest) error {
+	if s.chainVerifier == nil {
+		util.Warn("未配置链上校验，拒绝确认交易", zap.Uint("transactionId", m.ID))
+		return ErrChainVerifierUnavailable
+	}
+
+	transaction, err := s.transactionDAO.GetTransaction(m.ID)
+	if err != nil {
+		return err
+	}
+
+	// 只能确认自己的交易记录
+	walletAddress, err := s.transactionDAO.GetUserWalletAddress(userID)
+	if err != nil {
+		return err
+	}
+	if walletAddress == "" || !strings.EqualFold(walletAddress, transaction.BuyerWalletAddress) {
+		return ErrNotTransactionOwner
+	}
+	if transaction.Status != "" && transaction.Status != "pending" {
+		return ErrTransactionNotPending
+	}
+	// 提前拒绝已使用的哈希，避免无谓的链上查询；完成记录时在事务中再次检查
+	if used, err := s.transactionDAO.CheckTxHashUsedTX(s.db, m.TxHash, m.ID); err != nil {
+		return err
+	} else if used {
+		return ErrTxHashUsed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	event, err := s.chainVerifier.GetPurchaseEvent(ctx, m.TxHash)
+	if err != nil {
+		return err
+	}
+	if err = checkPurchaseEvent(transaction, event); err != nil {
+		util.Error("链上交易校验失败", zap.Uint("transactionId", m.ID), zap.String("txHash", m.TxHash), zap.Error(err))
+		return err
+	}
+	latest, err := s.chainVerifier.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	if event.BlockNumber+s.confirmations > latest {
+		return ErrTxNotConfirmed
+	}
+
+	tx := s.db.Begin()
+	if err := tx.Error; err != nil {
 		return err
 	}
-	return s.transactionDAO.ConfirmTransaction(m)
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	used, err := s.transactionDAO.CheckTxHashUsedTX(tx, event.TxHash, m.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if used {
+		tx.Rollback()
+		return ErrTxHashUsed
+	}
+	ok, err := s.transactionDAO.CompleteTransactionTX(tx, m.ID, map[string]interface{}{
+		"tx_hash":         event.TxHash,
+		"block_hash":      event.BlockHash,
+		"block_number":    event.BlockNumber,
+		"block_timestamp": event.BlockTimestamp,
+		"gas":             strconv.FormatUint(event.GasUsed, 10),
+		"nonce":           event.Nonce,
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if !ok {
+		tx.Rollback()
+		return ErrTransactionNotPending
+	}
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// 比对链上购买事件与交易记录的买家、卖家、数据集ID和金额
+func checkPurchaseEvent(t *model.Transaction, e *chain.PurchaseEvent) error {
+	if !strings.EqualFold(e.Buyer, t.BuyerWalletAddress) {
+		return fmt.Errorf("%w: 买家地址 %s", ErrTransactionMismatch, e.Buyer)
+	}
+	if !strings.EqualFold(e.Seller, t.SellerWalletAddress) {
+		return fmt.Errorf("%w: 卖家地址 %s", ErrTransactionMismatch, e.Seller)
+	}
+	if e.DatasetID != uint64(t.DatasetID) {
+		return fmt.Errorf("%w: 数据集ID %d", ErrTransactionMismatch, e.DatasetID)
+	}
+	// amount 为 decimal(18,8)，按 8 位小数比较
+	if chain.WeiToEther(e.Price, 8) != strconv.FormatFloat(t.Amount, 'f', 8, 64) {
+		return fmt.Errorf("%w: 金额 %s", ErrTransactionMismatch, chain.WeiToEther(e.Price, 8))
+	}
+	return nil
 }
 
 // 删除交易记录
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"backend/internal/dao/chain"
+	"backend/internal/model"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestConfirmTransactionWithoutVerifier(t *testing.T) {
+	s := NewTransactionService(nil, nil, nil, 0, nil)
+	err := s.ConfirmTransaction(1, &model.TransactionConfirmRequest{ID: 1, TxHash: "0x1"})
+	if !errors.Is(err, ErrChainVerifierUnavailable) {
+		t.Fatalf("err = %v, want ErrChainVerifierUnavailable", err)
+	}
+}
+
+func TestCheckPurchaseEvent(t *testing.T) {
+	transaction := &model.Transaction{
+		BuyerWalletAddress:  "0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+		SellerWalletAddress: "0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC",
+		DatasetID:           42,
+		Amount:              1.5,
+	}
+	event := func() *chain.PurchaseEvent {
+		return &chain.PurchaseEvent{
+			DatasetID: 42,
+			Buyer:     "0x70997970c51812dc3a010c7d01b50e0d17dc79c8",
+			Seller:    "0x3c44cdddb6a900fa2b585dd299e03d12fa4293bc",
+			Price:     new(big.Int).Mul(big.NewInt(15), big.NewInt(1e17)),
+		}
+	}
+
+	if err := checkPurchaseEvent(transaction, event()); err != nil {
+		t.Fatalf("matching event rejected: %v", err)
+	}
+
+	wrongAmount := event()
+	wrongAmount.Price = big.NewInt(1e16)
+	wrongBuyer := event()
+	wrongBuyer.Buyer = "0x0000000000000000000000000000000000000bad"
+	wrongDataset := event()
+	wrongDataset.DatasetID = 43
+	for name, e := range map[string]*chain.PurchaseEvent{"amount": wrongAmount, "buyer": wrongBuyer, "dataset": wrongDataset} {
+		if err := checkPurchaseEvent(transaction, e); !errors.Is(err, ErrTransactionMismatch) {
+			t.Errorf("%s: err = %v, want ErrTransactionMismatch", name, err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"backend/internal/config"
+	"backend/internal/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	minio2 "github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+)
+
+// 上传会话相关错误：ErrUploadSessionNotFound 映射为 404，ErrUploadSessionClosed 映射为 409，其余映射为 400
+var (
+	ErrUploadSessionNotFound = errors.New("上传会话不存在")
+	ErrUploadSessionClosed   = errors.New("上传会话已结束或已过期，请重新上传")
+	ErrInvalidUploadPart     = errors.New("分片参数错误")
+	ErrUploadIncomplete      = errors.New("文件分片未全部上传")
+)
+
+// S3 分片上传限制：除最后一片外每片至少 5MB，单片最大 5GB，最多 10000 片
+const (
+	minUploadPartSize = 5 << 20
+	maxUploadPartSize = 5 << 30
+	maxUploadParts    = 10000
+)
+
+type uploadConfig struct {
+	partSize   int64
+	sessionTTL time.Duration
+	orphanTTL  time.Duration
+}
+
+// 读取上传配置，未配置时使用默认值
+func loadUploadConfig() uploadConfig {
+	cfg := config.LoadConfig().Upload
+	c := uploadConfig{
+		partSize:   int64(cfg.PartSize) << 20,
+		sessionTTL: time.Duration(cfg.SessionTTL) * time.Hour,
+		orphanTTL:  time.Duration(cfg.OrphanTTL) * time.Hour,
+	}
+	if c.partSize <= 0 {
+		c.partSize = minUploadPartSize
+	}
+	if c.sessionTTL <= 0 {
+		c.sessionTTL = 24 * time.Hour
+	}
+	if c.orphanTTL <= 0 {
+		c.orphanTTL = 24 * time.Hour
+	}
+	return c
+}
+
+// 查询本人的上传会话，uploadId 不为空时须一致
+func (s DatasetService) getUploadSession(userID uint, objectName, uploadId string) (*model.UploadSession, error) {
+	if err := checkObjectOwner(userID, objectName); err != nil {
+		return nil, err
+	}
+	session, err := s.uploadDAO.GetSessionByObjectName(objectName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUploadSessionNotFound
+		}
+		return nil, err
+	}
+	if session.UserID != userID {
+		return nil, ErrNotObjectOwner
+	}
+	if uploadId != "" && session.UploadID != uploadId {
+		return nil, ErrUploadSessionNotFound
+	}
+	return session, nil
+}
+
+// 查询本人上传中且未过期的会话
+func (s DatasetService) getUploadingSession(userID uint, objectName, uploadId string) (*model.UploadSession, error) {
+	session, err := s.getUploadSession(userID, objectName, uploadId)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != model.UploadSessionUploading || session.ExpiresAt.Before(time.Now()) {
+		return nil, ErrUploadSessionClosed
+	}
+	return session, nil
+}
+
+// 续传：从 MinIO 查询已上传的分片，返回会话和尚未上传的分片号，并为会话续期
+// 已合并的会话直接返回，不再查询分片
+func (s DatasetService) ResumeMultipartUpload(userID uint, objectName string) (*model.UploadSessionResponse, error) {
+	session, err := s.getUploadSession(userID, objectName, "")
+	if err != nil {
+		return nil, err
+	}
+	res := &model.UploadSessionResponse{UploadSession: session, Parts: []model.UploadedPart{}, MissingParts: []int{}}
+	switch {
+	case session.Status == model.UploadSessionCompleted || session.Status == model.UploadSessionUsed:
+		_ = json.Unmarshal(session.UploadedParts, &res.Parts)
+		return res, nil
+	case session.Status != model.UploadSessionUploading || session.ExpiresAt.Before(time.Now()):
+		return nil, ErrUploadSessionClosed
+	}
+
+	listed, err := s.datasetMinioDAO.ListObjectParts(context.Background(), objectName, session.UploadID)
+	if err != nil {
+		if minio2.ToErrorResponse(err).Code == "NoSuchUpload" {
+			return nil, ErrUploadSessionClosed
+		}
+		return nil, err
+	}
+	parts, uploadedBytes := uploadedParts(listed)
+	uploaded := make(map[int]bool, len(parts))
+	for _, p := range parts {
+		uploaded[p.PartNumber] = true
+	}
+	for n := 1; n <= session.TotalParts; n++ {
+		if !uploaded[n] {
+			res.MissingParts = append(res.MissingParts, n)
+		}
+	}
+
+	b, _ := json.Marshal(parts)
+	session.UploadedParts = b
+	session.UploadedBytes = uploadedBytes
+	session.ExpiresAt = time.Now().Add(loadUploadConfig().sessionTTL)
+	if err = s.uploadDAO.UpdateUploadedParts(session.ID, b, uploadedBytes, session.ExpiresAt); err != nil {
+		return nil, err
+	}
+	res.Parts = parts
+	return res, nil
+}
+
+// MinIO 分片列表转为会话记录（去掉 ETag 两端的引号），返回总字节数
+func uploadedParts(listed []minio2.ObjectPart) ([]model.UploadedPart, int64) {
+	parts := make([]model.UploadedPart, 0, len(listed))
+	var total int64
+	for _, p := range listed {
+		parts = append(parts, model.UploadedPart{PartNumber: p.PartNumber, ETag: strings.Trim(p.ETag, `"`), Size: p.Size})
+		total += p.Size
+	}
+	return parts, total
+}
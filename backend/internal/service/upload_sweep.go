@@ -0,0 +1,198 @@
+package service
+
+import (
+	"backend/internal/dao/minio"
+	"backend/internal/dao/mongo"
+	"backend/internal/dao/mysql"
+	"backend/internal/model"
+	"backend/internal/util"
+	"context"
+	"time"
+
+	minio2 "github.com/minio/minio-go/v7"
+	"go.uber.org/zap"
+)
+
+const (
+	uploadSweepBatch = 500   // 每次清理处理的过期会话数、每批核对的对象数上限
+	uploadSweepScan  = 10000 // 每次清理最多列出的对象数，下次从上次停下的位置继续
+)
+
+// 清理被放弃的上传：
+// 1. 超过有效期未完成的会话，中断 MinIO 分片上传；
+// 2. 合并后超过保留时间仍未创建数据集或发布版本的文件，先将会话置为删除中（之后不能再被使用）再删除对象和预览数据；
+// 3. 会话表之前发起的分片上传和直传的对象没有会话，按 MinIO 记录的时间同样处理，只处理上传接口生成的文件名
+type UploadSweepService struct {
+	uploadDAO       *mysql.UploadSessionDAO
+	datasetDAO      *mysql.DatasetDAO
+	datasetMongoDAO *mongo.DatasetsPreviewDAO
+	datasetMinioDAO *minio.DatasetMinioDAO
+	interval        time.Duration
+	cursor          string // 对象扫描的位置（上次列出的最后一个对象名），为空时从头开始
+}
+
+func NewUploadSweepService(uploadDAO *mysql.UploadSessionDAO, datasetDAO *mysql.DatasetDAO, datasetMongoDAO *mongo.DatasetsPreviewDAO,
+	datasetMinioDAO *minio.DatasetMinioDAO, interval time.Duration) *UploadSweepService {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &UploadSweepService{
+		uploadDAO:       uploadDAO,
+		datasetDAO:      datasetDAO,
+		datasetMongoDAO: datasetMongoDAO,
+		datasetMinioDAO: datasetMinioDAO,
+		interval:        interval,
+	}
+}
+
+// 定时清理，直到 ctx 结束
+func (s *UploadSweepService) Run(ctx context.Context) {
+	util.Info("上传清理任务已启动", zap.Duration("interval", s.interval))
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			util.Info("上传清理任务已停止")
+			return
+		case <-ticker.C:
+		}
+		if err := s.Sweep(ctx); err != nil && ctx.Err() == nil {
+			util.Error("清理分片上传失败", zap.Error(err))
+		}
+	}
+}
+
+// 执行一次清理
+func (s *UploadSweepService) Sweep(ctx context.Context) error {
+	cfg := loadUploadConfig()
+	now := time.Now()
+
+	expired, err := s.uploadDAO.ListExpiredSessions(model.UploadSessionUploading, now, uploadSweepBatch)
+	if err != nil {
+		return err
+	}
+	for _, session := range expired {
+		if err := s.datasetMinioDAO.AbortMultipartUpload(session.ObjectName, session.UploadID); err != nil && minio2.ToErrorResponse(err).Code != "NoSuchUpload" {
+			util.Warn("中断过期分片上传失败", zap.String("objectName", session.ObjectName), zap.Error(err))
+			continue
+		}
+		if _, err := s.uploadDAO.UpdateStatus(session.ID, model.UploadSessionUploading, model.UploadSessionExpired); err != nil {
+			return err
+		}
+		util.Info("已中断过期分片上传", zap.String("objectName", session.ObjectName), zap.Uint("userID", session.UserID))
+	}
+
+	unused, err := s.uploadDAO.ListExpiredSessions(model.UploadSessionCompleted, now, uploadSweepBatch)
+	if err != nil {
+		return err
+	}
+	for _, session := range unused {
+		if s.datasetDAO.CheckObjectNameUsed(s.datasetDAO.DB(), session.ObjectName) {
+			if _, err := s.uploadDAO.UpdateStatus(session.ID, model.UploadSessionCompleted, model.UploadSessionUsed); err != nil {
+				return err
+			}
+			continue
+		}
+		// 先认领会话：之后创建数据集或发布版本会被拒绝，认领失败说明文件刚被使用
+		claimed, err := s.uploadDAO.UpdateStatus(session.ID, model.UploadSessionCompleted, model.UploadSessionDeleting)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		to := model.UploadSessionDeleted
+		if !s.deleteOrphanObject(session.ObjectName) {
+			// 删除失败时恢复为已合并，下次重试
+			to = model.UploadSessionCompleted
+		}
+		if _, err := s.uploadDAO.UpdateStatus(session.ID, model.UploadSessionDeleting, to); err != nil {
+			return err
+		}
+	}
+
+	uploads, err := s.datasetMinioDAO.ListIncompleteUploads(ctx)
+	if err != nil {
+		return err
+	}
+	for _, u := range uploads {
+		if u.Initiated.After(now.Add(-cfg.sessionTTL)) {
+			continue
+		}
+		if exists, err := s.uploadDAO.SessionExists("", u.UploadID); err != nil || exists {
+			continue
+		}
+		if err := s.datasetMinioDAO.AbortMultipartUpload(u.Key, u.UploadID); err != nil {
+			util.Warn("中断过期分片上传失败", zap.String("objectName", u.Key), zap.Error(err))
+			continue
+		}
+		util.Info("已中断过期分片上传（无上传会话）", zap.String("objectName", u.Key))
+	}
+
+	return s.sweepOrphanObjects(ctx, now.Add(-cfg.orphanTTL))
+}
+
+// 从上次的位置继续扫描桶中的对象，最多列出 uploadSweepScan 个；
+// 只核对 before 之前修改的、上传接口生成的文件名（管理员放入桶中的其他文件如泄露样本不处理），按批查询是否被使用或有上传会话，每批固定 3 次查询
+func (s *UploadSweepService) sweepOrphanObjects(ctx context.Context, before time.Time) error {
+	var batch []string
+	var flushErr error
+	flush := func() bool {
+		used, err := s.datasetDAO.GetUsedObjectNames(batch)
+		if err == nil {
+			var sessions map[string]bool
+			if sessions, err = s.uploadDAO.GetSessionObjectNames(batch); err == nil {
+				for _, name := range batch {
+					if !used[name] && !sessions[name] {
+						s.deleteOrphanObject(name)
+					}
+				}
+			}
+		}
+		batch = batch[:0]
+		flushErr = err
+		return err == nil
+	}
+
+	scanned, last := 0, s.cursor
+	err := s.datasetMinioDAO.WalkObjects(ctx, s.cursor, func(o minio2.ObjectInfo) bool {
+		scanned++
+		last = o.Key
+		if o.LastModified.Before(before) && minio.IsDatasetObjectName(o.Key) {
+			batch = append(batch, o.Key)
+			if len(batch) >= uploadSweepBatch && !flush() {
+				return false
+			}
+		}
+		return scanned < uploadSweepScan
+	})
+	if err == nil && flushErr == nil && len(batch) > 0 {
+		flush()
+	}
+	if err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		return err
+	}
+	// 未列满说明已扫描到桶的末尾，下次从头开始
+	if scanned < uploadSweepScan {
+		last = ""
+	}
+	s.cursor = last
+	return nil
+}
+
+// 删除未被使用的对象及其预览数据，返回对象是否已删除
+func (s *UploadSweepService) deleteOrphanObject(objectName string) bool {
+	if err := s.datasetMinioDAO.DeleteObject(objectName); err != nil && minio2.ToErrorResponse(err).Code != "NoSuchKey" {
+		util.Warn("删除未使用的数据集文件失败", zap.String("objectName", objectName), zap.Error(err))
+		return false
+	}
+	if err := s.datasetMongoDAO.DeletePreviewData(objectName); err != nil {
+		util.Warn("删除未使用文件的预览数据失败", zap.String("objectName", objectName), zap.Error(err))
+	}
+	util.Info("已删除未使用的数据集文件", zap.String("objectName", objectName))
+	return true
+}
@@ -233,12 +233,110 @@ func (s *AuthService) UpdateUserInfoWithAvatar(userID uint, req *mysql2.UpdateUs
 
 // 成为商家
 func (s *AuthService) UpgradeSeller(u uint) error {
-	return s.userDAO.UpgradeSeller(u)
+	if err := s.userDAO.UpgradeSeller(u); err != nil {
+		return err
+	}
+	// 角色变更，清除用户信息缓存
+	return s.userRedisDAO.DelRedisUserInfo(u)
 }
 
 // 成为商家失败回滚
 func (s *AuthService) DowngradeToUser(u uint) error {
-	return s.userDAO.DowngradeToUser(u)
+	if err := s.userDAO.DowngradeToUser(u); err != nil {
+		return err
+	}
+	// 角色变更，清除用户信息缓存
+	return s.userRedisDAO.DelRedisUserInfo(u)
+}
+
+// 角色权限表（与前端 lib/constants/roles.ts 保持一致）
+var rolePermissions = map[string]mysql2.RoleInfoResponse{
+	util.ROLE_USER: {
+		Role:        util.ROLE_USER,
+		DisplayName: "普通用户",
+		Permissions: []mysql2.Permission{
+			{Action: "read", Resource: "dataset"},
+			{Action: "download", Resource: "dataset", Conditions: map[string]interface{}{"is_free": true}},
+			{Action: "purchase", Resource: "dataset", Conditions: map[string]interface{}{"has_wallet": true}},
+			{Action: "read", Resource: "profile"},
+			{Action: "update", Resource: "profile"},
+			{Action: "bind", Resource: "wallet"},
+		},
+	},
+	util.ROLE_SELLER: {
+		Role:        util.ROLE_SELLER,
+		DisplayName: "商家",
+		Permissions: []mysql2.Permission{
+			{Action: "read", Resource: "dataset"},
+			{Action: "download", Resource: "dataset", Conditions: map[string]interface{}{"is_free": true}},
+			{Action: "purchase", Resource: "dataset", Conditions: map[string]interface{}{"has_wallet": true}},
+			{Action: "read", Resource: "profile"},
+			{Action: "update", Resource: "profile"},
+			{Action: "bind", Resource: "wallet"},
+			{Action: "create", Resource: "dataset"},
+			{Action: "update", Resource: "dataset", Conditions: map[string]interface{}{"is_owner": true}},
+			{Action: "delete", Resource: "dataset", Conditions: map[string]interface{}{"is_owner": true}},
+			{Action: "read", Resource: "sales_stats"},
+		},
+	},
+	util.ROLE_ADMIN: {
+		Role:        util.ROLE_ADMIN,
+		DisplayName: "管理员",
+		Permissions: []mysql2.Permission{
+			{Action: "read", Resource: "user"},
+			{Action: "read", Resource: "dataset"},
+			{Action: "delete", Resource: "dataset"},
+			{Action: "read", Resource: "platform_stats"},
+			{Action: "manage", Resource: "categories"},
+			{Action: "manage", Resource: "reports"},
+			{Action: "read", Resource: "profile"},
+			{Action: "update", Resource: "profile"},
+		},
+	},
+}
+
+// 获取用户角色（RequireRole 中间件使用）
+func (s *AuthService) GetUserRole(userID uint) (string, error) {
+	// 缓存写入失败时 GetUserInfo 仍会返回用户信息
+	user, err := s.GetUserInfo(userID)
+	if user == nil {
+		return "", err
+	}
+	return user.Role, nil
+}
+
+// 获取角色信息
+func (s *AuthService) GetRoleInfo(userID uint) (*mysql2.RoleInfoResponse, error) {
+	role, err := s.GetUserRole(userID)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := rolePermissions[role]
+	if !ok {
+		return nil, errors.New("未知角色")
+	}
+	return &info, nil
+}
+
+// 校验权限
+func (s *AuthService) CheckPermission(userID uint, req *mysql2.CheckPermissionRequest) (bool, string, error) {
+	info, err := s.GetRoleInfo(userID)
+	if err != nil {
+		return false, "", err
+	}
+	for _, p := range info.Permissions {
+		if p.Action != req.Action || p.Resource != req.Resource {
+			continue
+		}
+		// 检查条件：请求未携带的条件视为不满足
+		for k, v := range p.Conditions {
+			if got, ok := req.Conditions[k]; !ok || got != v {
+				return false, info.Role, nil
+			}
+		}
+		return true, info.Role, nil
+	}
+	return false, info.Role, nil
 }
 
 // 获取作者信息
@@ -49,6 +49,11 @@ func (w *WalletService) BindWallet(userID uint, req *mysql2.BindWalletRequest) e
 		util.Error("绑定钱包失败", zap.Error(err))
 		return errors.New(err.Error())
 	}
+
+	// 钱包和角色已变更，清除用户信息的redis缓存
+	if err := w.userRedisDAO.DelRedisUserInfo(userID); err != nil {
+		return errors.New(err.Error())
+	}
 	return nil
 }
 
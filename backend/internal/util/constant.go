@@ -5,6 +5,8 @@ const (
 	EMAIL_VERIFY_CODE     = "code:verify"
 	CODE_TTLS             = "code:TTL"
 	PAID_DOWNLOAD_TASK_ID = "paid_download_task_id"
+	PAID_STREAM_SIZE      = "paid_stream_size"
+	PAID_STREAM_CHECKSUM  = "paid_stream_checksum"
 )
 
 // minio
@@ -20,19 +22,36 @@ const (
 	A            = "a"
 )
 
+// role
+const (
+	ROLE_USER   = "user"
+	ROLE_SELLER = "seller"
+	ROLE_ADMIN  = "admin"
+)
+
 // dataset
 const (
-	DATASET_EXTENSION      = ".jsonl"
 	DATASET_EXPIRE         = 60
 	TOTAL_TASK_COUNT       = 4
 	FINGERPRINT_GROUP_SIZE = 6
 )
 
+// search
+const (
+	SEARCH_ENGINE_MYSQL  = "mysql"
+	SEARCH_ENGINE_MEMORY = "memory"
+)
+
 // admin
 const (
 	USERS_GROWTH_MONTH = 6
 )
 
+// outbox
+const (
+	OUTBOX_DELETE_OBJECT_DELAY = 72 // 管理员删除数据集后文件保留时长（小时），期间可恢复
+)
+
 // home ranks
 const (
 	RANK_DATASET_HOT    = "rank:dataset:hot"
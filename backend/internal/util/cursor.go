@@ -0,0 +1,30 @@
+package util
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("分页游标无效")
+
+// 将游标编码为不透明字符串（JSON + URL 安全的 base64）
+func EncodeCursor(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// 解码 EncodeCursor 生成的游标，格式错误返回 ErrInvalidCursor
+func DecodeCursor(s string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
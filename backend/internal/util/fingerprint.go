@@ -1,7 +1,6 @@
 package util
 
 import (
-	"bufio"
 	"io"
 	"mime/multipart"
 	"strings"
@@ -49,21 +48,45 @@ var VerifyFingerprintMap = map[string]string{
 
 // 插入指纹
 func InsertFingerprint(line, timestamp string, pos int) (string, error) {
-	if len(timestamp) != 10 {
+	payload := fingerprintV1Payload(timestamp, pos)
+	if payload == "" {
 		return line, nil // 跳过
 	}
+	return insertIntoJSONStringValue(line, payload), nil
+}
 
-	var payload string
-
+// v1 指纹第 pos 行的载荷：第 0 行为起始标记，之后每行两位时间戳数字
+func fingerprintV1Payload(timestamp string, pos int) string {
+	if len(timestamp) != 10 {
+		return ""
+	}
 	if pos == 0 {
-		payload = InsertFingerprintMap["start"]
-	} else {
-		idx := (pos - 1) * 2
-		d1 := string(timestamp[idx])
-		d2 := string(timestamp[idx+1])
-		payload = InsertFingerprintMap[d1] + InsertFingerprintMap[d2]
+		return InsertFingerprintMap["start"]
 	}
-	return insertIntoJSONStringValue(line, payload), nil
+	idx := (pos - 1) * 2
+	d1 := string(timestamp[idx])
+	d2 := string(timestamp[idx+1])
+	return InsertFingerprintMap[d1] + InsertFingerprintMap[d2]
+}
+
+// 按数据集格式插入指纹后写入 w，返回写入的字节数（输出内容只取决于源文件和指纹，可重复生成）
+// afterRecord 在每条记录写入后以已读取的源字节数回调，返回错误时终止
+func WriteWithFingerprint(w io.Writer, src io.Reader, format, fingerprint string, afterRecord func(read int64) error) (int64, error) {
+	f, err := GetFingerprinter(format)
+	if err != nil {
+		return 0, err
+	}
+	symbols, err := EncodeFingerprint(fingerprint)
+	if err != nil {
+		return 0, err
+	}
+	payload := func(i int) string {
+		if symbols != nil {
+			return symbols[i%len(symbols)]
+		}
+		return fingerprintV1Payload(fingerprint, i%FINGERPRINT_GROUP_SIZE)
+	}
+	return f.Embed(w, src, payload, afterRecord)
 }
 
 // 插入指纹处理函数
@@ -104,124 +127,32 @@ func insertIntoJSONStringValue(line, payload string) string {
 	return line
 }
 
-// 验证函数：从文件中提取零宽时间戳
-func VerifyFingerprint(file *multipart.FileHeader) (string, error) {
-	f, err := file.Open()
-	if err != nil {
-		return "", err
-	}
-	defer f.Close()
-
-	scanner := bufio.NewScanner(f)
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
-
-	for i, line := range lines {
-		pos := findFingerprintPosition(line)
-		if pos != -1 && containsStart(line, pos) {
-			return extractTimestamp(lines, i, pos), nil
-		}
-	}
-	return "无指纹", nil
-}
-
-// 本地文件验证函数
-//// 验证函数：从文件中提取零宽时间戳
-//func VerifyFingerprint(filePath string) (string, error) {
-//	file, err := os.Open(filePath)
-//	if err != nil {
-//		return "", err
-//	}
-//	defer file.Close()
-//
-//	scanner := bufio.NewScanner(file)
-//	var lines []string
-//	for scanner.Scan() {
-//		lines = append(lines, scanner.Text())
-//	}
-//
-//	for i, line := range lines {
-//		pos := findFingerprintPosition(line)
-//		if pos != -1 && containsStart(line, pos) {
-//			return extractTimestamp(lines, i, pos), nil
-//		}
-//	}
-//	fmt.Println("未找到指纹")
-//	return "", nil
-//}
-
-// 查找当前行指纹插入点类型（0=“}前”，1=“:”后，2=第一个key的“后”，-1=无）
-func findFingerprintPosition(line string) int {
-	if len(line) < 2 {
-		return -1
-	}
-	if line[len(line)-2] == '"' && line[len(line)-1] == '}' {
-		return 0
-	}
-	if idx := strings.Index(line, ":"); idx != -1 {
-		j := idx + 1
-		for j < len(line) && line[j] == ' ' {
-			j++
-		}
-		if j < len(line) && line[j] == '"' {
-			return 1
-		}
-	}
-	if strings.Index(line, `"`) != -1 {
-		return 2
-	}
-	return -1
+// 指纹检测结果
+type FingerprintResult struct {
+	Version    int     // 1=时间戳指纹，2=购买 ID 指纹
+	PurchaseID uint64  // v2：交易记录 ID
+	Timestamp  string  // v1：交易区块时间戳
+	Votes      int     // 支持该结果的最少票数（v2 为各序号得票最小值，v1 为完整时间戳出现次数）
+	Confidence float64 // 得票占比，越接近 1 越可信
 }
 
-// 判断在指定位置是否存在start字符
-func containsStart(line string, pos int) bool {
-	target := "\uFEFF"
-	switch pos {
-	case 0:
-		return strings.Contains(line[len(line)-5:], target)
-	case 1:
-		idx := strings.Index(line, ":")
-		return idx != -1 && strings.Contains(line[idx:], target)
-	case 2:
-		firstQuote := strings.Index(line, `"`)
-		return firstQuote != -1 && strings.Contains(line[firstQuote:], target)
-	default:
-		return false
+// 验证函数：按数据集格式从上传文件中提取指纹，未检测到时返回 nil
+// format 为空时按文件扩展名推断
+func VerifyFingerprint(file *multipart.FileHeader, format string) (*FingerprintResult, error) {
+	format, err := ResolveDatasetFormat(format, file.Filename)
+	if err != nil {
+		return nil, err
 	}
-}
-
-// 根据起点行与插入点提取后续时间戳字符
-func extractTimestamp(lines []string, startIdx, pos int) string {
-	var timestamp strings.Builder
-	for i := startIdx + 1; i < len(lines) && i <= startIdx+5; i++ {
-		chunks := extractTwoDigits(lines[i], pos)
-		timestamp.WriteString(chunks)
+	fp, err := GetFingerprinter(format)
+	if err != nil {
+		return nil, err
 	}
-	return timestamp.String()
-}
-
-// 提取当前行插入点处的两个零宽字符对应的数字
-func extractTwoDigits(line string, pos int) string {
-	switch pos {
-	case 0:
-		return findDigits(line[len(line)-8:])
-	case 1:
-		idx := strings.Index(line, ":")
-		if idx == -1 {
-			return ""
-		}
-		return findDigits(line[idx:])
-	case 2:
-		firstQuote := strings.Index(line, `"`)
-		if firstQuote == -1 {
-			return ""
-		}
-		return findDigits(line[firstQuote:])
-	default:
-		return ""
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close()
+	return fp.Extract(f, nil)
 }
 
 // 提取零宽字符对应的数字
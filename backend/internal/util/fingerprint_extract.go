@@ -0,0 +1,309 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// 检测扫描窗口：超长行按窗口切分，内存占用与文件大小和行长无关
+const fingerprintScanWindow = 64 * 1024
+
+// 相邻片段重叠的字节数，保证跨片段的符号不被截断
+const fingerprintScanOverlap = fingerprintV2SymbolBytes - 1
+
+// 按记录解析时单条 CSV 记录或单个 JSON token 的字节上限
+// csv.Reader 和 json.Decoder 会缓冲整条记录/整个字符串，超过上限时剩余内容改为按行扫描，内存占用保持有界
+const fingerprintMaxRecordBytes = 1 << 20
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+var errRecordTooLong = errors.New("记录超过长度上限")
+
+// 限制单条记录读取字节数的 reader，每条记录解析完后 reset
+// 解析器的预读会计入下一条记录，上限远大于预读缓冲，不影响正常记录
+type recordLimitReader struct {
+	R   io.Reader
+	Max int64
+	n   int64
+}
+
+func (l *recordLimitReader) Read(p []byte) (int, error) {
+	remaining := l.Max - l.n
+	if remaining <= 0 {
+		return 0, errRecordTooLong
+	}
+	if int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := l.R.Read(p)
+	l.n += int64(n)
+	return n, err
+}
+
+func (l *recordLimitReader) reset() {
+	l.n = 0
+}
+
+// 指纹收集器：逐条记录累计 v2 符号票数和 v1 时间戳票数
+// 只关心零宽字符本身，不依赖其在记录中的位置，记录被重新排版、调整键顺序后仍可提取
+type fingerprintCollector struct {
+	v2          fingerprintV2Votes
+	v1          map[string]int
+	v1Total     int
+	v1Digits    strings.Builder
+	v1Remaining int
+
+	// 当前记录
+	hasStart bool
+	digits   []byte // 最多保留 3 位，多于两位说明不是 v1 载荷
+}
+
+func newFingerprintCollector() *fingerprintCollector {
+	return &fingerprintCollector{v1: make(map[string]int)}
+}
+
+// 扫描当前记录中的一段文本，只处理起点在 limit 之前的字符
+func (c *fingerprintCollector) scan(text string, limit int) {
+	decodeFingerprintV2Symbols(text, limit, c.v2.add)
+	if idx := strings.Index(text, InsertFingerprintMap["start"]); idx != -1 && idx < limit {
+		c.hasStart = true
+	}
+	for i, r := range text {
+		if i >= limit || len(c.digits) > 2 {
+			break
+		}
+		if v, ok := VerifyFingerprintMap[string(r)]; ok && v != "start" {
+			c.digits = append(c.digits, v[0])
+		}
+	}
+}
+
+// 结束当前记录：v1 指纹每组 6 条记录，首条为起始标记，之后每条两位时间戳数字
+func (c *fingerprintCollector) endRecord() {
+	switch {
+	case c.hasStart || len(c.digits) == 0:
+		// 起始标记 U+FEFF 常被当作 BOM 删除，没有数字的记录同样视为组的起点
+		c.v1Digits.Reset()
+		c.v1Remaining = FINGERPRINT_GROUP_SIZE - 1
+	case c.v1Remaining > 0:
+		// 每条恰好两位数字，否则本组作废
+		if len(c.digits) == 2 {
+			c.v1Digits.Write(c.digits)
+		}
+		c.v1Remaining--
+		if c.v1Remaining == 0 {
+			if ts := c.v1Digits.String(); len(ts) == 10 {
+				c.v1[ts]++
+				c.v1Total++
+			}
+		}
+	}
+	c.resetRecord()
+}
+
+func (c *fingerprintCollector) resetRecord() {
+	c.hasStart = false
+	c.digits = c.digits[:0]
+}
+
+// 汇总结果：优先 v2（按序号多数投票 + CRC 校验），否则回退到 v1 时间戳（多数投票）
+func (c *fingerprintCollector) result() *FingerprintResult {
+	if id, votes, confidence, ok := c.v2.resolve(); ok {
+		return &FingerprintResult{Version: FINGERPRINT_VERSION_V2, PurchaseID: id, Votes: votes, Confidence: confidence}
+	}
+	best, bestCount := "", 0
+	for ts, count := range c.v1 {
+		if count > bestCount || (count == bestCount && ts < best) {
+			best, bestCount = ts, count
+		}
+	}
+	if bestCount == 0 {
+		return nil
+	}
+	return &FingerprintResult{Version: 1, Timestamp: best, Votes: bestCount, Confidence: float64(bestCount) / float64(c.v1Total)}
+}
+
+// 按行扫描，每个非空行为一条记录；超长行按窗口切分
+// progress 在每个扫描片段后回调，返回错误时终止
+func (c *fingerprintCollector) scanLines(r io.Reader, progress func() error) error {
+	br := bufio.NewReaderSize(r, fingerprintScanWindow)
+	blank := true
+	var buf, carry []byte
+	for {
+		seg, err := br.ReadSlice('\n')
+		if err != nil && err != bufio.ErrBufferFull && err != io.EOF {
+			return err
+		}
+		lineEnd := err != bufio.ErrBufferFull
+		buf = append(append(buf[:0], carry...), seg...)
+
+		// 行未结束时，末尾 overlap 字节留到下一片段，只处理起点在 limit 之前的符号
+		limit := len(buf)
+		if !lineEnd {
+			limit = max(len(buf)-fingerprintScanOverlap, 0)
+		}
+		c.scan(string(buf), limit)
+		if blank && len(bytes.TrimFunc(buf[:limit], unicode.IsSpace)) > 0 {
+			blank = false
+		}
+		carry = append(carry[:0], buf[limit:]...)
+
+		if lineEnd {
+			if blank {
+				// 空行不是记录
+				c.resetRecord()
+			} else {
+				c.endRecord()
+			}
+			blank = true
+		}
+
+		if perr := progress(); perr != nil {
+			return perr
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// 逐行扫描提取指纹，未检测到时返回 nil
+func ExtractFingerprint(r io.Reader) (*FingerprintResult, error) {
+	return ExtractFingerprintWithProgress(r, nil)
+}
+
+// 流式逐行提取指纹，progress 在每个扫描片段后以已读取的字节数回调，返回错误时终止
+func ExtractFingerprintWithProgress(r io.Reader, progress func(read int64) error) (*FingerprintResult, error) {
+	cr := &CountingReader{R: r}
+	c := newFingerprintCollector()
+	if err := c.scanLines(cr, readProgress(cr, progress)); err != nil {
+		return nil, err
+	}
+	return c.result(), nil
+}
+
+// 按 JSON 值解析提取指纹（jsonl 与 JSON 数组通用）
+// 顶层数组的每个元素、顶层的其它每个值各为一条记录，只扫描解码后的字符串（键和值），
+// 不受压缩/美化排版、键顺序和 \uXXXX 转义的影响；遇到非法 JSON 或超长 token 时剩余内容按行扫描
+func extractJSONRecords(r io.Reader, progress func(read int64) error) (*FingerprintResult, error) {
+	cr := &CountingReader{R: r}
+	br := bufio.NewReaderSize(cr, fingerprintScanWindow)
+	skipBOM(br)
+	report := readProgress(cr, progress)
+	c := newFingerprintCollector()
+
+	limit := &recordLimitReader{R: br, Max: fingerprintMaxRecordBytes}
+	dec := json.NewDecoder(limit)
+	dec.UseNumber()
+	depth := 0
+	arrayRoot := false
+	for {
+		tok, err := dec.Token()
+		limit.reset()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.resetRecord()
+			if err := c.scanLines(io.MultiReader(dec.Buffered(), br), report); err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		if depth == 0 {
+			arrayRoot = tok == json.Delim('[')
+		}
+		opened := false
+		switch t := tok.(type) {
+		case json.Delim:
+			if t == '[' || t == '{' {
+				depth++
+				opened = true
+			} else {
+				depth--
+			}
+		case string:
+			c.scan(t, len(t))
+		}
+
+		// 顶层数组中的元素结束，或顶层的其它值结束
+		if (arrayRoot && depth == 1 && !opened) || (!arrayRoot && depth == 0) {
+			c.endRecord()
+			if err := report(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return c.result(), nil
+}
+
+// 按 CSV/TSV 记录解析提取指纹，引号内换行的单元格属于同一条记录，无法解析的记录跳过
+// 遇到超长记录时（如未闭合的引号）剩余内容按行扫描
+func extractDelimitedRecords(delim rune) func(r io.Reader, progress func(read int64) error) (*FingerprintResult, error) {
+	return func(r io.Reader, progress func(read int64) error) (*FingerprintResult, error) {
+		cr := &CountingReader{R: r}
+		br := bufio.NewReaderSize(cr, fingerprintScanWindow)
+		skipBOM(br)
+		report := readProgress(cr, progress)
+		c := newFingerprintCollector()
+
+		limit := &recordLimitReader{R: br, Max: fingerprintMaxRecordBytes}
+		reader := csv.NewReader(limit)
+		reader.Comma = delim
+		reader.LazyQuotes = true
+		reader.FieldsPerRecord = -1
+		reader.ReuseRecord = true
+		for {
+			fields, err := reader.Read()
+			limit.reset()
+			if err == io.EOF {
+				break
+			}
+			if errors.Is(err, errRecordTooLong) {
+				c.resetRecord()
+				if err := c.scanLines(br, report); err != nil {
+					return nil, err
+				}
+				break
+			}
+			if err != nil {
+				var parseErr *csv.ParseError
+				if errors.As(err, &parseErr) {
+					continue
+				}
+				return nil, err
+			}
+			for _, field := range fields {
+				c.scan(field, len(field))
+			}
+			c.endRecord()
+			if err := report(); err != nil {
+				return nil, err
+			}
+		}
+		return c.result(), nil
+	}
+}
+
+// 跳过文件开头的 UTF-8 BOM
+func skipBOM(br *bufio.Reader) {
+	if head, _ := br.Peek(len(utf8BOM)); bytes.Equal(head, utf8BOM) {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+}
+
+func readProgress(cr *CountingReader, progress func(read int64) error) func() error {
+	return func() error {
+		if progress == nil {
+			return nil
+		}
+		return progress(cr.Count)
+	}
+}
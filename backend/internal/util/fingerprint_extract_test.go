@@ -0,0 +1,134 @@
+package util
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testPurchaseID = 987654
+
+// 各格式的示例源文件
+var extractSeeds = map[string][]string{
+	FORMAT_JSONL: {
+		"{\"id\": 1, \"text\": \"alpha\"}\n{\"id\": 2, \"text\": \"beta\"}\n{\"id\": 3, \"text\": \"gamma\"}\n{\"id\": 4, \"text\": \"delta\"}\n" +
+			"{\"id\": 5, \"text\": \"epsilon\"}\n{\"id\": 6, \"text\": \"zeta\"}\n{\"id\": 7, \"text\": \"eta\"}\n{\"id\": 8, \"text\": \"theta\"}\n",
+		"{\"q\": \"a\\\"b\", \"nested\": {\"k\": [\"x\", \"y\"]}}\n",
+	},
+	FORMAT_CSV: {
+		"id,text\n1,alpha\n2,\"beta, quoted\"\n3,gamma\n4,delta\n5,\"multi\nline\"\n6,zeta\n7,eta\n8,theta\n",
+		"a,b\n\"unterminated,1\n",
+	},
+	FORMAT_TSV: {
+		"id\ttext\n1\talpha\n2\tbeta\n3\tgamma\n4\tdelta\n5\tepsilon\n6\tzeta\n7\teta\n8\ttheta\n",
+	},
+	FORMAT_TEXT: {
+		"alpha\nbeta\ngamma\ndelta\n\nepsilon\nzeta\neta\ntheta\n",
+	},
+	FORMAT_JSON: {
+		`[{"text": "alpha"}, {"text": "beta"}, {"text": "gamma"}, {"text": "delta"}, {"text": "epsilon"}, {"text": "zeta"}, {"text": "eta"}, {"text": "theta"}]`,
+		`["a", "b", {"c": ["d"]}]`,
+	},
+}
+
+// 写入指纹后的内容
+func embedFingerprint(t testing.TB, format string, src []byte) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	if _, err := WriteWithFingerprint(&out, bytes.NewReader(src), format, NewFingerprintV2(testPurchaseID), nil); err != nil {
+		// 任意输入（如非法 JSON）都应能写出，不应出错
+		t.Fatalf("WriteWithFingerprint(%s): %v", format, err)
+	}
+	return out.Bytes()
+}
+
+// 对带指纹的内容做变形：在 pos 处插入 junk，并删除其后的 cut 个字节
+func mutate(data []byte, pos, cut uint16, junk []byte) []byte {
+	p := int(pos) % (len(data) + 1)
+	end := min(p+int(cut), len(data))
+	out := make([]byte, 0, len(data)+len(junk))
+	out = append(out, data[:p]...)
+	out = append(out, junk...)
+	return append(out, data[end:]...)
+}
+
+// 任意输入都不应 panic 或返回读取错误；检测到 v2 指纹时不能归到其他买家
+func fuzzExtract(f *testing.F, format string) {
+	withFingerprintKey(f, "fuzz-secret")
+	for _, seed := range extractSeeds[format] {
+		f.Add([]byte(seed), uint16(0), uint16(0), []byte(nil))
+		f.Add([]byte(seed), uint16(7), uint16(3), []byte("\"\n{["))
+		f.Add(append(append([]byte{}, utf8BOM...), seed...), uint16(40), uint16(60), []byte("\u200b\u206c\u206d"))
+	}
+	fp, err := GetFingerprinter(format)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Fuzz(func(t *testing.T, src []byte, pos, cut uint16, junk []byte) {
+		data := mutate(embedFingerprint(t, format, src), pos, cut, junk)
+		res, err := fp.Extract(bytes.NewReader(data), nil)
+		if err != nil {
+			t.Fatalf("Extract: %v", err)
+		}
+		if res != nil && res.Version == FINGERPRINT_VERSION_V2 && res.PurchaseID != testPurchaseID {
+			t.Fatalf("extracted purchase %d, embedded %d", res.PurchaseID, testPurchaseID)
+		}
+	})
+}
+
+func FuzzExtractJSONL(f *testing.F) { fuzzExtract(f, FORMAT_JSONL) }
+func FuzzExtractCSV(f *testing.F)   { fuzzExtract(f, FORMAT_CSV) }
+func FuzzExtractTSV(f *testing.F)   { fuzzExtract(f, FORMAT_TSV) }
+func FuzzExtractText(f *testing.F)  { fuzzExtract(f, FORMAT_TEXT) }
+func FuzzExtractJSON(f *testing.F)  { fuzzExtract(f, FORMAT_JSON) }
+
+// 完整的示例文件写入指纹后，加上 BOM 或改为 CRLF 换行仍能提取出购买 ID
+func TestExtractRoundTrip(t *testing.T) {
+	withFingerprintKey(t, "test-secret")
+	for format, seeds := range extractSeeds {
+		fp, err := GetFingerprinter(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := embedFingerprint(t, format, []byte(seeds[0]))
+		for name, input := range map[string][]byte{
+			"plain": data,
+			"bom":   append(append([]byte{}, utf8BOM...), data...),
+			"crlf":  bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n")),
+		} {
+			res, err := fp.Extract(bytes.NewReader(input), nil)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", format, name, err)
+			}
+			if res == nil || res.PurchaseID != testPurchaseID {
+				t.Errorf("%s/%s: result = %+v, want purchase %d", format, name, res, testPurchaseID)
+			}
+		}
+	}
+}
+
+// 超过上限的记录不会被整条缓冲，之后的记录按行扫描仍能提取
+func TestExtractOversizedRecord(t *testing.T) {
+	withFingerprintKey(t, "test-secret")
+	huge := strings.Repeat("x", 2*fingerprintMaxRecordBytes)
+	cases := map[string]string{
+		FORMAT_JSONL: "{\"text\": \"" + huge + "\"}\n" + extractSeeds[FORMAT_JSONL][0],
+		FORMAT_CSV:   "id,text\n0,\"" + huge + "\n" + strings.TrimPrefix(extractSeeds[FORMAT_CSV][0], "id,text\n"),
+	}
+	for format, src := range cases {
+		fp, err := GetFingerprinter(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 超长记录之前的内容不带指纹，只在其后的记录中写入
+		head, tail, _ := strings.Cut(src, huge)
+		data := append([]byte(head+huge), embedFingerprint(t, FORMAT_TEXT, []byte(tail))...)
+		res, err := fp.Extract(bytes.NewReader(data), nil)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if res == nil || res.PurchaseID != testPurchaseID {
+			t.Errorf("%s: result = %+v, want purchase %d", format, res, testPurchaseID)
+		}
+	}
+}
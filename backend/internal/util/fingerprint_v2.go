@@ -0,0 +1,235 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// v2 指纹格式
+//
+// 载荷为 16 字节：6 字节购买 ID（交易记录 ID，大端）+ 8 字节 HMAC-SHA256(服务端密钥, ID) 截断 + 2 字节 CRC-16
+// HMAC 使他人无法凭顺序的交易记录 ID 伪造嫁祸其他买家的指纹
+// 载荷按 2 字节拆成 8 个符号，第 i 行写入第 i%8 个符号，全文件循环重复
+// 每个符号自带版本号、序号和校验位，行被打乱、删除或抽样后仍可按序号还原：
+//
+//	标记(2) + 版本(1) + 序号(1) + 数据(6) + 校验(2)，共 12 个零宽字符，数字均为八进制
+//
+// 提取时丢弃校验失败的符号，同一序号按多数投票，最后用 CRC-16 校验整个载荷并验证 HMAC
+const (
+	FINGERPRINT_VERSION_V2 = 2
+	FINGERPRINT_V2_PREFIX  = "v2:"
+	fingerprintV2Chunks    = 8
+	fingerprintV2ChunkSize = 2
+	fingerprintV2IDBytes   = 6
+	fingerprintV2MACBytes  = 8
+	fingerprintV2MaxID     = 1<<48 - 1
+	fingerprintV2Marker    = "\u206C\u206D"
+	// 单个符号的零宽数字个数和字节数：标记 + 10 个三字节零宽字符
+	fingerprintV2SymbolDigits = 10
+	fingerprintV2SymbolBytes  = len(fingerprintV2Marker) + fingerprintV2SymbolDigits*3
+)
+
+var ErrInvalidFingerprint = errors.New("无效的指纹")
+
+// 指纹 HMAC 密钥（从配置文件读取）
+var fingerprintKey []byte
+
+// 设置指纹 HMAC 密钥，启动时调用；更换密钥后旧文件中的指纹无法再验证
+func SetFingerprintKey(key string) {
+	fingerprintKey = []byte(key)
+}
+
+// 八进制数字对应的零宽字符，与 v1 的起始标记不重叠
+var fingerprintV2Digits = []rune{'\u200B', '\u200C', '\u200D', '\u2060', '\u2061', '\u2062', '\u2063', '\u2064'}
+
+var fingerprintV2DigitValue = func() map[rune]int {
+	m := make(map[rune]int, len(fingerprintV2Digits))
+	for i, r := range fingerprintV2Digits {
+		m[r] = i
+	}
+	return m
+}()
+
+// 生成 v2 指纹（写入下载任务的 fingerprint 字段）
+func NewFingerprintV2(purchaseID uint64) string {
+	return FINGERPRINT_V2_PREFIX + strconv.FormatUint(purchaseID, 10)
+}
+
+// 将 v2 指纹编码为逐行写入的零宽字符串（第 i 行写入第 i%8 个），v1 指纹返回 nil，由 InsertFingerprint 处理
+func EncodeFingerprint(fingerprint string) ([]string, error) {
+	if !strings.HasPrefix(fingerprint, FINGERPRINT_V2_PREFIX) {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(fingerprint, FINGERPRINT_V2_PREFIX), 10, 64)
+	if err != nil || id > fingerprintV2MaxID {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFingerprint, fingerprint)
+	}
+
+	payload := fingerprintV2Payload(id)
+	symbols := make([]string, fingerprintV2Chunks)
+	for i := range symbols {
+		symbols[i] = encodeFingerprintV2Symbol(byte(i), payload[i*fingerprintV2ChunkSize:(i+1)*fingerprintV2ChunkSize])
+	}
+	return symbols, nil
+}
+
+// 组装 16 字节载荷：6 字节 ID + 8 字节 HMAC + CRC-16
+func fingerprintV2Payload(id uint64) []byte {
+	payload := make([]byte, fingerprintV2Chunks*fingerprintV2ChunkSize)
+	for i := 0; i < fingerprintV2IDBytes; i++ {
+		payload[i] = byte(id >> (8 * (fingerprintV2IDBytes - 1 - i)))
+	}
+	copy(payload[fingerprintV2IDBytes:], fingerprintV2MAC(payload[:fingerprintV2IDBytes]))
+	n := len(payload) - 2
+	crc := crc16(payload[:n])
+	payload[n] = byte(crc >> 8)
+	payload[n+1] = byte(crc)
+	return payload
+}
+
+// 截断的 HMAC-SHA256(密钥, ID)
+func fingerprintV2MAC(id []byte) []byte {
+	mac := hmac.New(sha256.New, fingerprintKey)
+	mac.Write(id)
+	return mac.Sum(nil)[:fingerprintV2MACBytes]
+}
+
+// 编码单个符号，data 为载荷中的 2 个字节
+func encodeFingerprintV2Symbol(index byte, data []byte) string {
+	var b strings.Builder
+	b.WriteString(fingerprintV2Marker)
+	b.WriteRune(fingerprintV2Digits[FINGERPRINT_VERSION_V2])
+	b.WriteRune(fingerprintV2Digits[index])
+	for _, d := range data {
+		b.WriteRune(fingerprintV2Digits[d>>6])
+		b.WriteRune(fingerprintV2Digits[(d>>3)&7])
+		b.WriteRune(fingerprintV2Digits[d&7])
+	}
+	check := fingerprintV2SymbolCheck(index, data)
+	b.WriteRune(fingerprintV2Digits[check>>3])
+	b.WriteRune(fingerprintV2Digits[check&7])
+	return b.String()
+}
+
+// 符号校验位（6 bit）
+func fingerprintV2SymbolCheck(index byte, data []byte) byte {
+	return byte(crc16(append([]byte{FINGERPRINT_VERSION_V2, index}, data...))) & 0x3F
+}
+
+// 解码所有校验通过的符号，只处理标记起点在 limit 之前的符号
+func decodeFingerprintV2Symbols(line string, limit int, fn func(index byte, data uint16)) {
+	offset := 0
+	for {
+		idx := strings.Index(line, fingerprintV2Marker)
+		if idx == -1 || offset+idx >= limit {
+			return
+		}
+		offset += idx + len(fingerprintV2Marker)
+		line = line[idx+len(fingerprintV2Marker):]
+
+		var digits [fingerprintV2SymbolDigits]int
+		rest := line
+		ok := true
+		for i := range digits {
+			if rest == "" {
+				ok = false
+				break
+			}
+			r, size := utf8.DecodeRuneInString(rest)
+			v, found := fingerprintV2DigitValue[r]
+			if !found {
+				ok = false
+				break
+			}
+			digits[i] = v
+			rest = rest[size:]
+		}
+		if !ok || digits[0] != FINGERPRINT_VERSION_V2 {
+			continue
+		}
+		index := byte(digits[1])
+		hi := digits[2]<<6 | digits[3]<<3 | digits[4]
+		lo := digits[5]<<6 | digits[6]<<3 | digits[7]
+		if hi > 0xFF || lo > 0xFF {
+			continue
+		}
+		check := byte(digits[8]<<3 | digits[9])
+		if check != fingerprintV2SymbolCheck(index, []byte{byte(hi), byte(lo)}) {
+			continue
+		}
+		fn(index, uint16(hi<<8|lo))
+		offset += len(line) - len(rest)
+		line = rest
+	}
+}
+
+// v2 提取：按序号累计每个符号值的票数
+type fingerprintV2Votes [fingerprintV2Chunks]map[uint16]int
+
+func (v *fingerprintV2Votes) add(index byte, data uint16) {
+	if v[index] == nil {
+		v[index] = make(map[uint16]int)
+	}
+	v[index][data]++
+}
+
+// 按多数投票还原载荷，CRC 校验和 HMAC 验证都通过时返回购买 ID、最少得票数和置信度（各序号得票占比的最小值）
+// HMAC 不一致说明指纹由他人伪造或密钥已更换，视为未检测到
+func (v *fingerprintV2Votes) resolve() (id uint64, votes int, confidence float64, ok bool) {
+	payload := make([]byte, fingerprintV2Chunks*fingerprintV2ChunkSize)
+	votes = -1
+	confidence = 1
+	for i, counts := range v {
+		if len(counts) == 0 {
+			return 0, 0, 0, false
+		}
+		best, bestCount, total := uint16(0), 0, 0
+		for data, count := range counts {
+			total += count
+			if count > bestCount || (count == bestCount && data < best) {
+				best, bestCount = data, count
+			}
+		}
+		payload[2*i] = byte(best >> 8)
+		payload[2*i+1] = byte(best)
+		if votes == -1 || bestCount < votes {
+			votes = bestCount
+		}
+		if c := float64(bestCount) / float64(total); c < confidence {
+			confidence = c
+		}
+	}
+	n := len(payload) - 2
+	crc := crc16(payload[:n])
+	if payload[n] != byte(crc>>8) || payload[n+1] != byte(crc) {
+		return 0, 0, 0, false
+	}
+	if !hmac.Equal(payload[fingerprintV2IDBytes:n], fingerprintV2MAC(payload[:fingerprintV2IDBytes])) {
+		return 0, 0, 0, false
+	}
+	for i := 0; i < fingerprintV2IDBytes; i++ {
+		id = id<<8 | uint64(payload[i])
+	}
+	return id, votes, confidence, true
+}
+
+// CRC-16/CCITT-FALSE
+func crc16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,71 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func embedJSONL(t *testing.T, fingerprint string, records int) string {
+	t.Helper()
+	var src strings.Builder
+	for i := 0; i < records; i++ {
+		fmt.Fprintf(&src, "{\"id\": %d, \"text\": \"record %d\"}\n", i, i)
+	}
+	var out bytes.Buffer
+	if _, err := WriteWithFingerprint(&out, strings.NewReader(src.String()), "jsonl", fingerprint, nil); err != nil {
+		t.Fatalf("WriteWithFingerprint: %v", err)
+	}
+	return out.String()
+}
+
+func withFingerprintKey(t testing.TB, key string) {
+	t.Helper()
+	old := fingerprintKey
+	SetFingerprintKey(key)
+	t.Cleanup(func() { fingerprintKey = old })
+}
+
+func TestFingerprintV2RoundTrip(t *testing.T) {
+	withFingerprintKey(t, "test-secret")
+	data := embedJSONL(t, NewFingerprintV2(123456), 32)
+
+	res, err := ExtractFingerprint(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ExtractFingerprint: %v", err)
+	}
+	if res == nil || res.Version != FINGERPRINT_VERSION_V2 || res.PurchaseID != 123456 {
+		t.Fatalf("result = %+v, want v2 purchase 123456", res)
+	}
+}
+
+func TestFingerprintV2RejectsForgery(t *testing.T) {
+	// 不知道密钥时生成的指纹 CRC 正确但 HMAC 不一致
+	withFingerprintKey(t, "attacker-guess")
+	forged := embedJSONL(t, NewFingerprintV2(42), 32)
+
+	SetFingerprintKey("server-secret")
+	res, err := ExtractFingerprint(strings.NewReader(forged))
+	if err != nil {
+		t.Fatalf("ExtractFingerprint: %v", err)
+	}
+	if res != nil && res.Version == FINGERPRINT_VERSION_V2 {
+		t.Fatalf("forged fingerprint accepted: %+v", res)
+	}
+}
+
+func TestFingerprintV2SymbolBytes(t *testing.T) {
+	symbols, err := EncodeFingerprint(NewFingerprintV2(fingerprintV2MaxID))
+	if err != nil {
+		t.Fatalf("EncodeFingerprint: %v", err)
+	}
+	for i, s := range symbols {
+		if len(s) != fingerprintV2SymbolBytes {
+			t.Fatalf("symbol %d has %d bytes, want %d", i, len(s), fingerprintV2SymbolBytes)
+		}
+	}
+	if _, err := EncodeFingerprint(NewFingerprintV2(fingerprintV2MaxID + 1)); err == nil {
+		t.Fatal("ID above 48 bits accepted")
+	}
+}
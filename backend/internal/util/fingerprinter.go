@@ -0,0 +1,335 @@
+package util
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 数据集格式
+const (
+	FORMAT_JSONL = "jsonl" // 每行一个 JSON 对象
+	FORMAT_CSV   = "csv"   // 首行为表头
+	FORMAT_TSV   = "tsv"   // 首行为表头
+	FORMAT_TEXT  = "text"  // 纯文本/逐行语料
+	FORMAT_JSON  = "json"  // JSON 数组
+)
+
+var ErrUnsupportedFormat = errors.New("不支持的数据集格式")
+
+// 指纹写入器：按数据集格式把指纹载荷嵌入每条记录，并从带指纹的内容中提取指纹
+type Fingerprinter interface {
+	Format() string
+	// 将 src 的第 i 条记录嵌入 payload(i) 后写入 w，返回写入的字节数
+	// afterRecord 在每条记录写入后以已读取的源字节数回调，返回错误时终止
+	Embed(w io.Writer, src io.Reader, payload func(i int) string, afterRecord func(read int64) error) (int64, error)
+	// 流式提取指纹，未检测到时返回 nil；progress 以已读取的字节数回调，可为 nil
+	Extract(r io.Reader, progress func(read int64) error) (*FingerprintResult, error)
+}
+
+var fingerprinters = make(map[string]Fingerprinter)
+
+// 注册指纹写入器
+func RegisterFingerprinter(f Fingerprinter) {
+	if _, ok := fingerprinters[f.Format()]; ok {
+		panic("重复注册的数据集格式: " + f.Format())
+	}
+	fingerprinters[f.Format()] = f
+}
+
+// 按数据集格式获取指纹写入器，空格式按 jsonl 处理（兼容旧数据）
+func GetFingerprinter(format string) (Fingerprinter, error) {
+	if format == "" {
+		format = FORMAT_JSONL
+	}
+	f, ok := fingerprinters[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	return f, nil
+}
+
+// 数据集格式对应的文件扩展名
+var datasetFormatExtensions = map[string]string{
+	FORMAT_JSONL: ".jsonl",
+	FORMAT_CSV:   ".csv",
+	FORMAT_TSV:   ".tsv",
+	FORMAT_TEXT:  ".txt",
+	FORMAT_JSON:  ".json",
+}
+
+// 获取数据集格式对应的文件扩展名，空格式按 jsonl 处理
+func DatasetFormatExtension(format string) string {
+	if format == "" {
+		format = FORMAT_JSONL
+	}
+	return datasetFormatExtensions[format]
+}
+
+// 确定数据集格式：优先使用显式指定的格式，否则按文件扩展名推断
+func ResolveDatasetFormat(format, filename string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".jsonl", ".ndjson", "":
+			format = FORMAT_JSONL
+		case ".csv":
+			format = FORMAT_CSV
+		case ".tsv":
+			format = FORMAT_TSV
+		case ".txt":
+			format = FORMAT_TEXT
+		case ".json":
+			format = FORMAT_JSON
+		default:
+			return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, filepath.Ext(filename))
+		}
+	}
+	if _, err := GetFingerprinter(format); err != nil {
+		return "", err
+	}
+	return format, nil
+}
+
+func init() {
+	RegisterFingerprinter(lineFingerprinter{format: FORMAT_JSONL, newEmbedder: newJSONLEmbedder, extract: extractJSONRecords})
+	RegisterFingerprinter(lineFingerprinter{format: FORMAT_CSV, newEmbedder: newDelimitedEmbedder(','), extract: extractDelimitedRecords(',')})
+	RegisterFingerprinter(lineFingerprinter{format: FORMAT_TSV, newEmbedder: newDelimitedEmbedder('\t'), extract: extractDelimitedRecords('\t')})
+	RegisterFingerprinter(lineFingerprinter{format: FORMAT_TEXT, newEmbedder: newTextEmbedder, extract: ExtractFingerprintWithProgress})
+	RegisterFingerprinter(jsonArrayFingerprinter{})
+}
+
+// 按行处理的格式：embedder 返回处理后的行，以及该行是否算作一条记录（表头、续行不计）
+// 提取时按格式解析记录，不依赖行结构
+type lineFingerprinter struct {
+	format      string
+	newEmbedder func() func(line, payload string) (string, bool)
+	extract     func(r io.Reader, progress func(read int64) error) (*FingerprintResult, error)
+}
+
+func (f lineFingerprinter) Format() string {
+	return f.format
+}
+
+func (f lineFingerprinter) Embed(w io.Writer, src io.Reader, payload func(i int) string, afterRecord func(read int64) error) (int64, error) {
+	embed := f.newEmbedder()
+	cr := &CountingReader{R: src}
+	br := bufio.NewReader(cr)
+	var written int64
+	index := 0
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			processedLine, isRecord := embed(line, payload(index))
+			n, werr := io.WriteString(w, processedLine)
+			written += int64(n)
+			if werr != nil {
+				return written, werr
+			}
+			if isRecord {
+				index++
+				if afterRecord != nil {
+					if werr = afterRecord(cr.Count); werr != nil {
+						return written, werr
+					}
+				}
+			}
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+func (f lineFingerprinter) Extract(r io.Reader, progress func(read int64) error) (*FingerprintResult, error) {
+	return f.extract(r, progress)
+}
+
+// jsonl：写入 JSON 字符串值
+func newJSONLEmbedder() func(line, payload string) (string, bool) {
+	return func(line, payload string) (string, bool) {
+		if payload == "" {
+			return line, true
+		}
+		return insertIntoJSONStringValue(line, payload), true
+	}
+}
+
+// 纯文本：写在行尾（换行符之前），空行不计
+func newTextEmbedder() func(line, payload string) (string, bool) {
+	return func(line, payload string) (string, bool) {
+		content := strings.TrimRight(line, "\r\n")
+		if strings.TrimSpace(content) == "" {
+			return line, false
+		}
+		return content + payload + line[len(content):], true
+	}
+}
+
+// csv/tsv：跳过表头，优先写入第一个带引号的单元格，否则写入第一个非数字单元格末尾
+// 引号内换行的单元格跨多行，续行不计为新记录
+func newDelimitedEmbedder(delim byte) func() func(line, payload string) (string, bool) {
+	return func() func(line, payload string) (string, bool) {
+		headerSeen := false
+		inQuote := false
+		return func(line, payload string) (string, bool) {
+			continuation := inQuote
+			if strings.Count(line, `"`)%2 == 1 {
+				inQuote = !inQuote
+			}
+			if continuation {
+				return line, false
+			}
+			if !headerSeen {
+				headerSeen = true
+				return line, false
+			}
+			if payload == "" {
+				return line, true
+			}
+			return insertIntoDelimitedLine(line, payload, delim), true
+		}
+	}
+}
+
+func insertIntoDelimitedLine(line, payload string, delim byte) string {
+	content := strings.TrimRight(line, "\r\n")
+	textEnd := -1
+	start := 0
+	for start <= len(content) {
+		if start < len(content) && content[start] == '"' {
+			return line[:start+1] + payload + line[start+1:]
+		}
+		end := strings.IndexByte(content[start:], delim)
+		if end == -1 {
+			end = len(content)
+		} else {
+			end += start
+		}
+		if textEnd == -1 {
+			cell := strings.TrimSpace(content[start:end])
+			if _, err := strconv.ParseFloat(cell, 64); cell != "" && err != nil {
+				textEnd = end
+			}
+		}
+		start = end + 1
+	}
+	if textEnd == -1 {
+		return line
+	}
+	return line[:textEnd] + payload + line[textEnd:]
+}
+
+// JSON 数组：逐字节扫描，不重新序列化，保留原始排版
+// 每个对象元素写入第一个字符串值，字符串元素写入字符串本身
+type jsonArrayFingerprinter struct{}
+
+func (jsonArrayFingerprinter) Format() string {
+	return FORMAT_JSON
+}
+
+func (jsonArrayFingerprinter) Embed(w io.Writer, src io.Reader, payload func(i int) string, afterRecord func(read int64) error) (int64, error) {
+	cr := &CountingReader{R: src}
+	br := bufio.NewReader(cr)
+	cw := &countingWriter{W: w}
+	bw := bufio.NewWriter(cw)
+
+	depth, index := 0, 0
+	inString, escape, embedded := false, false, false
+	var lastSig byte
+	endRecord := func() error {
+		index++
+		embedded = false
+		if afterRecord == nil {
+			return nil
+		}
+		return afterRecord(cr.Count)
+	}
+
+	embed := func() error {
+		for {
+			b, err := br.ReadByte()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := bw.WriteByte(b); err != nil {
+				return err
+			}
+
+			if inString {
+				switch {
+				case escape:
+					escape = false
+				case b == '\\':
+					escape = true
+				case b == '"':
+					inString = false
+					if depth == 1 {
+						if err := endRecord(); err != nil {
+							return err
+						}
+					}
+				}
+				lastSig = b
+				continue
+			}
+
+			switch b {
+			case '"':
+				inString = true
+				// 对象元素中的字符串值（冒号之后），或数组顶层的字符串元素
+				if !embedded && ((depth >= 2 && lastSig == ':') || depth == 1) {
+					if _, err := bw.WriteString(payload(index)); err != nil {
+						return err
+					}
+					embedded = true
+				}
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 1 {
+					if err := endRecord(); err != nil {
+						return err
+					}
+				}
+			}
+			if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+				lastSig = b
+			}
+		}
+	}
+
+	// 出错时同样刷出已缓冲的内容，返回的字节数与实际写入 w 的一致
+	err := embed()
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return cw.N, err
+}
+
+func (jsonArrayFingerprinter) Extract(r io.Reader, progress func(read int64) error) (*FingerprintResult, error) {
+	return extractJSONRecords(r, progress)
+}
+
+// 计数字节的 writer
+type countingWriter struct {
+	W io.Writer
+	N int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.W.Write(p)
+	c.N += int64(n)
+	return n, err
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJSONArrayEmbedCountsBytesOnError(t *testing.T) {
+	stop := errors.New("stop")
+	src := `[{"a": "x"}, {"a": "y"}, {"a": "z"}]`
+	var out bytes.Buffer
+	records := 0
+	n, err := jsonArrayFingerprinter{}.Embed(&out, strings.NewReader(src), func(int) string { return "\u200b" }, func(int64) error {
+		if records++; records == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("err = %v, want stop", err)
+	}
+	if n != int64(out.Len()) || n == 0 {
+		t.Fatalf("n = %d, written = %d", n, out.Len())
+	}
+}
+
+func TestDatasetFormatExtension(t *testing.T) {
+	for _, ext := range []string{".jsonl", ".csv", ".tsv", ".txt", ".json"} {
+		format, err := ResolveDatasetFormat("", "data"+ext)
+		if err != nil {
+			t.Fatalf("%s: %v", ext, err)
+		}
+		if got := DatasetFormatExtension(format); got != ext {
+			t.Errorf("DatasetFormatExtension(%q) = %q, want %q", format, got, ext)
+		}
+	}
+	if got := DatasetFormatExtension(""); got != ".jsonl" {
+		t.Errorf("empty format extension = %q", got)
+	}
+}
@@ -0,0 +1,784 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 字段类型
+const (
+	FIELD_TYPE_NULL    = "null"
+	FIELD_TYPE_STRING  = "string"
+	FIELD_TYPE_INTEGER = "integer"
+	FIELD_TYPE_NUMBER  = "number"
+	FIELD_TYPE_BOOLEAN = "boolean"
+	FIELD_TYPE_ARRAY   = "array"
+	FIELD_TYPE_OBJECT  = "object"
+)
+
+const (
+	profileMaxIssues = 20      // 报告中保留的问题条数
+	profileMaxFields = 200     // 统计的字段数上限，超出的字段忽略
+	profileHeadLines = 20      // 保留的文件开头行数（用于核对预览数据）
+	profileHeadBytes = 1 << 20 // 文件开头最多保留的字节数
+
+	profileSampleHead   = 20   // 预览抽样：文件开头的记录数
+	profileSampleRandom = 20   // 预览抽样：其余记录中随机抽取的记录数
+	profileMaxDistinct  = 1000 // 字段不同值计数上限，超出后只给出下限
+	profileMaxExamples  = 5    // 字段示例值个数
+	profileExampleLen   = 100  // 示例值最大长度（字符）
+
+	// 抽样记录保存在预览文档中，需保证文档远小于 MongoDB 16MB 的限制
+	profileSampleRecordBytes = 8 << 10   // 单条抽样记录的最大字节数，超出时截断
+	profileSampleValueBytes  = 1 << 10   // 截断记录时每个字段值保留的最大字节数
+	profileSampleTotalBytes  = 256 << 10 // 全部抽样记录的总字节数上限
+	profileTruncatedKey      = "_truncated"
+
+	// 单条记录（JSONL/文本的一行、CSV/TSV 的一条记录、JSON 数组的一个元素）的字节上限
+	// 解析时整条记录保存在内存中，文件由卖家提供，超过上限的记录不再读入，计为格式错误，文件不通过校验
+	profileMaxRecordBytes = 8 << 20
+)
+
+// 数据集文件概况：记录数、格式错误、编码问题、字段结构和 SHA-256/MD5
+type DatasetProfile struct {
+	Bytes            int64          `json:"bytes"`
+	SHA256           string         `json:"sha256"`
+	MD5              string         `json:"md5"`
+	Records          int64          `json:"records"`          // 记录数（含格式错误的记录，不含 CSV/TSV 表头和空行）
+	MalformedRecords int64          `json:"malformedRecords"` // 无法按格式解析的记录
+	EncodingErrors   int64          `json:"encodingErrors"`   // 含非法 UTF-8 的行（JSON 数组为非法字节序列数）
+	OversizedRecords int64          `json:"oversizedRecords"` // 超过长度上限的记录（同时计入格式错误）
+	Fields           []FieldProfile `json:"fields"`
+	FieldsTruncated  bool           `json:"fieldsTruncated"` // 字段数超过上限，部分字段未统计
+	Issues           []ProfileIssue `json:"issues"`          // 前若干条问题
+	Head             []string       `json:"-"`               // 文件开头若干行（去掉行尾换行）
+	Sample           []string       `json:"-"`               // 预览抽样记录（JSON 对象），前 SampleHead 条为文件开头的记录，其余为随机抽样
+	SampleHead       int            `json:"-"`
+}
+
+// 字段概况，空值率 = (null + 空值 + 缺失) / 有效记录数
+// 数值统计只针对 integer/number，长度统计只针对 string，不同值和示例值只统计标量（不含数组和对象）
+type FieldProfile struct {
+	Name              string           `json:"name"`
+	Types             map[string]int64 `json:"types"` // 类型 → 出现次数（不含 null）
+	Nulls             int64            `json:"nulls"`
+	NullRate          float64          `json:"nullRate"`
+	Min               *float64         `json:"min,omitempty"`
+	Max               *float64         `json:"max,omitempty"`
+	Mean              *float64         `json:"mean,omitempty"`
+	MinLength         *int             `json:"minLength,omitempty"`
+	MaxLength         *int             `json:"maxLength,omitempty"`
+	Distinct          int64            `json:"distinct"`
+	DistinctTruncated bool             `json:"distinctTruncated,omitempty"` // 不同值超过计数上限，distinct 为下限
+	Examples          []string         `json:"examples"`
+}
+
+// 校验问题，line 从 1 开始，JSON 数组格式为 0
+type ProfileIssue struct {
+	Line    int64  `json:"line"`
+	Message string `json:"message"`
+}
+
+// 流式读取数据集文件并生成概况；progress 以已读取的字节数回调，返回错误时终止，可为 nil
+// 格式错误和编码问题计入概况，只有读取失败或 progress 返回错误时才返回 error
+func ProfileDataset(r io.Reader, format string, progress func(read int64) error) (*DatasetProfile, error) {
+	if format == "" {
+		format = FORMAT_JSONL
+	}
+	hash := sha256.New()
+	md5Hash := md5.New()
+	head := &headWriter{}
+	cr := &CountingReader{R: io.TeeReader(r, io.MultiWriter(hash, md5Hash, head))}
+	p := &profiler{profile: &DatasetProfile{Issues: []ProfileIssue{}}, fields: make(map[string]*fieldStats), present: make(map[string]int64)}
+	if progress == nil {
+		progress = func(int64) error { return nil }
+	}
+
+	var err error
+	switch format {
+	case FORMAT_JSONL:
+		err = p.eachLine(cr, progress, p.jsonLine)
+	case FORMAT_TEXT:
+		err = p.eachLine(cr, progress, func(_ int64, text string) {
+			p.profile.Records++
+			p.sample(func() string { return textRecord(text) })
+		})
+	case FORMAT_CSV:
+		err = p.delimited(cr, ',', progress)
+	case FORMAT_TSV:
+		err = p.delimited(cr, '\t', progress)
+	case FORMAT_JSON:
+		err = p.jsonArray(cr, progress)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// 解析提前结束（如 JSON 结构错误）时读完剩余内容，保证大小和哈希覆盖整个文件
+	if _, err := io.Copy(io.Discard, cr); err != nil {
+		return nil, err
+	}
+
+	p.profile.Bytes = cr.Count
+	p.profile.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	p.profile.MD5 = hex.EncodeToString(md5Hash.Sum(nil))
+	p.profile.Head = head.lines()
+	p.finishFields()
+	p.finishSample()
+	return p.profile, nil
+}
+
+type profiler struct {
+	profile    *DatasetProfile
+	fields     map[string]*fieldStats
+	order      []string
+	valid      int64 // 参与字段统计的记录数
+	present    map[string]int64
+	lineNumber int64
+	sampled    int64 // 参与抽样的记录数
+	head       []string
+	reservoir  []sampledRecord
+}
+
+// 字段统计的中间状态
+type fieldStats struct {
+	FieldProfile
+	numbers  int64
+	sum      float64
+	distinct map[uint64]struct{}
+}
+
+type sampledRecord struct {
+	index int64
+	data  string
+}
+
+// 抽样：前 profileSampleHead 条全部保留，之后的记录用蓄水池抽样保留 profileSampleRandom 条
+// data 仅在记录被选中时调用，过长的记录截断后保存
+func (p *profiler) sample(data func() string) {
+	p.sampled++
+	if len(p.head) < profileSampleHead {
+		p.head = append(p.head, truncateSampleRecord(data()))
+		return
+	}
+	n := p.sampled - int64(len(p.head))
+	if len(p.reservoir) < profileSampleRandom {
+		p.reservoir = append(p.reservoir, sampledRecord{index: p.sampled, data: truncateSampleRecord(data())})
+	} else if j := rand.Int64N(n); j < profileSampleRandom {
+		p.reservoir[j] = sampledRecord{index: p.sampled, data: truncateSampleRecord(data())}
+	}
+}
+
+// 随机抽样部分按文件中的顺序排列；总字节数超过上限时丢弃之后的记录
+func (p *profiler) finishSample() {
+	slices.SortFunc(p.reservoir, func(a, b sampledRecord) int { return int(a.index - b.index) })
+	p.profile.Sample = []string{}
+	total := 0
+	keep := func(data string) bool {
+		if total+len(data) > profileSampleTotalBytes {
+			return false
+		}
+		total += len(data)
+		p.profile.Sample = append(p.profile.Sample, data)
+		return true
+	}
+	for _, data := range p.head {
+		if !keep(data) {
+			break
+		}
+	}
+	p.profile.SampleHead = len(p.profile.Sample)
+	if p.profile.SampleHead < len(p.head) {
+		return
+	}
+	for _, r := range p.reservoir {
+		if !keep(r.data) {
+			break
+		}
+	}
+}
+
+// 截断过长的抽样记录（JSON 对象），保持为合法的 JSON 对象以便预览按字段展示：
+// 按原顺序输出字段，过长的字段值截断为 profileSampleValueBytes 字节的字符串并以 "…" 结尾，
+// 超出 profileSampleRecordBytes 后不再输出之后的字段；截断的记录带有 "_truncated": true
+func truncateSampleRecord(data string) string {
+	if len(data) <= profileSampleRecordBytes {
+		return data
+	}
+	dec := json.NewDecoder(strings.NewReader(data))
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	if tok, err := dec.Token(); err == nil && tok == json.Delim('{') {
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				break
+			}
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				break
+			}
+			k, _ := json.Marshal(truncateBytes(key.(string), profileSampleValueBytes))
+			v := []byte(value)
+			if len(v) > profileSampleValueBytes {
+				text := string(v)
+				_ = json.Unmarshal(v, &text) // 字符串取原文，数组和对象取 JSON 文本
+				v, _ = json.Marshal(truncateBytes(text, profileSampleValueBytes) + "…")
+			}
+			// 预留截断标记的空间
+			if buf.Len()+len(k)+len(v)+len(profileTruncatedKey)+16 > profileSampleRecordBytes {
+				break
+			}
+			if buf.Len() > 1 {
+				buf.WriteByte(',')
+			}
+			buf.Write(k)
+			buf.WriteByte(':')
+			buf.Write(v)
+		}
+	}
+	if buf.Len() > 1 {
+		buf.WriteByte(',')
+	}
+	buf.WriteString(`"` + profileTruncatedKey + `":true}`)
+	return buf.String()
+}
+
+// 按字节截断字符串，不截断多字节字符
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func (p *profiler) issue(line int64, format string, args ...interface{}) {
+	if len(p.profile.Issues) < profileMaxIssues {
+		p.profile.Issues = append(p.profile.Issues, ProfileIssue{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// 记录超过长度上限：计为格式错误
+func (p *profiler) oversized(line int64) {
+	p.profile.Records++
+	p.profile.MalformedRecords++
+	p.profile.OversizedRecords++
+	p.issue(line, "记录超过 %d 字节的长度上限", profileMaxRecordBytes)
+}
+
+// 读取一行（含换行符），超过 profileMaxRecordBytes 时不再保存，跳过该行剩余内容并返回 tooLong
+func readLimitedLine(br *bufio.Reader) (line string, tooLong bool, err error) {
+	var buf []byte
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if !tooLong {
+			if len(buf)+len(chunk) > profileMaxRecordBytes {
+				tooLong, buf = true, nil
+			} else {
+				buf = append(buf, chunk...)
+			}
+		}
+		if err != bufio.ErrBufferFull {
+			return string(buf), tooLong, err
+		}
+	}
+}
+
+// 跳过当前行的剩余内容（含换行符）
+func skipLine(br *bufio.Reader) error {
+	for {
+		_, err := br.ReadSlice('\n')
+		if err != bufio.ErrBufferFull {
+			return err
+		}
+	}
+}
+
+// 按行处理，跳过空行；含非法 UTF-8 的行计为编码问题，超过长度上限的行计为格式错误
+func (p *profiler) eachLine(cr *CountingReader, progress func(read int64) error, handle func(line int64, text string)) error {
+	br := bufio.NewReader(cr)
+	for {
+		line, tooLong, err := readLimitedLine(br)
+		if tooLong || len(line) > 0 {
+			p.lineNumber++
+			text := strings.TrimRight(line, "\r\n")
+			if tooLong {
+				p.oversized(p.lineNumber)
+			} else if strings.TrimSpace(text) != "" {
+				if !utf8.ValidString(text) {
+					p.profile.EncodingErrors++
+					p.issue(p.lineNumber, "包含非法的 UTF-8 编码")
+				}
+				handle(p.lineNumber, text)
+			}
+			if perr := progress(cr.Count); perr != nil {
+				return perr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// JSONL：每行一个 JSON 对象
+func (p *profiler) jsonLine(line int64, text string) {
+	p.profile.Records++
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		p.profile.MalformedRecords++
+		p.issue(line, "JSON 解析失败: %v", err)
+		return
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		p.profile.MalformedRecords++
+		p.issue(line, "一行包含多个 JSON 值")
+		return
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		p.profile.MalformedRecords++
+		p.issue(line, "记录不是 JSON 对象")
+		return
+	}
+	p.observeObject(obj)
+	p.sample(func() string { return strings.TrimSpace(text) })
+}
+
+// CSV/TSV：首行为表头，之后每行字段数须与表头一致
+// 超过长度上限的记录（如未闭合的引号）跳到下一个换行符后重新开始解析
+func (p *profiler) delimited(cr *CountingReader, comma rune, progress func(read int64) error) error {
+	br := bufio.NewReader(cr)
+	lines := &lineCountingReader{R: br}
+	limit := &recordLimitReader{R: lines, Max: profileMaxRecordBytes}
+	newReader := func() *csv.Reader {
+		rd := csv.NewReader(limit)
+		rd.Comma = comma
+		rd.ReuseRecord = true
+		return rd
+	}
+	rd := newReader()
+	lineBase := int64(0) // 重新开始解析前已读取的行数，csv.Reader 的行号从 1 开始
+	var header []string
+	for {
+		record, err := rd.Read()
+		limit.reset()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.Is(err, errRecordTooLong) {
+			p.oversized(lines.lines + 1)
+			if err := skipLine(br); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			lines.lines++
+			lineBase = lines.lines
+			rd = newReader()
+		} else if errors.As(err, &parseErr) {
+			if header == nil {
+				p.issue(lineBase+int64(parseErr.Line), "表头解析失败: %v", parseErr.Err)
+				return nil
+			}
+			p.profile.Records++
+			p.profile.MalformedRecords++
+			p.issue(lineBase+int64(parseErr.Line), "%v", parseErr.Err)
+		} else if err != nil {
+			return err
+		} else {
+			line, _ := rd.FieldPos(0)
+			line += int(lineBase)
+			invalid := false
+			for _, cell := range record {
+				if !utf8.ValidString(cell) {
+					invalid = true
+					break
+				}
+			}
+			if invalid {
+				p.profile.EncodingErrors++
+				p.issue(int64(line), "包含非法的 UTF-8 编码")
+			}
+			if header == nil {
+				header = make([]string, len(record))
+				for i, name := range record {
+					if name = strings.TrimSpace(name); name == "" {
+						name = "column_" + strconv.Itoa(i+1)
+					}
+					header[i] = name
+				}
+			} else {
+				p.profile.Records++
+				if p.observeRow(header, record) {
+					p.sample(func() string { return rowRecord(header, record) })
+				}
+			}
+		}
+		if perr := progress(cr.Count); perr != nil {
+			return perr
+		}
+	}
+}
+
+// JSON 数组：元素为对象或字符串，结构错误或元素超过长度上限后无法继续定位记录，停止解析
+func (p *profiler) jsonArray(cr *CountingReader, progress func(read int64) error) error {
+	checker := &utf8Checker{}
+	limit := &recordLimitReader{R: io.TeeReader(cr, checker), Max: profileMaxRecordBytes}
+	dec := json.NewDecoder(limit)
+	dec.UseNumber()
+	defer func() { p.profile.EncodingErrors += checker.finish() }()
+
+	tok, err := dec.Token()
+	limit.reset()
+	if err != nil && !isJSONSyntaxError(err) && !errors.Is(err, errRecordTooLong) {
+		return err
+	}
+	if tok != json.Delim('[') {
+		// 整个文件计为一条格式错误的记录
+		p.profile.Records++
+		p.profile.MalformedRecords++
+		p.issue(0, "文件不是 JSON 数组")
+		return nil
+	}
+	for dec.More() {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		limit.reset()
+		if errors.Is(err, errRecordTooLong) {
+			p.oversized(0)
+			return nil
+		}
+		if err != nil {
+			if !isJSONSyntaxError(err) {
+				return err
+			}
+			p.profile.Records++
+			p.profile.MalformedRecords++
+			p.issue(0, "第 %d 个元素解析失败: %v", p.profile.Records, err)
+			return nil
+		}
+		p.profile.Records++
+		var v interface{}
+		rd := json.NewDecoder(bytes.NewReader(raw))
+		rd.UseNumber()
+		_ = rd.Decode(&v)
+		switch value := v.(type) {
+		case map[string]interface{}:
+			p.observeObject(value)
+			p.sample(func() string {
+				var buf bytes.Buffer
+				_ = json.Compact(&buf, raw)
+				return buf.String()
+			})
+		case string:
+			p.sample(func() string { return textRecord(value) })
+		default:
+			p.profile.MalformedRecords++
+			p.issue(0, "第 %d 个元素不是对象或字符串", p.profile.Records)
+		}
+		if err := progress(cr.Count); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		if !isJSONSyntaxError(err) {
+			return err
+		}
+		p.profile.MalformedRecords++
+		p.issue(0, "JSON 数组未正确结束: %v", err)
+	}
+	return nil
+}
+
+func (p *profiler) observeObject(obj map[string]interface{}) {
+	p.valid++
+	// 同一记录内按字段名排序，保证字段输出顺序稳定
+	for _, name := range slices.Sorted(maps.Keys(obj)) {
+		p.observe(name, jsonType(obj[name]), jsonScalar(obj[name]))
+	}
+}
+
+// 区分内容错误和读取错误（读取错误原样返回，不计入概况）
+func isJSONSyntaxError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	return errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// 字段数与表头不一致的行不参与统计，返回 false
+func (p *profiler) observeRow(header, record []string) bool {
+	if len(record) != len(header) {
+		return false
+	}
+	p.valid++
+	for i, cell := range record {
+		p.observe(header[i], cellType(cell), strings.TrimSpace(cell))
+	}
+	return true
+}
+
+// value 为标量值的文本形式，数组和对象为空
+func (p *profiler) observe(name, typ, value string) {
+	f, ok := p.fields[name]
+	if !ok {
+		if len(p.order) >= profileMaxFields {
+			p.profile.FieldsTruncated = true
+			return
+		}
+		// 字段名同样写入预览文档，过长时截断
+		f = &fieldStats{FieldProfile: FieldProfile{Name: truncateBytes(name, profileSampleValueBytes), Types: make(map[string]int64), Examples: []string{}}, distinct: make(map[uint64]struct{})}
+		p.fields[name] = f
+		p.order = append(p.order, name)
+	}
+	p.present[name]++
+	if typ == FIELD_TYPE_NULL {
+		f.Nulls++
+		return
+	}
+	f.Types[typ]++
+	if typ == FIELD_TYPE_ARRAY || typ == FIELD_TYPE_OBJECT {
+		return
+	}
+
+	switch typ {
+	case FIELD_TYPE_INTEGER, FIELD_TYPE_NUMBER:
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			f.numbers++
+			f.sum += n
+			if f.Min == nil || n < *f.Min {
+				f.Min = &n
+			}
+			if f.Max == nil || n > *f.Max {
+				f.Max = &n
+			}
+		}
+	case FIELD_TYPE_STRING:
+		l := utf8.RuneCountInString(value)
+		if f.MinLength == nil || l < *f.MinLength {
+			f.MinLength = &l
+		}
+		if f.MaxLength == nil || l > *f.MaxLength {
+			f.MaxLength = &l
+		}
+	}
+
+	if f.DistinctTruncated {
+		return
+	}
+	h := fnv.New64a()
+	h.Write([]byte(typ))
+	h.Write([]byte{0})
+	h.Write([]byte(value))
+	key := h.Sum64()
+	if _, seen := f.distinct[key]; seen {
+		return
+	}
+	if len(f.distinct) >= profileMaxDistinct {
+		f.DistinctTruncated = true
+		return
+	}
+	f.distinct[key] = struct{}{}
+	if len(f.Examples) < profileMaxExamples {
+		if r := []rune(value); len(r) > profileExampleLen {
+			value = string(r[:profileExampleLen]) + "…"
+		}
+		f.Examples = append(f.Examples, value)
+	}
+}
+
+// 缺失字段计为空值，按首次出现顺序输出
+func (p *profiler) finishFields() {
+	p.profile.Fields = make([]FieldProfile, 0, len(p.order))
+	for _, name := range p.order {
+		f := p.fields[name]
+		f.Nulls += p.valid - p.present[name]
+		if p.valid > 0 {
+			f.NullRate = float64(f.Nulls) / float64(p.valid)
+		}
+		if f.numbers > 0 {
+			mean := f.sum / float64(f.numbers)
+			f.Mean = &mean
+		}
+		f.Distinct = int64(len(f.distinct))
+		p.profile.Fields = append(p.profile.Fields, f.FieldProfile)
+	}
+}
+
+// 标量值的文本形式，数组和对象返回空
+func jsonScalar(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case bool:
+		return strconv.FormatBool(value)
+	case json.Number:
+		return value.String()
+	default:
+		return ""
+	}
+}
+
+// 纯文本记录转为预览记录 {"text": ...}
+func textRecord(text string) string {
+	b, _ := json.Marshal(map[string]string{"text": text})
+	return string(b)
+}
+
+// CSV/TSV 行转为预览记录，按表头顺序输出字段
+func rowRecord(header, record []string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range header {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(name)
+		v, _ := json.Marshal(record[i])
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+func jsonType(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return FIELD_TYPE_NULL
+	case string:
+		return FIELD_TYPE_STRING
+	case bool:
+		return FIELD_TYPE_BOOLEAN
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			return FIELD_TYPE_INTEGER
+		}
+		return FIELD_TYPE_NUMBER
+	case []interface{}:
+		return FIELD_TYPE_ARRAY
+	default:
+		return FIELD_TYPE_OBJECT
+	}
+}
+
+// CSV/TSV 单元格按内容推断类型，空单元格视为 null
+func cellType(cell string) string {
+	cell = strings.TrimSpace(cell)
+	switch {
+	case cell == "":
+		return FIELD_TYPE_NULL
+	case strings.EqualFold(cell, "true") || strings.EqualFold(cell, "false"):
+		return FIELD_TYPE_BOOLEAN
+	}
+	if _, err := strconv.ParseInt(cell, 10, 64); err == nil {
+		return FIELD_TYPE_INTEGER
+	}
+	if _, err := strconv.ParseFloat(cell, 64); err == nil {
+		return FIELD_TYPE_NUMBER
+	}
+	return FIELD_TYPE_STRING
+}
+
+// 保留文件开头的若干行
+type headWriter struct {
+	buf   []byte
+	count int
+}
+
+func (h *headWriter) Write(b []byte) (int, error) {
+	if h.count < profileHeadLines && len(h.buf) < profileHeadBytes {
+		n := min(len(b), profileHeadBytes-len(h.buf))
+		h.buf = append(h.buf, b[:n]...)
+		h.count = bytes.Count(h.buf, []byte{'\n'})
+	}
+	return len(b), nil
+}
+
+func (h *headWriter) lines() []string {
+	lines := strings.Split(string(h.buf), "\n")
+	if len(h.buf) >= profileHeadBytes || (len(lines) > 0 && lines[len(lines)-1] == "") {
+		// 最后一段不完整或为空
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > profileHeadLines {
+		lines = lines[:profileHeadLines]
+	}
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+	return lines
+}
+
+// 流式统计非法 UTF-8 字节序列，处理跨块截断的多字节字符
+// 统计已读取的换行符个数
+type lineCountingReader struct {
+	R     io.Reader
+	lines int64
+}
+
+func (l *lineCountingReader) Read(p []byte) (int, error) {
+	n, err := l.R.Read(p)
+	l.lines += int64(bytes.Count(p[:n], []byte{'\n'}))
+	return n, err
+}
+
+type utf8Checker struct {
+	pending []byte
+	invalid int64
+}
+
+func (c *utf8Checker) Write(b []byte) (int, error) {
+	data := append(c.pending, b...)
+	i := 0
+	for i < len(data) {
+		if data[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		if !utf8.FullRune(data[i:]) {
+			break
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			c.invalid++
+		}
+		i += size
+	}
+	c.pending = append(c.pending[:0:0], data[i:]...)
+	return len(b), nil
+}
+
+// 文件结束时仍未完整的字节计为一处非法序列
+func (c *utf8Checker) finish() int64 {
+	if len(c.pending) > 0 {
+		c.invalid++
+		c.pending = nil
+	}
+	return c.invalid
+}
@@ -0,0 +1,167 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestProfileDataset(t *testing.T) {
+	cases := []struct {
+		name      string
+		format    string
+		src       string
+		records   int64
+		malformed int64
+		encoding  int64
+		samples   int
+	}{
+		{"jsonl", FORMAT_JSONL, "{\"a\": 1, \"b\": \"x\"}\n{\"a\": 2, \"b\": null}\n\n{\"a\": 3.5}\n", 3, 0, 0, 3},
+		{"jsonl malformed", FORMAT_JSONL, "{\"a\": 1}\nnot json\n[1]\n{\"a\": 2} {\"a\": 3}\n", 4, 3, 0, 1},
+		{"jsonl invalid utf-8", FORMAT_JSONL, "{\"a\": \"\xff\"}\n{\"a\": \"ok\"}\n", 2, 0, 1, 2},
+		{"csv", FORMAT_CSV, "id,name\n1,alice\n2,\"bob, jr\"\n3,\"multi\nline\"\n", 3, 0, 0, 3},
+		{"csv field count", FORMAT_CSV, "id,name\n1,alice\n2\n", 2, 1, 0, 1},
+		{"tsv", FORMAT_TSV, "id\tname\r\n1\talice\r\n2\tbob\r\n", 2, 0, 0, 2},
+		{"text", FORMAT_TEXT, "hello\n\n  \nworld\r\n", 2, 0, 0, 2},
+		{"text invalid utf-8", FORMAT_TEXT, "hello\n\xfe\xff\n", 2, 0, 1, 2},
+		{"json", FORMAT_JSON, `[{"a": 1}, "text", 2]`, 3, 1, 0, 2},
+		{"json not array", FORMAT_JSON, `{"a": 1}`, 1, 1, 0, 0},
+		{"json truncated", FORMAT_JSON, `[{"a": 1}, {"a":`, 2, 1, 0, 1},
+		{"empty", FORMAT_JSONL, "", 0, 0, 0, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p, err := ProfileDataset(strings.NewReader(c.src), c.format, nil)
+			if err != nil {
+				t.Fatalf("ProfileDataset: %v", err)
+			}
+			if p.Records != c.records || p.MalformedRecords != c.malformed || p.EncodingErrors != c.encoding {
+				t.Errorf("records/malformed/encoding = %d/%d/%d, want %d/%d/%d",
+					p.Records, p.MalformedRecords, p.EncodingErrors, c.records, c.malformed, c.encoding)
+			}
+			if len(p.Sample) != c.samples {
+				t.Errorf("samples = %d, want %d", len(p.Sample), c.samples)
+			}
+			// 大小和哈希覆盖整个文件，包括解析提前结束后的剩余内容
+			sum := sha256.Sum256([]byte(c.src))
+			if p.Bytes != int64(len(c.src)) || p.SHA256 != hex.EncodeToString(sum[:]) {
+				t.Errorf("bytes/sha256 = %d/%s, want %d/%x", p.Bytes, p.SHA256, len(c.src), sum)
+			}
+		})
+	}
+}
+
+func TestProfileDatasetFields(t *testing.T) {
+	src := "{\"a\": 1, \"b\": \"x\"}\n{\"a\": 2, \"b\": null}\n{\"a\": 3.5}\n"
+	p, err := ProfileDataset(strings.NewReader(src), FORMAT_JSONL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Fields) != 2 {
+		t.Fatalf("fields = %+v", p.Fields)
+	}
+	a, b := p.Fields[0], p.Fields[1]
+	if a.Name != "a" || a.Types[FIELD_TYPE_INTEGER] != 2 || a.Types[FIELD_TYPE_NUMBER] != 1 || *a.Min != 1 || *a.Max != 3.5 {
+		t.Errorf("field a = %+v", a)
+	}
+	// null 与缺失都计为空值
+	if b.Name != "b" || b.Nulls != 2 || b.Types[FIELD_TYPE_STRING] != 1 {
+		t.Errorf("field b = %+v", b)
+	}
+}
+
+func TestProfileDatasetUnsupportedFormat(t *testing.T) {
+	if _, err := ProfileDataset(strings.NewReader("x"), "xml", nil); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
+
+// 过长的记录截断后仍为合法的 JSON 对象，并带有截断标记；抽样总字节数不超过上限
+func TestProfileDatasetTruncatesSample(t *testing.T) {
+	long := strings.Repeat("长", profileSampleValueBytes)
+	var src strings.Builder
+	for i := range profileSampleHead + profileSampleRandom + 10 {
+		fmt.Fprintf(&src, "{\"id\": %d", i)
+		for f := range 20 {
+			fmt.Fprintf(&src, ", \"f%02d\": %q", f, long)
+		}
+		src.WriteString(", \"nested\": {\"k\": [1, 2]}}\n")
+	}
+
+	p, err := ProfileDataset(strings.NewReader(src.String()), FORMAT_JSONL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每条记录截断到上限附近，总量超过上限，只保留部分抽样
+	if len(p.Sample) == 0 || len(p.Sample) >= profileSampleHead+profileSampleRandom || p.SampleHead != min(len(p.Sample), profileSampleHead) {
+		t.Fatalf("sample = %d, sampleHead = %d", len(p.Sample), p.SampleHead)
+	}
+	total := 0
+	for _, record := range p.Sample {
+		total += len(record)
+		if len(record) > profileSampleRecordBytes {
+			t.Fatalf("record of %d bytes exceeds limit", len(record))
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(record), &obj); err != nil {
+			t.Fatalf("truncated record is not valid JSON: %v", err)
+		}
+		if obj[profileTruncatedKey] != true || obj["id"] == nil {
+			t.Fatalf("truncated record = %.200s", record)
+		}
+		if text := obj["f00"].(string); !strings.HasSuffix(text, "…") || !utf8.ValidString(text) {
+			t.Fatalf("value not truncated: %.50s", text)
+		}
+	}
+	if total > profileSampleTotalBytes {
+		t.Fatalf("sample total %d bytes exceeds limit", total)
+	}
+}
+
+func TestTruncateSampleRecordKeepsShortRecords(t *testing.T) {
+	record := `{"id":1,"text":"short"}`
+	if got := truncateSampleRecord(record); got != record {
+		t.Fatalf("truncateSampleRecord(%q) = %q", record, got)
+	}
+}
+
+// 超过长度上限的记录计为格式错误，不整条读入内存；JSONL 和 CSV 跳到下一个换行符后继续解析
+func TestProfileDatasetOversizedRecord(t *testing.T) {
+	huge := strings.Repeat("x", 2*profileMaxRecordBytes)
+	cases := []struct {
+		name      string
+		format    string
+		src       string
+		records   int64
+		malformed int64
+		issueLine int64
+	}{
+		{"jsonl", FORMAT_JSONL, "{\"a\": 1}\n{\"a\": \"" + huge + "\"}\n{\"a\": 2}\n", 3, 1, 2},
+		{"jsonl last line", FORMAT_JSONL, "{\"a\": 1}\n" + huge, 2, 1, 2},
+		{"text", FORMAT_TEXT, "hello\n" + huge + "\nworld\n", 3, 1, 2},
+		{"csv quoted cell", FORMAT_CSV, "id,text\n1,a\n2,\"" + huge + "\n3,b\n", 3, 1, 3},
+		{"json element", FORMAT_JSON, `[{"a": 1}, "` + huge + `", {"a": 2}]`, 2, 1, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p, err := ProfileDataset(strings.NewReader(c.src), c.format, nil)
+			if err != nil {
+				t.Fatalf("ProfileDataset: %v", err)
+			}
+			if p.Records != c.records || p.MalformedRecords != c.malformed || p.OversizedRecords != 1 {
+				t.Errorf("records/malformed/oversized = %d/%d/%d, want %d/%d/1",
+					p.Records, p.MalformedRecords, p.OversizedRecords, c.records, c.malformed)
+			}
+			if len(p.Issues) == 0 || p.Issues[0].Line != c.issueLine || !strings.Contains(p.Issues[0].Message, "长度上限") {
+				t.Errorf("issues = %+v", p.Issues)
+			}
+			if p.Bytes != int64(len(c.src)) {
+				t.Errorf("bytes = %d, want %d", p.Bytes, len(c.src))
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package util
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrRangeNotSatisfiable = errors.New("请求范围无效")
+	// 已写满请求范围，用于提前结束生成
+	ErrRangeDone = errors.New("已写完请求范围")
+)
+
+// 解析单段 Range 请求头（bytes=a-b / bytes=a- / bytes=-n），返回闭区间 [start, end]
+// 多段范围和格式无效的范围（非数字、end < start 等）按 RFC 7233 忽略，按整文件处理（ok 为 false）；
+// 只有格式有效但不包含文件中任何字节（起点超出文件末尾）时返回 ErrRangeNotSatisfiable
+func ParseRange(header string, size int64) (start, end int64, ok bool, err error) {
+	if header == "" || !strings.HasPrefix(header, "bytes=") {
+		return 0, size - 1, false, nil
+	}
+	spec := strings.TrimSpace(strings.TrimPrefix(header, "bytes="))
+	if strings.Contains(spec, ",") {
+		return 0, size - 1, false, nil
+	}
+	startStr, endStr, found := strings.Cut(spec, "-")
+	startStr, endStr = strings.TrimSpace(startStr), strings.TrimSpace(endStr)
+	if !found {
+		return 0, size - 1, false, nil
+	}
+
+	if startStr == "" {
+		// 后缀范围：最后 n 个字节
+		n, perr := strconv.ParseInt(endStr, 10, 64)
+		if perr != nil || n < 0 {
+			return 0, size - 1, false, nil
+		}
+		if n == 0 || size <= 0 {
+			return 0, 0, false, ErrRangeNotSatisfiable
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, true, nil
+	}
+
+	start, perr := strconv.ParseInt(startStr, 10, 64)
+	if perr != nil || start < 0 {
+		return 0, size - 1, false, nil
+	}
+	end = size - 1
+	if endStr != "" {
+		end, perr = strconv.ParseInt(endStr, 10, 64)
+		if perr != nil || end < start {
+			return 0, size - 1, false, nil
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	if start >= size {
+		return 0, 0, false, ErrRangeNotSatisfiable
+	}
+	return start, end, true, nil
+}
+
+// 只把 [Start, End] 区间内的字节写入 W，之前的字节丢弃，写满后返回 ErrRangeDone
+type RangeWriter struct {
+	W     io.Writer
+	Start int64
+	End   int64
+	pos   int64
+}
+
+func (r *RangeWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	begin, finish := r.pos, r.pos+int64(n)
+	r.pos = finish
+	if finish <= r.Start {
+		return n, nil
+	}
+	if begin > r.End {
+		return n, ErrRangeDone
+	}
+	lo := int64(0)
+	if r.Start > begin {
+		lo = r.Start - begin
+	}
+	hi := int64(n)
+	if r.End+1 < finish {
+		hi = r.End + 1 - begin
+	}
+	if _, err := r.W.Write(p[lo:hi]); err != nil {
+		return n, err
+	}
+	if finish > r.End {
+		return n, ErrRangeDone
+	}
+	return n, nil
+}
@@ -0,0 +1,64 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	const size = 100
+	cases := []struct {
+		header     string
+		start, end int64
+		ok         bool
+		err        error
+	}{
+		{"", 0, 99, false, nil},
+		{"bytes=0-9", 0, 9, true, nil},
+		{"bytes=90-", 90, 99, true, nil},
+		{"bytes=90-200", 90, 99, true, nil},
+		{"bytes=-10", 90, 99, true, nil},
+		{"bytes=-200", 0, 99, true, nil},
+		// 格式无效的范围忽略，返回整个文件
+		{"items=0-9", 0, 99, false, nil},
+		{"bytes=0-9,20-29", 0, 99, false, nil},
+		{"bytes=abc", 0, 99, false, nil},
+		{"bytes=a-9", 0, 99, false, nil},
+		{"bytes=0-b", 0, 99, false, nil},
+		{"bytes=50-10", 0, 99, false, nil},
+		{"bytes=-", 0, 99, false, nil},
+		{"bytes=-x", 0, 99, false, nil},
+		// 起点超出文件末尾
+		{"bytes=100-", 0, 0, false, ErrRangeNotSatisfiable},
+		{"bytes=150-200", 0, 0, false, ErrRangeNotSatisfiable},
+		{"bytes=-0", 0, 0, false, ErrRangeNotSatisfiable},
+	}
+	for _, c := range cases {
+		start, end, ok, err := ParseRange(c.header, size)
+		if !errors.Is(err, c.err) || ok != c.ok || (err == nil && (start != c.start || end != c.end)) {
+			t.Errorf("ParseRange(%q) = %d, %d, %v, %v, want %d, %d, %v, %v",
+				c.header, start, end, ok, err, c.start, c.end, c.ok, c.err)
+		}
+	}
+
+	if _, _, _, err := ParseRange("bytes=0-", 0); !errors.Is(err, ErrRangeNotSatisfiable) {
+		t.Errorf("empty file: err = %v, want ErrRangeNotSatisfiable", err)
+	}
+}
+
+func TestRangeWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := &RangeWriter{W: &buf, Start: 3, End: 7}
+	for _, chunk := range []string{"01", "234", "56789"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			if !errors.Is(err, ErrRangeDone) {
+				t.Fatalf("Write: %v", err)
+			}
+			break
+		}
+	}
+	if got := buf.String(); got != "34567" {
+		t.Errorf("got %q, want %q", got, "34567")
+	}
+}
@@ -53,3 +53,18 @@ func NotFound(c *gin.Context, msg string) {
 func Forbidden(c *gin.Context, msg string) {
 	Failure(c, http.StatusForbidden, msg)
 }
+
+// 需要付费
+func PaymentRequired(c *gin.Context, msg string) {
+	Failure(c, http.StatusPaymentRequired, msg)
+}
+
+// 资源状态冲突
+func Conflict(c *gin.Context, msg string) {
+	Failure(c, http.StatusConflict, msg)
+}
+
+// 服务不可用
+func ServiceUnavailable(c *gin.Context, msg string) {
+	Failure(c, http.StatusServiceUnavailable, msg)
+}
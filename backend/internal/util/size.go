@@ -0,0 +1,44 @@
+package util
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidSize = errors.New("文件大小格式错误")
+
+// 文件大小单位（按 1024 进制），后缀按长度从长到短匹配
+var sizeUnits = []struct {
+	suffix string
+	bytes  float64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30}, {"TIB", 1 << 40},
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"TB", 1 << 40},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"T", 1 << 40},
+	{"B", 1},
+}
+
+// 解析带单位的文件大小（如 1024、10MB、1.5GB、512 KiB，不区分大小写），返回字节数
+func ParseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := 1.0
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 || math.IsInf(n, 0) || math.IsNaN(n) {
+		return 0, ErrInvalidSize
+	}
+	size := n * multiplier
+	// MaxInt64 转换为 float64 后进位为 1<<63，须用 >= 比较
+	if size >= 1<<63 {
+		return 0, ErrInvalidSize
+	}
+	return int64(size), nil
+}
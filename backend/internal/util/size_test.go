@@ -0,0 +1,43 @@
+package util
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int64
+		err  bool
+	}{
+		{"1024", 1024, false},
+		{"10MB", 10 << 20, false},
+		{"1GB", 1 << 30, false},
+		{"1.5gb", 3 << 29, false},
+		{"512 KiB", 512 << 10, false},
+		{" 100 ", 100, false},
+		{"8TB", 8 << 40, false},
+		{"abc", 0, true},
+		{"", 0, true},
+		{"-", 0, true},
+		{"-50", 0, true},
+		{"MB", 0, true},
+		{"NaN", 0, true},
+		{"Inf", 0, true},
+		{"8388608TB", 0, true}, // 1<<63 字节
+		{"9223372036854775807", 0, true},
+	}
+	for _, c := range cases {
+		got, err := ParseSize(c.in)
+		if c.err {
+			if !errors.Is(err, ErrInvalidSize) {
+				t.Errorf("ParseSize(%q) = %d, %v, want ErrInvalidSize", c.in, got, err)
+			}
+			continue
+		}
+		if err != nil || got != c.want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", c.in, got, err, c.want)
+		}
+	}
+}
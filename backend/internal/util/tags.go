@@ -0,0 +1,33 @@
+package util
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// 单个标签的最大长度（字符）
+const TAG_MAX_LENGTH = 50
+
+// 解析逗号分隔的标签（兼容中文逗号、顿号和分号）：去除首尾空白、合并连续空白、转小写并去重，保持原有顺序
+func ParseTags(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '，' || r == '、' || r == ';' || r == '；'
+	})
+	tags := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		tag := strings.ToLower(strings.Join(strings.Fields(f), " "))
+		if tag == "" {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > TAG_MAX_LENGTH {
+			tag = string([]rune(tag)[:TAG_MAX_LENGTH])
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
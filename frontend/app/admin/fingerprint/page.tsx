@@ -65,9 +65,10 @@ export default function AdminFingerprintPage() {
   const handleFileSelect = useCallback((event: React.ChangeEvent<HTMLInputElement>) => {
     const file = event.target.files?.[0]
     if (file) {
-      // 验证文件类型 (只支持 JSONL 格式)
-      if (!file.name.toLowerCase().endsWith(".jsonl")) {
-        setError("只支持 JSONL 格式的文件")
+      // 验证文件类型（与服务端支持的数据集格式一致）
+      const ext = file.name.toLowerCase().split(".").pop() || ""
+      if (!["jsonl", "ndjson", "csv", "tsv", "txt", "json"].includes(ext)) {
+        setError("只支持 JSONL、CSV、TSV、TXT、JSON 格式的文件")
         return
       }
 
@@ -271,11 +272,11 @@ export default function AdminFingerprintPage() {
                       <FileText className="h-12 w-12 mx-auto text-muted-foreground mb-4" />
                       <div className="space-y-2">
                         <p className="text-lg font-medium">拖拽文件到此处或点击选择</p>
-                        <p className="text-sm text-muted-foreground">支持 JSONL 格式</p>
+                        <p className="text-sm text-muted-foreground">支持 JSONL、CSV、TSV、TXT、JSON 格式</p>
                       </div>
                       <input
                         type="file"
-                        accept=".jsonl"
+                        accept=".jsonl,.ndjson,.csv,.tsv,.txt,.json"
                         onChange={handleFileSelect}
                         className="hidden"
                         id="file-upload"
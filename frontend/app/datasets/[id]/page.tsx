@@ -63,6 +63,7 @@ export default function DatasetDetailPage() {
   const [previewData, setPreviewData] = useState<any[]>([]);
   const [previewLoading, setPreviewLoading] = useState(false);
   const [previewError, setPreviewError] = useState("");
+  const [previewSource, setPreviewSource] = useState<"server" | "seller">("server");
 
   // 伪进度条相关逻辑全部交由 usePaidDownloadWithProgress 管理
   const { progress: downloadProgress, status: downloadStatus, modalOpen: downloadModalOpen, startDownload, closeModal } = usePaidDownloadWithProgress(dataset && dataset.objectName ? { id: dataset.id, fileSize: dataset.fileSize, objectName: dataset.objectName } : null);
@@ -131,9 +132,10 @@ export default function DatasetDetailPage() {
     if (!dataset || !dataset.objectName) return;
     setPreviewLoading(true);
     setPreviewError("");
-    fetchDatasetPreviewByObjectName(dataset.objectName)
+    fetchDatasetPreviewByObjectName(dataset.objectName, 1, 40)
       .then((data) => {
         setPreviewData(Array.isArray(data.data) ? data.data : []);
+        setPreviewSource(data.source);
       })
       .catch(() => {
         setPreviewData([]);
@@ -169,7 +171,10 @@ export default function DatasetDetailPage() {
     try {
       if (dataset.isFree) {
         toast.success("下载已开始", { description: "正在获取下载链接..." });
-        const url = await downloadFreeDataset(dataset.id);
+        const { url, checksum } = await downloadFreeDataset(dataset.id);
+        if (checksum) {
+          toast.info("文件 SHA-256", { description: checksum });
+        }
         // 触发浏览器下载
         const a = document.createElement('a');
         a.href = url;
@@ -453,7 +458,9 @@ export default function DatasetDetailPage() {
           <Card>
             <CardHeader>
               <CardTitle>数据预览</CardTitle>
-              <CardDescription>查看数据集的样本数据（JSONL格式，共20条预览数据）</CardDescription>
+              <CardDescription>
+                {previewSource === "server" ? "由平台从数据集文件中抽样（文件开头与随机抽取的记录）" : "卖家上传的样本数据"}
+              </CardDescription>
             </CardHeader>
             <CardContent>
               {/* 预览数据展示，独立请求 previewData */}
@@ -464,9 +471,9 @@ export default function DatasetDetailPage() {
                 </div>
               ) : previewData.length > 0 ? (
                 <div className="space-y-4">
-                  <PreviewTable data={previewData.slice(0, 20)} />
+                  <PreviewTable data={previewData} />
                   <p className="text-sm text-muted-foreground">
-                    显示前{Math.min(previewData.length, 20)}条数据
+                    共{previewData.length}条样本数据
                   </p>
                 </div>
               ) : (
@@ -513,6 +520,12 @@ export default function DatasetDetailPage() {
                       <span className="text-muted-foreground">创建时间:</span>
                       <span>{dataset.createdAt ? new Date(dataset.createdAt).toLocaleDateString() : "-"}</span>
                     </div>
+                    {dataset.checksum && (
+                      <div className="flex justify-between gap-4">
+                        <span className="text-muted-foreground shrink-0">SHA-256:</span>
+                        <span className="font-mono text-xs break-all text-right" title={dataset.isFree ? undefined : "付费下载的文件带有买家指纹，SHA-256 以下载时提供的为准"}>{dataset.checksum}</span>
+                      </div>
+                    )}
                   </div>
                 </div>
 
@@ -28,6 +28,8 @@ import { Dialog, DialogContent, DialogHeader, DialogTitle, DialogFooter } from '
 import { Input } from '@/components/ui/input'
 import { Textarea } from '@/components/ui/textarea'
 import { getWritableContract } from '@/contract/contractConnect'
+import { updateDatasetApi } from '@/lib/api/dataset'
+import { ethers } from 'ethers'
 import { toast } from 'sonner'
 
 export default function MyDatasetsPage() {
@@ -148,17 +150,27 @@ export default function MyDatasetsPage() {
     if (!editingDataset) return
     setEditLoading(true)
     try {
-      const contract = await getWritableContract()
-      const tx = await contract.updateDataset(
-        editingDataset.id,
-        editForm.title,
-        editForm.description,
-        Number(editForm.price)
-      )
-      await tx.wait()
-      toast.success('数据集已成功更新（链上）')
+      // 先提交到后端（校验并记录修改），付费数据集再按返回的 chainAction 同步到合约
+      const result = await updateDatasetApi(editingDataset.id, {
+        title: editForm.title,
+        description: editForm.description,
+        ...(editingDataset.isFree ? {} : { price: Number(editForm.price) }),
+      })
+      if (result.chainAction === 'updateDataset') {
+        const contract = await getWritableContract()
+        const tx = await contract.updateDataset(
+          editingDataset.id,
+          editForm.title,
+          editForm.description,
+          ethers.parseEther(editForm.price)
+        )
+        await tx.wait()
+        toast.success('数据集已成功更新（链上），价格将在链上确认后生效')
+      } else {
+        toast.success('数据集已成功更新')
+      }
       // 本地同步更新
-      setDatasets((prev) => prev.map((d) => d.id === editingDataset.id ? { ...d, ...editForm, price: Number(editForm.price) } : d))
+      setDatasets((prev) => prev.map((d) => d.id === editingDataset.id ? { ...d, ...result.dataset } : d))
       setEditOpen(false)
     } catch (err: any) {
       toast.error('更新失败: ' + (err?.reason || err?.message || '未知错误'))
@@ -636,7 +636,7 @@ export default function UploadPage() {
                           type="file"
                           onChange={handleFileUpload}
                           className="hidden"
-                          accept=".jsonl"
+                          accept=".jsonl,.ndjson,.csv,.tsv,.txt,.json"
                         ref={fileInputRef}
                         />
                         <label htmlFor="files" className="cursor-pointer">
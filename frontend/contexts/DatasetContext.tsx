@@ -6,6 +6,7 @@ import {
   putPartToMinio,
   completeMultipartUpload,
   abortMultipartUploadApi,
+  resumeMultipartUploadApi,
 } from "@/lib/api/dataset";
 import { deleteDatasetAndFileApi } from "@/lib/api/dataset"
 import { getFreeDatasets, getPaidDatasets, getDatasetById, getPaidDatasetsByAuthor, getDatasetPreviewByObjectName } from "@/lib/api/dataset";
@@ -41,7 +42,7 @@ export async function multipartUploadWithResume(
   setObjectName?: (objectName: string) => void 
 ) {
   const stateKey = `multipart-upload-${objectName}`;
-  let state: { uploadId: string, objectName: string, parts: Record<number, PartInfo>, fileSize: number, fileName: string } | null = null;
+  let state: { uploadId: string, objectName: string, parts: Record<number, PartInfo>, fileSize: number, fileName: string, partSize: number } | null = null;
   const saved = localStorage.getItem(stateKey);
   if (saved) {
     state = JSON.parse(saved);
@@ -49,23 +50,41 @@ export async function multipartUploadWithResume(
       state = null;
     }
   }
+  if (state) {
+    // 已上传分片以服务端记录为准，会话已结束或过期时重新上传
+    try {
+      const session = await resumeMultipartUploadApi(state.objectName);
+      if (session.status !== 'uploading' || session.uploadId !== state.uploadId) throw new Error(session.status);
+      state.partSize = session.partSize;
+      state.parts = {};
+      for (const p of session.parts) {
+        state.parts[p.partNumber] = { etag: p.etag, size: p.size };
+      }
+      localStorage.setItem(stateKey, JSON.stringify(state));
+    } catch {
+      localStorage.removeItem(stateKey);
+      state = null;
+    }
+  }
 
   let uploadId = state?.uploadId;
   if (!uploadId) {
-    const { uploadId: newUploadId } = await initMultipartUpload(objectName);
+    // 对象名和分片大小由后端决定，后续分片和合并都使用后端返回的值
+    const { uploadId: newUploadId, objectName: serverObjectName, partSize } = await initMultipartUpload(file.name, file.size);
     uploadId = newUploadId;
     state = {
       uploadId: uploadId!,
-      objectName,
+      objectName: serverObjectName,
       parts: {},
       fileSize: file.size,
       fileName: file.name,
+      partSize,
     };
     localStorage.setItem(stateKey, JSON.stringify(state));
   }
   if (!state) return;
 
-  const chunkSize = 5 * 1024 * 1024;
+  const chunkSize = state.partSize || 5 * 1024 * 1024;
   const chunks = Math.ceil(file.size / chunkSize);
   const parts: { ETag: string; PartNumber: number }[] = [];
   let uploadedBytesRef = 0;
@@ -92,7 +111,7 @@ export async function multipartUploadWithResume(
     const end = Math.min(file.size, start + chunkSize);
     const chunk = file.slice(start, end);
 
-    const partUrl = await getPartUploadUrl(objectName, uploadId!, partNumber);
+    const partUrl = await getPartUploadUrl(state.objectName, uploadId!, partNumber);
     console.log("partNumber", partNumber);
     // 新增：为每个分片创建 AbortController
     const abortController = new AbortController();
@@ -121,7 +140,7 @@ export async function multipartUploadWithResume(
     }
     isPaused = false;
     if (current >= chunks && uploading === 0) {
-      completeMultipartUpload(objectName, uploadId!, parts.sort((a, b) => a.PartNumber - b.PartNumber))
+      completeMultipartUpload(state!.objectName, uploadId!, parts.sort((a, b) => a.PartNumber - b.PartNumber))
         .then((data) => {
           localStorage.removeItem(stateKey);
           if (setObjectName && data && data.objectName) setObjectName(data.objectName);
@@ -209,8 +228,8 @@ export async function fetchDatasetById(id: number) {
 }
 
 // 获取数据集预览数据（业务层）
-export async function fetchDatasetPreviewByObjectName(objectName: string) {
-  return await getDatasetPreviewByObjectName(objectName);
+export async function fetchDatasetPreviewByObjectName(objectName: string, page?: number, limit?: number) {
+  return await getDatasetPreviewByObjectName(objectName, page, limit);
 }
 
 // 获取免费数据集下载链接（业务层）
@@ -225,8 +244,8 @@ export async function startPaidDownloadTask(datasetId: number) {
   return apiStartPaidDownloadTask(datasetId);
 }
 // 查询付费下载任务状态
-export async function getPaidDownloadTaskStatus(datasetId: number, objectName: string) {
-  return apiGetPaidDownloadTaskStatus(datasetId, objectName);
+export async function getPaidDownloadTaskStatus(datasetId: number, jobId: number) {
+  return apiGetPaidDownloadTaskStatus(datasetId, jobId);
 }
 
 // 付费下载伪进度条业务逻辑，返回 {progress, status, modalOpen, startDownload}
@@ -243,12 +262,16 @@ export function usePaidDownloadWithProgress(dataset: { id: number; fileSize: num
     setStatus('正在准备下载...');
     setModalOpen(true);
     pollingRef.current = true;
-    await startPaidDownloadTask(dataset.id); // 只发起任务
+    const { jobId } = await startPaidDownloadTask(dataset.id); // 只发起任务
     let polling = true;
     while (polling && pollingRef.current) {
       // 轮询获取进度和任务信息
-      const statusRes = await getPaidDownloadTaskStatus(dataset.id, dataset.objectName);
-      // statusRes: { url, progressPercent, status }
+      const statusRes = await getPaidDownloadTaskStatus(dataset.id, jobId);
+      // statusRes: { url, progressPercent, status, state, error }
+      if (statusRes.state === 'failed') {
+        setStatus('下载任务失败: ' + (statusRes.error || '未知错误'));
+        break;
+      }
       const progressPercent = statusRes.progressPercent ?? 0;
       setProgress(progressPercent);
       if ((progressPercent >= 100 || statusRes.status === 1) && statusRes.url) {
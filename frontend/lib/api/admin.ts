@@ -165,6 +165,41 @@ export async function restoreDatasetApi(datasetId: number): Promise<void> {
   }
 }
 
+// 获取重复文件记录（其他卖家已上传过相同文件的数据集版本）
+export async function getDatasetDuplicatesApi(params: {
+  page?: number
+  limit?: number
+  status?: import('@/types').DatasetDuplicateStatus
+}): Promise<PaginatedResponse<import('@/types').DatasetDuplicate>> {
+  const res = await axios.get(`${BASE_URL}/admin/duplicates`, {
+    params: {
+      ...params,
+      page: params.page || 1,
+      limit: params.limit || 10,
+    },
+    withCredentials: true,
+  })
+
+  if (res.data.code === 200 && res.data.data) {
+    return res.data.data
+  }
+
+  throw new Error(res.data.msg || '获取重复文件记录失败')
+}
+
+// 审核重复文件记录：confirm 下架数据集，dismiss 标记为误报
+export async function reviewDatasetDuplicateApi(duplicateId: number, action: 'confirm' | 'dismiss', note = ''): Promise<import('@/types').DatasetDuplicate> {
+  const res = await axios.post(`${BASE_URL}/admin/duplicates/${duplicateId}/review`, { action, note }, {
+    withCredentials: true,
+  })
+
+  if (res.data.code === 200 && res.data.data?.data) {
+    return res.data.data.data
+  }
+
+  throw new Error(res.data.msg || '审核重复文件失败')
+}
+
 // ==================== 指纹检测相关 API ====================
 
 // 上传文件进行指纹检测
@@ -185,13 +220,63 @@ export async function detectFingerprintApi(file: File): Promise<import('@/types'
   throw new Error(res.data.msg || '指纹检测失败')
 }
 
+// 创建异步指纹检测任务：上传文件，或检测 MinIO 中已有的对象（适合大文件）
+export async function createFingerprintDetectJobApi(source: { file: File, format?: string } | { bucket: string, objectName: string, format?: string }): Promise<number> {
+  let res
+  if ('file' in source) {
+    const formData = new FormData()
+    formData.append('file', source.file)
+    if (source.format) formData.append('format', source.format)
+    res = await axios.post(`${BASE_URL}/admin/fingerprint/detect-jobs`, formData, {
+      withCredentials: true,
+      headers: {
+        'Content-Type': 'multipart/form-data',
+      },
+    })
+  } else {
+    res = await axios.post(`${BASE_URL}/admin/fingerprint/detect-jobs`, source, { withCredentials: true })
+  }
+  if (res.data.code === 200 && res.data.data) {
+    return res.data.data.jobId
+  }
+
+  throw new Error(res.data.msg || '创建检测任务失败')
+}
+
+// 查询指纹检测任务状态，state 为 queued/running/done/failed，完成后 result 为检测结果
+export async function getFingerprintDetectJobApi(jobId: number): Promise<{ jobId: number, state: string, progress: number, filename: string, error: string, result?: any }> {
+  const res = await axios.get(`${BASE_URL}/admin/fingerprint/detect-jobs/${jobId}`, { withCredentials: true })
+  if (res.data.code === 200 && res.data.data) {
+    return res.data.data
+  }
+
+  throw new Error(res.data.msg || '获取检测任务状态失败')
+}
+
+// 取消指纹检测任务
+export async function cancelFingerprintDetectJobApi(jobId: number): Promise<void> {
+  const res = await axios.post(`${BASE_URL}/admin/fingerprint/detect-jobs/${jobId}/cancel`, null, { withCredentials: true })
+  if (res.data.code !== 200) {
+    throw new Error(res.data.msg || '取消检测任务失败')
+  }
+}
+
 // 获取指纹检测历史记录
 export async function getFingerprintHistoryApi(params: {
   page?: number
   limit?: number
+  status?: 'no_fingerprint' | 'unknown' | 'matched' | 'conflict'
+  datasetId?: number
+  transactionId?: number
+  buyerWallet?: string
+  buyerUserId?: number
+  minConfidence?: number
+  startDate?: string
+  endDate?: string
 }): Promise<import('@/types').PaginatedResponse<import('@/types').FingerprintHistoryItem>> {
   const res = await axios.get(`${BASE_URL}/admin/fingerprint/records`, {
     params: {
+      ...params,
       page: params.page || 1,
       limit: params.limit || 20,
     },
@@ -205,6 +290,11 @@ export async function getFingerprintHistoryApi(params: {
   throw new Error(res.data.msg || '获取指纹检测历史失败')
 }
 
+// 指纹匹配报告导出地址（json 或 html 证据文件）
+export function getFingerprintReportURL(recordId: number, format: 'json' | 'html' = 'json'): string {
+  return `${BASE_URL}/admin/fingerprint/records/${recordId}/report?format=${format}`
+}
+
 // ==================== MinIO存储管理相关 API ====================
 
 // 获取所有存储桶列表
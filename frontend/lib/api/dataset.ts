@@ -1,5 +1,5 @@
 import axios from 'axios'
-import type { DatasetUploadForm } from '@/types'
+import type { DatasetDownloadURL, DatasetPreview, DatasetSort, DatasetUpdateForm, DatasetUpdateResult, DatasetUploadForm, DatasetValidation, DatasetVersion, UploadSession } from '@/types'
 const BASE_URL = process.env.NEXT_PUBLIC_API_BASE
 
 // 上传预览数据集数据
@@ -36,9 +36,52 @@ export async function createDatasetApi(form: DatasetUploadForm): Promise<any> {
 }
 
 // 初始化分片上传
-export async function initMultipartUpload(fileName: string) {
-  const res = await axios.post(`${BASE_URL}/dataset/init-multipart`, { fileName }, { withCredentials: true });
-  return res.data.data; // { uploadId, objectName }
+// 发布数据集新版本（文件需先通过分片上传接口上传）
+export async function publishDatasetVersionApi(params: { datasetId: number; objectName: string; fileSize: number; format?: string; checksum?: string; changelog: string }): Promise<DatasetVersion> {
+  const res = await axios.post(`${BASE_URL}/dataset/publish-version`, params, { withCredentials: true });
+  if (res.data.code === 201 && res.data.data?.version) {
+    return res.data.data.version as DatasetVersion;
+  }
+  throw new Error(res.data.msg || '发布数据集版本失败');
+}
+
+// 修改数据集元数据，chainAction 不为空时需继续调用合约，价格在链上事件同步后生效
+export async function updateDatasetApi(datasetId: number, form: DatasetUpdateForm): Promise<DatasetUpdateResult> {
+  const res = await axios.put(`${BASE_URL}/dataset/${datasetId}`, form, { withCredentials: true });
+  if (res.data.code === 200 && res.data.data?.data) {
+    return res.data.data.data as DatasetUpdateResult;
+  }
+  throw new Error(res.data.msg || '修改数据集失败');
+}
+
+// 查询数据集版本的文件校验报告（仅作者本人），version 为空时取最新版本
+export async function getDatasetValidationApi(datasetId: number, version?: number): Promise<DatasetValidation> {
+  const res = await axios.get(`${BASE_URL}/dataset/validation`, {
+    params: { datasetId, version },
+    withCredentials: true,
+  });
+  if (res.data.code === 200 && res.data.data?.data) {
+    return res.data.data.data as DatasetValidation;
+  }
+  throw new Error(res.data.msg || '查询校验报告失败');
+}
+
+// 初始化分片上传，服务端创建上传会话，partSize 不传时使用服务端默认分片大小
+export async function initMultipartUpload(fileName: string, fileSize: number, partSize?: number) {
+  const res = await axios.post(`${BASE_URL}/dataset/init-multipart`, { fileName, fileSize, partSize }, { withCredentials: true });
+  if (res.data.code !== 200) {
+    throw new Error(res.data.msg || '初始化分片上传失败');
+  }
+  return res.data.data; // { uploadId, objectName, partSize, totalParts, expiresAt }
+}
+
+// 续传：查询上传会话和服务端记录的已上传分片，会话已结束或不存在时抛错
+export async function resumeMultipartUploadApi(objectName: string): Promise<UploadSession> {
+  const res = await axios.post(`${BASE_URL}/dataset/resume-multipart`, { objectName }, { withCredentials: true });
+  if (res.data.code === 200 && res.data.data?.data) {
+    return res.data.data.data as UploadSession;
+  }
+  throw new Error(res.data.msg || '上传会话已失效');
 }
 
 // 获取分片 presigned url
@@ -77,8 +120,44 @@ export async function deleteDatasetAndFileApi(datasetId: number, objectName: str
   });
 }
 
+// 全文搜索数据集（相关度排序，返回分面统计和高亮片段，高亮为已转义的 HTML，命中词用 <mark> 包裹）
+export async function searchDatasetsApi(params: {
+  q: string
+  page?: number
+  limit?: number
+  category?: string
+  license?: string
+  is_free?: boolean
+  fileSizeRange?: string
+  priceRange?: string
+}) {
+  const res = await axios.get(`${BASE_URL}/dataset/search`, {
+    params,
+    withCredentials: true,
+  });
+  return res.data.data;
+}
+
+// 热门标签（按数据集数量倒序）
+export async function getPopularTagsApi(limit = 20): Promise<{ name: string; count: number }[]> {
+  const res = await axios.get(`${BASE_URL}/dataset/tags/popular`, {
+    params: { limit },
+    withCredentials: true,
+  });
+  return res.data.data.items;
+}
+
+// 标签前缀补全
+export async function autocompleteTagsApi(prefix: string, limit = 10): Promise<{ name: string; count: number }[]> {
+  const res = await axios.get(`${BASE_URL}/dataset/tags/autocomplete`, {
+    params: { prefix, limit },
+    withCredentials: true,
+  });
+  return res.data.data.items;
+}
+
 // 获取免费数据集
-export async function getFreeDatasets(params: { page?: number; limit?: number; category?: string; search?: string; fileSizeRange?: string }) {
+export async function getFreeDatasets(params: { page?: number; limit?: number; category?: string; search?: string; fileSizeRange?: string; tags?: string; tagMode?: 'and' | 'or'; sort?: DatasetSort; cursor?: string; withTotal?: boolean }) {
   const res = await axios.get(`${BASE_URL}/dataset/list`, {
     params: {
       ...params,
@@ -90,7 +169,7 @@ export async function getFreeDatasets(params: { page?: number; limit?: number; c
 }
 
 // 获取付费数据集
-export async function getPaidDatasets(params: { page?: number; limit?: number; category?: string; search?: string; fileSizeRange?: string; priceRange?: string }) {
+export async function getPaidDatasets(params: { page?: number; limit?: number; category?: string; search?: string; fileSizeRange?: string; priceRange?: string; tags?: string; tagMode?: 'and' | 'or'; sort?: DatasetSort; cursor?: string; withTotal?: boolean }) {
   const res = await axios.get(`${BASE_URL}/dataset/list`, {
     params: {
       ...params,
@@ -126,48 +205,57 @@ export async function getDatasetById(id: number) {
   return res.data.data;
 }
 
-// 获取数据集预览数据
-export async function getDatasetPreviewByObjectName(objectName: string) {
+// 获取数据集预览数据（服务端从文件抽样的记录和字段概况，按页返回抽样记录）
+export async function getDatasetPreviewByObjectName(objectName: string, page?: number, limit?: number): Promise<DatasetPreview> {
   const res = await axios.get(`${BASE_URL}/dataset/preview`, {
-    params: { objectName },
+    params: { objectName, page, limit },
     withCredentials: true,
   });
   return res.data.data;
 }
 
-// 获取免费数据集下载链接
-export async function downloadFreeDataset(datasetId: number): Promise<string> {
+// 获取免费数据集下载链接和文件 SHA-256/MD5（version 不传时下载最新版本）
+export async function downloadFreeDataset(datasetId: number, version?: number): Promise<DatasetDownloadURL> {
   const res = await axios.get(`${BASE_URL}/dataset/download-free`, {
-    params: { datasetID: datasetId },
+    params: { datasetID: datasetId, version },
     withCredentials: true,
   });
   if (res.data.code === 200 && res.data.data?.url) {
-    return res.data.data.url;
+    return res.data.data as DatasetDownloadURL;
   } else {
     throw new Error(res.data.msg || '获取下载链接失败');
   }
 }
 
-// 发起付费下载任务，返回objectName
-export async function startPaidDownloadTask(datasetId: number): Promise<{ objectName: string }> {
-  const res = await axios.get(`${BASE_URL}/dataset/download-paid`, { params: { datasetID: datasetId }, withCredentials: true });
+// 发起付费下载任务，返回objectName和任务ID（version 不传时下载最新版本）
+export async function startPaidDownloadTask(datasetId: number, version?: number): Promise<{ objectName: string, jobId: number }> {
+  const res = await axios.get(`${BASE_URL}/dataset/download-paid`, { params: { datasetID: datasetId, version }, withCredentials: true });
   if (res.data.code === 200) {
-    return res.data.data as { objectName: string };
+    return res.data.data as { objectName: string, jobId: number };
   }
   throw new Error(res.data.msg || '发起下载任务失败');
 }
 
-// 查询付费下载任务状态，需传递 objectName 和 datasetID
-export async function getPaidDownloadTaskStatus(datasetId: number, objectName: string): Promise<{ url: string, progressPercent: number, status: 0|1 }> {
+// 流式下载付费数据集地址（服务端开启 download.streaming 时可用，浏览器可直接断点续传，响应头 X-Checksum-Sha256 为文件 SHA-256）
+export function getPaidDownloadStreamURL(datasetId: number, version?: number): string {
+  const versionQuery = version ? `&version=${version}` : '';
+  return `${BASE_URL}/dataset/download-paid-stream?datasetID=${datasetId}${versionQuery}`;
+}
+
+// 查询付费下载任务状态，state 为 queued/running/done/failed，完成后 checksum 为下载文件（带买家指纹）的 SHA-256
+export async function getPaidDownloadTaskStatus(datasetId: number, jobId: number): Promise<{ url: string, progressPercent: number, status: 0|1, state: string, checksum: string, error: string }> {
   const res = await axios.get(`${BASE_URL}/dataset/download-status`, {
-    params: { datasetID: datasetId, objectName },
+    params: { datasetID: datasetId, jobId },
     withCredentials: true,
   });
   if (res.data.code === 200 && res.data.data) {
     return {
       url: res.data.data.url,
-      progressPercent: res.data.data.progress, // 后端taskId即进度百分比
-      status: res.data.data.status
+      progressPercent: res.data.data.progress,
+      status: res.data.data.status,
+      state: res.data.data.state,
+      checksum: res.data.data.checksum,
+      error: res.data.data.error,
     };
   } else {
     throw new Error(res.data.msg || '查询下载任务状态失败');
@@ -102,6 +102,212 @@ export interface Dataset {
   createdAt: string;
   updatedAt: string;
   objectName: string;
+  checksum: string;  // 当前文件 SHA-256，历史数据集可能为空
+  status: DatasetStatus;
+}
+
+/**
+ * 数据集/版本状态：文件校验通过前不对外展示，未通过的版本不能下载
+ */
+export type DatasetStatus = 'validating' | 'ready' | 'rejected';
+
+/**
+ * 数据集版本（发布后不可修改，已购买用户可下载全部版本）
+ */
+export interface DatasetVersion {
+  id: number;
+  datasetId: number;
+  version: number;
+  objectName: string;
+  fileSize: number;
+  format: string;
+  checksum: string;  // SHA-256
+  md5: string;
+  etag: string;
+  changelog: string;
+  status: DatasetStatus;
+  createdAt: string;
+}
+
+/**
+ * 免费数据集下载链接及文件校验值
+ */
+export interface DatasetDownloadURL {
+  url: string;
+  version: number;
+  fileSize: number;
+  checksum: string;  // SHA-256，历史版本可能为空
+  md5: string;
+}
+
+/**
+ * 重复文件记录：版本通过校验时其他卖家已有相同 SHA-256 的文件，等待管理员审核
+ */
+export type DatasetDuplicateStatus = 'pending' | 'confirmed' | 'dismissed';
+
+export interface DatasetDuplicate {
+  id: number;
+  datasetId: number;
+  versionId: number;
+  version: number;
+  checksum: string;
+  originalDatasetId: number;
+  originalVersionId: number;
+  originalVersion: number;
+  status: DatasetDuplicateStatus;
+  reviewerId: number | null;
+  reviewNote: string;
+  reviewedAt: string | null;
+  createdAt: string;
+  title: string;
+  authorWalletAddress: string;
+  originalTitle: string;
+  originalAuthorAddress: string;
+}
+
+/**
+ * 数据集字段结构及取值统计（数值统计仅 integer/number，长度统计仅 string）
+ */
+export interface DatasetPreviewField {
+  name: string;
+  types: Record<string, number>;
+  nullRate: number;
+  min?: number;
+  max?: number;
+  mean?: number;
+  minLength?: number;
+  maxLength?: number;
+  distinct: number;
+  distinctTruncated?: boolean;
+  examples: string[];
+}
+
+/**
+ * 数据集预览（分页）：source 为 server 时为服务端从文件抽样的记录，
+ * 前 sampleHead 条为文件开头的记录，其余为随机抽样；seller 为历史数据集卖家上传的预览
+ */
+export interface DatasetPreview {
+  source: 'server' | 'seller';
+  data: string[];
+  page: number;
+  limit: number;
+  total: number;
+  totalPages: number;
+  sampleHead: number;
+  records: number;
+  fields: DatasetPreviewField[];
+  generatedAt: string | null;
+}
+
+/**
+ * 分片上传会话：parts 为服务端（MinIO）记录的已上传分片，missingParts 为尚未上传的分片号
+ */
+export interface UploadSession {
+  id: number;
+  userId: number;
+  objectName: string;
+  uploadId: string;
+  fileName: string;
+  expectedSize: number;
+  partSize: number;
+  totalParts: number;
+  uploadedBytes: number;
+  status: 'uploading' | 'completed' | 'used' | 'aborted' | 'expired' | 'deleted';
+  expiresAt: string;
+  completedAt: string | null;
+  createdAt: string;
+  updatedAt: string;
+  parts: { partNumber: number; etag: string; size: number }[];
+  missingParts: number[];
+}
+
+/**
+ * 数据集文件校验报告
+ */
+export interface DatasetValidationReport {
+  bytes: number;
+  sha256: string;
+  records: number;
+  malformedRecords: number;
+  encodingErrors: number;
+  fields: { name: string; types: Record<string, number>; nulls: number; nullRate: number }[];
+  fieldsTruncated: boolean;
+  issues: { line: number; message: string }[];
+  declaredSize: number;
+  declaredChecksum?: string;
+  errorRate: number;
+  previewMatched?: boolean;
+  reasons: string[];
+}
+
+/**
+ * 数据集版本文件校验任务，完成后 result 为校验结论
+ */
+export interface DatasetValidation {
+  id: number;
+  datasetId: number;
+  versionId: number;
+  version: number;
+  objectName: string;
+  format: string;
+  declaredSize: number;
+  declaredChecksum: string;
+  status: 'queued' | 'running' | 'done' | 'failed';
+  progress: number;
+  attempts: number;
+  error: string;
+  result: '' | 'ready' | 'rejected';
+  report: DatasetValidationReport | null;
+  startedAt: string | null;
+  finishedAt: string | null;
+  createdAt: string;
+  updatedAt: string;
+}
+
+/**
+ * 数据集详情（含版本列表，按版本号倒序）
+ */
+export interface DatasetDetail extends Dataset {
+  versions: DatasetVersion[];
+}
+
+/**
+ * 修改数据集请求体，未填写的字段不修改
+ */
+export interface DatasetUpdateForm {
+  title?: string;
+  description?: string;
+  category?: string;
+  tags?: string;
+  price?: number;
+  isFree?: boolean;
+  license?: string;
+}
+
+/**
+ * 数据集修改记录
+ * status: applied 已生效 / pending_chain 价格待链上确认 / confirmed 链上已确认 / superseded 已取代
+ */
+export interface DatasetEdit {
+  id: number;
+  datasetId: number;
+  userId: number | null;
+  source: 'user' | 'chain';
+  changes: Record<string, { old: unknown; new: unknown }>;
+  pendingPrice: number | null;
+  status: 'applied' | 'pending_chain' | 'confirmed' | 'superseded';
+  txHash: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+/**
+ * 修改数据集响应体，chainAction 为需要卖家调用的合约方法
+ */
+export interface DatasetUpdateResult {
+  dataset: Dataset;
+  edit: DatasetEdit;
+  chainAction?: 'createDataset' | 'updateDataset';
 }
 
 /**
@@ -156,6 +362,24 @@ export interface PaginatedResponse<T> {
   totalPages: number
 }
 
+/**
+ * 数据集列表排序方式（relevance 仅在有搜索关键词时可用）
+ */
+export type DatasetSort = 'newest' | 'downloads' | 'price_asc' | 'price_desc' | 'size' | 'relevance'
+
+/**
+ * 游标分页响应类型：nextCursor 传回 cursor 参数获取下一页，total 仅在 withTotal=true 时返回
+ */
+export interface CursorPageResponse<T> {
+  items: T[]
+  limit: number
+  sort: DatasetSort
+  nextCursor: string
+  hasMore: boolean
+  total?: number
+  totalPages?: number
+}
+
 /**
  * 登录表单类型
  */
@@ -347,16 +571,61 @@ export interface DashboardAnalytics {
 export interface FingerprintDetectionResult {
   matchResult: string
   detectionTime: string
+  report?: FingerprintReport
+}
+
+export type FingerprintDetectStatus = 'no_fingerprint' | 'unknown' | 'matched' | 'conflict'
+
+// 指纹匹配候选交易
+export interface FingerprintCandidate {
+  transactionId: number
+  datasetId: number
+  buyerUserId: number
+  buyerWallet: string
+  txHash: string
+  purchasedAt: string | null
+}
+
+// 指纹匹配报告
+export interface FingerprintReport {
+  recordId: number
+  filename: string
+  fileSize: number
+  detectedAt: string
+  detectionTime: string
+  status: FingerprintDetectStatus
+  version: number
+  timestamp?: string
+  datasetId: number
+  datasetTitle?: string
+  buyerUserId: number
+  buyerWallet: string
+  transactionId: number
+  txHash: string
+  purchasedAt: string | null
+  confidence: number
+  copies: number
+  candidates: FingerprintCandidate[]
 }
 
 // 指纹检测历史记录项
 export interface FingerprintHistoryItem {
+  id: number
   filename: string
   fileSize: number
   timestamp: string
   detectionTime: string
   detectionResult: string
   datasetID?: number
+  version: number
+  status: FingerprintDetectStatus
+  transactionId: number
+  buyerUserId: number
+  buyerWallet: string
+  txHash: string
+  purchasedAt: string | null
+  confidence: number
+  copies: number
   createdAt: string
 }
 